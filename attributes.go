@@ -0,0 +1,247 @@
+package dag
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Attributes are the typed attributes of a vertex or an edge, e.g. for GraphML & GEXF. values are string, bool, int,
+// int64, float32 or float64, they are written as string, boolean, int, long, float & double attributes
+type Attributes map[string]any
+
+// AttributedGraph is a graph with typed vertex & edge attributes
+type AttributedGraph struct {
+	// Graph holds the vertices & edges, the payloads of vertices with attributes are Attributes
+	Graph *Graph
+	// Edges holds the attributes of edges, edges without attributes are omitted
+	Edges map[Edge]Attributes
+}
+
+// VertexAttributes returns the attributes of a vertex
+func (a *AttributedGraph) VertexAttributes(vertex Vertex) Attributes {
+	payload, _ := a.Graph.Payload(vertex)
+	attributes, _ := payload.(Attributes)
+	return attributes
+}
+
+// EdgeAttributes returns the attributes of an edge
+func (a *AttributedGraph) EdgeAttributes(from Vertex, to Vertex) Attributes {
+	return a.Edges[Edge{From: from, To: to}]
+}
+
+// attribute types, named as in GraphML
+const (
+	attributeString  = "string"
+	attributeBoolean = "boolean"
+	attributeInt     = "int"
+	attributeLong    = "long"
+	attributeFloat   = "float"
+	attributeDouble  = "double"
+)
+
+// attributeKey is the definition of an attribute of vertices or edges
+type attributeKey struct {
+	name string
+	kind string
+}
+
+// attributeKind returns the attribute type of a value
+func attributeKind(value any) (string, error) {
+	switch value.(type) {
+	case string:
+		return attributeString, nil
+	case bool:
+		return attributeBoolean, nil
+	case int:
+		return attributeInt, nil
+	case int64:
+		return attributeLong, nil
+	case float32:
+		return attributeFloat, nil
+	case float64:
+		return attributeDouble, nil
+	default:
+		return "", fmt.Errorf("unsupported type %T, expected string, bool, int, int64, float32 or float64", value)
+	}
+}
+
+// formatAttribute formats a value, the value is parsed back exactly by parseAttribute
+func formatAttribute(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatFloat writes infinities as INF & -INF, which the xml schema types of GraphML & GEXF expect
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// parseAttribute parses a value of an attribute type
+func parseAttribute(kind string, text string) (any, error) {
+	switch kind {
+	case attributeString:
+		return text, nil
+	case attributeBoolean:
+		return strconv.ParseBool(text)
+	case attributeInt:
+		i, err := strconv.ParseInt(text, 10, strconv.IntSize)
+		return int(i), err
+	case attributeLong:
+		return strconv.ParseInt(text, 10, 64)
+	case attributeFloat:
+		f, err := strconv.ParseFloat(parseableFloat(text), 32)
+		return float32(f), err
+	case attributeDouble:
+		return strconv.ParseFloat(parseableFloat(text), 64)
+	default:
+		return nil, fmt.Errorf("unknown attribute type %s", kind)
+	}
+}
+
+func parseableFloat(text string) string {
+	switch text {
+	case "INF":
+		return "+Inf"
+	case "-INF":
+		return "-Inf"
+	}
+	return text
+}
+
+// attributeKeys returns the keys of the attributes sorted by name, an attribute must have the same type everywhere
+func attributeKeys(all []Attributes) ([]attributeKey, error) {
+	kinds := map[string]string{}
+	for _, attributes := range all {
+		for name, value := range attributes {
+			kind, err := attributeKind(value)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %s: %s", name, err)
+			}
+			if known, ok := kinds[name]; ok && known != kind {
+				return nil, fmt.Errorf("attribute %s has values of types %s and %s", name, known, kind)
+			}
+			kinds[name] = kind
+		}
+	}
+
+	keys := make([]attributeKey, 0, len(kinds))
+	for name, kind := range kinds {
+		keys = append(keys, attributeKey{name: name, kind: kind})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].name < keys[j].name
+	})
+	return keys, nil
+}
+
+// attributeKeys returns the keys of vertex & edge attributes, attributes are collected in vertex & edge order so
+// conflicts are reported in a stable order
+func (a *AttributedGraph) attributeKeys(vertices []Vertex, edges Edges, payloads map[Vertex]Attributes) ([]attributeKey, []attributeKey, error) {
+	vertexAttributes := make([]Attributes, 0, len(vertices))
+	edgeAttributes := []Attributes{}
+	for _, from := range vertices {
+		vertexAttributes = append(vertexAttributes, payloads[from])
+		for _, to := range edges[from] {
+			edgeAttributes = append(edgeAttributes, a.Edges[Edge{From: from, To: to}])
+		}
+	}
+
+	vertexKeys, err := attributeKeys(vertexAttributes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "vertices")
+	}
+	edgeKeys, err := attributeKeys(edgeAttributes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "edges")
+	}
+	return vertexKeys, edgeKeys, nil
+}
+
+// attributes returns the vertices & edges with their attributes, vertex payloads must be Attributes
+func (a *AttributedGraph) attributes() ([]Vertex, Edges, map[Vertex]Attributes, error) {
+	vertices, edges := a.Graph.snapshot()
+	payloads := make(map[Vertex]Attributes, len(vertices))
+	for _, v := range vertices {
+		if err := validXML(v); err != nil {
+			return nil, nil, nil, fmt.Errorf("vertex %q %s", v, err)
+		}
+		payload, _ := a.Graph.Payload(v)
+		switch attributes := payload.(type) {
+		case nil:
+		case Attributes:
+			payloads[v] = attributes
+		case map[string]any:
+			payloads[v] = attributes
+		default:
+			return nil, nil, nil, fmt.Errorf("payload of vertex %s is %T, expected Attributes", v, payload)
+		}
+	}
+
+	for edge := range a.Edges {
+		if !includes(edges[edge.From], edge.To) {
+			return nil, nil, nil, fmt.Errorf("edge %s -> %s is not found in graph", edge.From, edge.To)
+		}
+	}
+
+	check := func(attributes Attributes) error {
+		for name, value := range attributes {
+			if err := validXML(name); err != nil {
+				return fmt.Errorf("attribute %q %s", name, err)
+			}
+			if s, ok := value.(string); ok {
+				if err := validXML(s); err != nil {
+					return fmt.Errorf("value of attribute %s %s", name, err)
+				}
+			}
+		}
+		return nil
+	}
+	for _, attributes := range payloads {
+		if err := check(attributes); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, attributes := range a.Edges {
+		if err := check(attributes); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return vertices, edges, payloads, nil
+}
+
+// validXML returns an error when a string has characters that xml documents cannot hold
+func validXML(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("is not valid utf-8")
+	}
+	for _, r := range s {
+		if r == '\t' || r == '\n' || r == '\r' || r >= 0x20 && r <= 0xD7FF || r >= 0xE000 && r <= 0xFFFD || r >= 0x10000 {
+			continue
+		}
+		return fmt.Errorf("has character %U which xml cannot hold", r)
+	}
+	return nil
+}
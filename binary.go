@@ -0,0 +1,370 @@
+package dag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// BinaryVersion is the version of the binary encoding of graphs
+const BinaryVersion = 1
+
+// binaryMagic starts every binary encoded graph
+const binaryMagic = "DAGB"
+
+// binaryHeaderSize is the size of the magic, version, flags, checksum & body length
+const binaryHeaderSize = 4 + 2 + 2 + 4 + 8
+
+// binaryPayloads is the flag of the payload section
+const binaryPayloads = 1 << 0
+
+var binaryChecksum = crc32.MakeTable(crc32.Castagnoli)
+
+// binaryWriter appends uvarints & strings to a body
+type binaryWriter struct {
+	bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (w *binaryWriter) uvarint(value uint64) {
+	n := binary.PutUvarint(w.scratch[:], value)
+	w.Write(w.scratch[:n])
+}
+
+func (w *binaryWriter) string(value string) {
+	w.uvarint(uint64(len(value)))
+	w.WriteString(value)
+}
+
+// WriteBinary writes the graph in a compact binary encoding for large graphs, it is smaller & faster to load than json.
+//
+// the encoding starts with a header of the magic "DAGB", the version, flags, the CRC-32C checksum of the body & the length
+// of the body. the body holds a string table of vertices in insertion order, the edges as uvarint encoded compressed
+// sparse rows, i.e. the number of next vertices of each vertex followed by the string table indices of all next vertices,
+// & a payload section of json encoded payloads that is only written when vertices have payloads
+func (g *Graph) WriteBinary(w io.Writer) error {
+	g.mu.RLock()
+	body := &binaryWriter{}
+	indices := make(map[Vertex]uint64, len(g.vertices))
+	body.uvarint(uint64(len(g.vertices)))
+	for i, vertex := range g.vertices {
+		indices[vertex] = uint64(i)
+		body.string(vertex)
+	}
+	for _, vertex := range g.vertices {
+		body.uvarint(uint64(len(g.edges[vertex])))
+	}
+	for _, vertex := range g.vertices {
+		for _, next := range g.edges[vertex] {
+			body.uvarint(indices[next])
+		}
+	}
+
+	var flags uint16
+	if len(g.payloads) > 0 {
+		flags |= binaryPayloads
+		body.uvarint(uint64(len(g.payloads)))
+		for _, vertex := range g.vertices {
+			payload, ok := g.payloads[vertex]
+			if !ok {
+				continue
+			}
+			encoded, err := json.Marshal(payload)
+			if err != nil {
+				g.mu.RUnlock()
+				return errors.Wrap(err, fmt.Sprintf("could not marshal payload of vertex %s", vertex))
+			}
+			body.uvarint(indices[vertex])
+			body.string(string(encoded))
+		}
+	}
+	g.mu.RUnlock()
+
+	header := make([]byte, binaryHeaderSize)
+	copy(header, binaryMagic)
+	binary.LittleEndian.PutUint16(header[4:], BinaryVersion)
+	binary.LittleEndian.PutUint16(header[6:], flags)
+	binary.LittleEndian.PutUint32(header[8:], crc32.Checksum(body.Bytes(), binaryChecksum))
+	binary.LittleEndian.PutUint64(header[12:], uint64(body.Len()))
+	if _, err := w.Write(header); err != nil {
+		return errors.Wrap(err, "could not write binary graph")
+	}
+	if _, err := w.Write(body.Bytes()); err != nil {
+		return errors.Wrap(err, "could not write binary graph")
+	}
+	return nil
+}
+
+// MarshalBinary encodes the graph with WriteBinary
+func (g *Graph) MarshalBinary() ([]byte, error) {
+	var out bytes.Buffer
+	if err := g.WriteBinary(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a graph that is encoded by MarshalBinary, replacing the vertices, edges & payloads of the graph
+func (g *Graph) UnmarshalBinary(data []byte) error {
+	if g.readOnly {
+		return errors.Wrap(errReadOnly(), "could not load binary graph")
+	}
+	loaded, err := LoadBinary(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.vertices, g.edges, g.payloads = loaded.vertices, loaded.edges, loaded.payloads
+	return nil
+}
+
+// LoadBinaryFile loads a graph from a file that is written by WriteBinary
+func LoadBinaryFile(path string) (*Graph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load binary graph")
+	}
+	defer file.Close()
+	return LoadBinary(file)
+}
+
+// LoadBinary loads a graph that is written by WriteBinary. the checksum is verified before decoding & the graph is built
+// without Connect, duplicate vertices & edges are rejected while decoding & cycles are rejected by a single topological
+// pass over all edges. payloads are decoded as json.RawMessage, they can be decoded to their own types afterwards
+func LoadBinary(r io.Reader) (*Graph, error) {
+	header := make([]byte, binaryHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.Wrap(err, "could not load binary graph. header is truncated")
+	}
+	if string(header[:4]) != binaryMagic {
+		return nil, fmt.Errorf("could not load binary graph. invalid magic %q", header[:4])
+	}
+	if version := binary.LittleEndian.Uint16(header[4:]); version != BinaryVersion {
+		return nil, fmt.Errorf("could not load binary graph. unsupported version %d, expected %d", version, BinaryVersion)
+	}
+	flags := binary.LittleEndian.Uint16(header[6:])
+	checksum := binary.LittleEndian.Uint32(header[8:])
+	length := binary.LittleEndian.Uint64(header[12:])
+
+	// the body is read through a limited reader, so a corrupt length can not allocate more than the input
+	body, err := io.ReadAll(io.LimitReader(r, int64(length)))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load binary graph")
+	}
+	if uint64(len(body)) != length {
+		return nil, fmt.Errorf("could not load binary graph. body is truncated, expected %d bytes, got %d", length, len(body))
+	}
+	if crc32.Checksum(body, binaryChecksum) != checksum {
+		return nil, fmt.Errorf("could not load binary graph. checksum mismatch")
+	}
+
+	g, err := decodeBinary(body, flags)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load binary graph")
+	}
+	return g, nil
+}
+
+// binaryReader reads uvarints & strings from a body
+type binaryReader struct {
+	body []byte
+}
+
+func (r *binaryReader) uvarint(what string) (uint64, error) {
+	value, n := binary.Uvarint(r.body)
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid %s", what)
+	}
+	r.body = r.body[n:]
+	return value, nil
+}
+
+// count reads a uvarint that is bounded by the remaining bytes, each counted item takes at least one byte
+func (r *binaryReader) count(what string) (int, error) {
+	value, err := r.uvarint(what)
+	if err != nil {
+		return 0, err
+	}
+	if value > uint64(len(r.body)) {
+		return 0, fmt.Errorf("invalid %s %d", what, value)
+	}
+	return int(value), nil
+}
+
+// index reads a uvarint index of the string table
+func (r *binaryReader) index(what string, vertices []Vertex) (int, error) {
+	value, err := r.uvarint(what)
+	if err != nil {
+		return 0, err
+	}
+	if value >= uint64(len(vertices)) {
+		return 0, fmt.Errorf("%s %d is out of range of %d vertices", what, value, len(vertices))
+	}
+	return int(value), nil
+}
+
+func (r *binaryReader) string(what string) (string, error) {
+	length, err := r.uvarint(what)
+	if err != nil {
+		return "", err
+	}
+	if length > uint64(len(r.body)) {
+		return "", fmt.Errorf("%s is truncated", what)
+	}
+	value := string(r.body[:length])
+	r.body = r.body[length:]
+	return value, nil
+}
+
+// decodeBinary decodes the body of a binary encoded graph
+func decodeBinary(body []byte, flags uint16) (*Graph, error) {
+	r := &binaryReader{body: body}
+	count, err := r.count("vertex count")
+	if err != nil {
+		return nil, err
+	}
+
+	vertices := make([]Vertex, count)
+	edges := make(Edges, count)
+	for i := range vertices {
+		vertex, err := r.string("vertex")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := edges[vertex]; ok {
+			return nil, fmt.Errorf("vertex %s already added. vertices must be unique", vertex)
+		}
+		vertices[i] = vertex
+		edges[vertex] = []Vertex{}
+	}
+
+	degrees := make([]int, count)
+	total := 0
+	for i := range degrees {
+		if degrees[i], err = r.count("edge count"); err != nil {
+			return nil, err
+		}
+		if total += degrees[i]; total > len(r.body) {
+			return nil, fmt.Errorf("invalid edge count %d", total)
+		}
+	}
+
+	// next vertices share one backing array, capped so that Connect can not append into the next vertices of another vertex
+	targets := make([]int, total)
+	next := make([]Vertex, total)
+	seen := make([]int, count)
+	offset := 0
+	for i, degree := range degrees {
+		for j := offset; j < offset+degree; j++ {
+			if targets[j], err = r.index("next vertex", vertices); err != nil {
+				return nil, err
+			}
+			if seen[targets[j]] == i+1 {
+				return nil, fmt.Errorf("edge %s -> %s already exists", vertices[i], vertices[targets[j]])
+			}
+			seen[targets[j]] = i + 1
+			next[j] = vertices[targets[j]]
+		}
+		if degree > 0 {
+			edges[vertices[i]] = next[offset : offset+degree : offset+degree]
+		}
+		offset += degree
+	}
+	if err := binaryCycle(vertices, degrees, targets); err != nil {
+		return nil, err
+	}
+
+	payloads := map[Vertex]any{}
+	if flags&binaryPayloads != 0 {
+		count, err := r.count("payload count")
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			vertex, err := r.index("payload vertex", vertices)
+			if err != nil {
+				return nil, err
+			}
+			payload, err := r.string("payload")
+			if err != nil {
+				return nil, err
+			}
+			if !json.Valid([]byte(payload)) {
+				return nil, fmt.Errorf("payload of vertex %s is not valid json", vertices[vertex])
+			}
+			payloads[vertices[vertex]] = json.RawMessage(payload)
+		}
+	}
+	if len(r.body) > 0 {
+		return nil, fmt.Errorf("%d trailing bytes", len(r.body))
+	}
+
+	return &Graph{vertices: vertices, edges: edges, payloads: payloads}, nil
+}
+
+// binaryCycle sorts the compressed sparse rows topologically & returns an error with a cycle when not all vertices are sorted
+func binaryCycle(vertices []Vertex, degrees []int, targets []int) error {
+	inDegree := make([]int, len(vertices))
+	for _, target := range targets {
+		inDegree[target]++
+	}
+
+	offsets := make([]int, len(vertices)+1)
+	for i, degree := range degrees {
+		offsets[i+1] = offsets[i] + degree
+	}
+
+	sorted := make([]int, 0, len(vertices))
+	for i, degree := range inDegree {
+		if degree == 0 {
+			sorted = append(sorted, i)
+		}
+	}
+	for i := 0; i < len(sorted); i++ {
+		for _, target := range targets[offsets[sorted[i]]:offsets[sorted[i]+1]] {
+			if inDegree[target]--; inDegree[target] == 0 {
+				sorted = append(sorted, target)
+			}
+		}
+	}
+	if len(sorted) == len(vertices) {
+		return nil
+	}
+
+	// every unsorted vertex has an unsorted previous vertex, so walking previous vertices ends in a cycle
+	prev := make([]int, len(vertices))
+	start := -1
+	for from := range vertices {
+		for _, to := range targets[offsets[from]:offsets[from+1]] {
+			if inDegree[from] > 0 && inDegree[to] > 0 {
+				prev[to] = from
+				start = to
+			}
+		}
+	}
+	walked := map[int]int{}
+	walk := []int{}
+	for current := start; ; current = prev[current] {
+		if at, ok := walked[current]; ok {
+			walk = walk[at:]
+			break
+		}
+		walked[current] = len(walk)
+		walk = append(walk, current)
+	}
+
+	cycle := make([]Vertex, 0, len(walk)+1)
+	for i := len(walk) - 1; i >= 0; i-- {
+		cycle = append(cycle, vertices[walk[i]])
+	}
+	cycle = append(cycle, cycle[0])
+	return &CycleError{From: cycle[len(cycle)-2], To: cycle[0], Cycle: cycle}
+}
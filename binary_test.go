@@ -0,0 +1,124 @@
+package dag_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeBinary encodes a body with a valid header
+func encodeBinary(flags uint16, body ...byte) []byte {
+	header := make([]byte, 20)
+	copy(header, "DAGB")
+	binary.LittleEndian.PutUint16(header[4:], dag.BinaryVersion)
+	binary.LittleEndian.PutUint16(header[6:], flags)
+	binary.LittleEndian.PutUint32(header[8:], crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)))
+	binary.LittleEndian.PutUint64(header[12:], uint64(len(body)))
+	return append(header, body...)
+}
+
+func TestBinary(t *testing.T) {
+	t.Run("should encode a string table, compressed sparse rows & payloads", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B", "C"}), dag.WithEdges(dag.Edges{"A": {"B", "C"}}))
+		assert.Nil(t, err)
+		assert.Nil(t, g.SetPayload("C", 42))
+
+		data, err := g.MarshalBinary()
+		assert.Nil(t, err)
+		assert.Equal(t, encodeBinary(1, 3, 1, 'A', 1, 'B', 1, 'C', 2, 0, 0, 1, 2, 1, 2, 2, '4', '2'), data)
+
+		_, err = dag.LoadBinary(bytes.NewReader(encodeBinary(0, 3, 1, 'A', 1, 'B', 1, 'C', 2, 0, 0, 1, 2)))
+		assert.Nil(t, err)
+	})
+
+	t.Run("should round trip vertices, edges & payloads", func(t *testing.T) {
+		g := createGraph()
+		assert.Nil(t, g.SetPayload("F", map[string]string{"cmd": "make"}))
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteBinary(&out))
+		loaded, err := dag.LoadBinary(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, g.Vertices(), loaded.Vertices())
+		assert.Equal(t, g.Edges(), loaded.Edges())
+		payload, ok := loaded.Payload("F")
+		assert.True(t, ok)
+		assert.Equal(t, json.RawMessage(`{"cmd":"make"}`), payload)
+		_, ok = loaded.Payload("A")
+		assert.False(t, ok)
+
+		sorted, err := loaded.TopSort()
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B", "D", "C", "E", "F"}, sorted)
+
+		// next vertices of loaded graphs can be connected without overwriting each other
+		assert.Nil(t, loaded.Add("G"))
+		assert.Nil(t, loaded.Connect("A", "G"))
+		next, _ := loaded.Next("B")
+		assert.Equal(t, []dag.Vertex{"C", "E"}, next)
+	})
+
+	t.Run("should decode into an existing graph", func(t *testing.T) {
+		data, err := createGraph().MarshalBinary()
+		assert.Nil(t, err)
+
+		decoded := &dag.Graph{}
+		assert.Nil(t, decoded.UnmarshalBinary(data))
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F"}, decoded.Vertices())
+	})
+
+	t.Run("should load large graphs", func(t *testing.T) {
+		g, err := dag.New()
+		assert.Nil(t, err)
+		for i := 0; i < 10000; i++ {
+			prev := []dag.Vertex{}
+			for _, distance := range []int{1, 3, 7} {
+				if i >= distance {
+					prev = append(prev, fmt.Sprintf("v%d", i-distance))
+				}
+			}
+			assert.Nil(t, g.Append(fmt.Sprintf("v%d", i), prev))
+		}
+
+		data, err := g.MarshalBinary()
+		assert.Nil(t, err)
+		loaded, err := dag.LoadBinary(bytes.NewReader(data))
+		assert.Nil(t, err)
+		assert.Equal(t, g.Vertices(), loaded.Vertices())
+		assert.Equal(t, g.Edges(), loaded.Edges())
+	})
+
+	t.Run("should return errors for invalid input", func(t *testing.T) {
+		valid := encodeBinary(0, 2, 1, 'A', 1, 'B', 1, 0, 1)
+		corrupt := append([]byte{}, valid...)
+		corrupt[len(corrupt)-1] = 0
+		version := append([]byte{}, valid...)
+		version[4] = 2
+
+		inputs := map[string][]byte{
+			"could not load binary graph. header is truncated: unexpected EOF":             valid[:10],
+			"could not load binary graph. invalid magic \"GRAP\"":                          []byte("GRAPH-------------------"),
+			"could not load binary graph. unsupported version 2, expected 1":               version,
+			"could not load binary graph. body is truncated, expected 8 bytes, got 7":      valid[:len(valid)-1],
+			"could not load binary graph. checksum mismatch":                               corrupt,
+			"could not load binary graph: vertex A already added. vertices must be unique": encodeBinary(0, 2, 1, 'A', 1, 'A', 0, 0),
+			"could not load binary graph: next vertex 2 is out of range of 2 vertices":     encodeBinary(0, 2, 1, 'A', 1, 'B', 1, 0, 2),
+			"could not load binary graph: edge A -> B already exists":                      encodeBinary(0, 2, 1, 'A', 1, 'B', 2, 0, 1, 1),
+			"could not load binary graph: invalid edge count 9":                            encodeBinary(0, 2, 1, 'A', 1, 'B', 9, 0),
+			"could not load binary graph: payload of vertex A is not valid json":           encodeBinary(1, 1, 1, 'A', 0, 1, 0, 1, '{'),
+			"could not load binary graph: 1 trailing bytes":                                encodeBinary(0, 1, 1, 'A', 0, 0),
+			"could not load binary graph: edge A -> A creates a cycle A -> A":              encodeBinary(0, 1, 1, 'A', 1, 0),
+			"could not load binary graph: edge C -> A creates a cycle A -> B -> C -> A":    encodeBinary(0, 4, 1, 'A', 1, 'B', 1, 'C', 1, 'D', 1, 1, 2, 0, 1, 2, 0, 3),
+		}
+		for message, input := range inputs {
+			_, err := dag.LoadBinary(bytes.NewReader(input))
+			assert.EqualError(t, err, message)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Checkpoint is the persisted state of a vertex in a run
+type Checkpoint struct {
+	State  State  `json:"state"`
+	Output []byte `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func newCheckpoint(result VertexResult) Checkpoint {
+	checkpoint := Checkpoint{State: result.State, Output: result.Output}
+	if result.Err != nil {
+		checkpoint.Error = result.Err.Error()
+	}
+	return checkpoint
+}
+
+// CheckpointStore persists the checkpoints of vertices so that an interrupted run can be resumed
+type CheckpointStore interface {
+	// Load returns the checkpoint of a vertex, ok is false when the vertex has no checkpoint
+	Load(vertex Vertex) (checkpoint Checkpoint, ok bool, err error)
+	// Save stores the checkpoint of a vertex, replacing the existing one
+	Save(vertex Vertex, checkpoint Checkpoint) error
+}
+
+// WithCheckpoint sets the checkpoint store of the executor
+//
+// the executor saves the terminal state & output of each vertex to the store. when a run starts,
+// succeeded vertices in the store are not run again unless one of their dependencies runs again
+func WithCheckpoint(store CheckpointStore) ExecutorOptions {
+	return func(e *Executor) error {
+		if store == nil {
+			return fmt.Errorf("checkpoint store is nil")
+		}
+		e.checkpoint = store
+		return nil
+	}
+}
+
+// resume loads the checkpoints of vertices in topological order & marks the reusable ones as succeeded.
+// checkpoints of vertices that will run again are reset, so that a later interruption does not reuse stale descendants
+func (e *Executor) resume(vertices []Vertex, prev Edges, results Results) error {
+	if e.checkpoint == nil {
+		return nil
+	}
+
+	for _, vertex := range vertices {
+		checkpoint, ok, err := e.checkpoint.Load(vertex)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("could not load checkpoint of vertex %s", vertex))
+		}
+		if !ok {
+			continue
+		}
+
+		reusable := checkpoint.State == StateSucceeded && !some(prev[vertex], func(v Vertex) bool {
+			return !results[v].Resumed
+		})
+		if reusable {
+			results[vertex] = VertexResult{State: StateSucceeded, Output: checkpoint.Output, Resumed: true}
+			continue
+		}
+
+		if checkpoint.State != StatePending {
+			if err := e.checkpoint.Save(vertex, Checkpoint{State: StatePending}); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("could not reset checkpoint of vertex %s", vertex))
+			}
+		}
+	}
+	return nil
+}
+
+// DirCheckpointStore is a CheckpointStore that keeps a json file per vertex in a local directory
+type DirCheckpointStore struct {
+	dir string
+}
+
+// NewDirCheckpointStore creates a checkpoint store in the given directory, creating the directory if it does not exist
+func NewDirCheckpointStore(dir string) (*DirCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "could not create checkpoint store")
+	}
+	return &DirCheckpointStore{dir: dir}, nil
+}
+
+// Load returns the checkpoint of a vertex
+func (s *DirCheckpointStore) Load(vertex Vertex) (checkpoint Checkpoint, ok bool, err error) {
+	data, err := os.ReadFile(vertexFile(s.dir, vertex, ".json"))
+	if os.IsNotExist(err) {
+		return Checkpoint{}, false, nil
+	}
+	if err != nil {
+		return Checkpoint{}, false, errors.Wrap(err, "could not read checkpoint")
+	}
+
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return Checkpoint{}, false, errors.Wrap(err, "could not decode checkpoint")
+	}
+	return checkpoint, true, nil
+}
+
+// Save writes the checkpoint of a vertex, replacing the existing checkpoint file atomically
+func (s *DirCheckpointStore) Save(vertex Vertex, checkpoint Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "could not encode checkpoint")
+	}
+
+	if err := writeFileAtomic(vertexFile(s.dir, vertex, ".json"), data); err != nil {
+		return errors.Wrap(err, "could not write checkpoint")
+	}
+	return nil
+}
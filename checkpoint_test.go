@@ -0,0 +1,116 @@
+package dag_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint(t *testing.T) {
+	t.Run("DirCheckpointStore", func(t *testing.T) {
+		t.Run("should return not ok for missing checkpoint", func(t *testing.T) {
+			store, err := dag.NewDirCheckpointStore(t.TempDir())
+			assert.Nil(t, err)
+
+			_, ok, err := store.Load("A")
+			assert.Nil(t, err)
+			assert.False(t, ok)
+		})
+
+		t.Run("should load saved checkpoint", func(t *testing.T) {
+			store, err := dag.NewDirCheckpointStore(t.TempDir())
+			assert.Nil(t, err)
+
+			err = store.Save("a/../b", dag.Checkpoint{State: dag.StateFailed, Error: "failed"})
+			assert.Nil(t, err)
+
+			checkpoint, ok, err := store.Load("a/../b")
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, dag.Checkpoint{State: dag.StateFailed, Error: "failed"}, checkpoint)
+		})
+
+		t.Run("should save checkpoints of long vertex names", func(t *testing.T) {
+			store, err := dag.NewDirCheckpointStore(t.TempDir())
+			assert.Nil(t, err)
+
+			vertex := strings.Repeat("v", 300)
+			assert.Nil(t, store.Save(vertex, dag.Checkpoint{State: dag.StateSucceeded}))
+			checkpoint, ok, err := store.Load(vertex)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, dag.StateSucceeded, checkpoint.State)
+		})
+	})
+
+	t.Run("WithCheckpoint", func(t *testing.T) {
+		t.Run("should return error when store is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithCheckpoint(nil))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should resume failed vertices & their descendants", func(t *testing.T) {
+			store, err := dag.NewDirCheckpointStore(t.TempDir())
+			assert.Nil(t, err)
+
+			var mu sync.Mutex
+			ran := []dag.Vertex{}
+			failing := dag.Vertex("D")
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				mu.Lock()
+				ran = append(ran, vertex)
+				mu.Unlock()
+				if vertex == failing {
+					return nil, fmt.Errorf("%s failed", vertex)
+				}
+				return concatTask(ctx, vertex, inputs)
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithWorkers(1), dag.WithCheckpoint(store))
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.NotNil(t, err)
+			assert.ElementsMatch(t, []dag.Vertex{"A", "B", "C", "D"}, ran)
+
+			checkpoint, ok, err := store.Load("E")
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, dag.StateSkipped, checkpoint.State)
+
+			ran = []dag.Vertex{}
+			failing = ""
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, []dag.Vertex{"D", "E", "F"}, ran)
+			assert.True(t, results["B"].Resumed)
+			assert.False(t, results["E"].Resumed)
+			assert.Equal(t, "A+B+A+D+E+F", string(results["F"].Output))
+		})
+
+		t.Run("should rerun descendants of a vertex that has no checkpoint", func(t *testing.T) {
+			store, err := dag.NewDirCheckpointStore(t.TempDir())
+			assert.Nil(t, err)
+
+			for _, vertex := range []dag.Vertex{"B", "C", "E", "F"} {
+				err := store.Save(vertex, dag.Checkpoint{State: dag.StateSucceeded, Output: []byte("stale")})
+				assert.Nil(t, err)
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), concatTask, dag.WithCheckpoint(store))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			for _, result := range results {
+				assert.False(t, result.Resumed)
+			}
+			assert.Equal(t, "A+B+A+D+E+F", string(results["F"].Output))
+		})
+	})
+}
@@ -0,0 +1,419 @@
+/*
+Command dag inspects & runs graphs that are defined in pipeline files.
+
+Usage:
+
+	dag <command> [flags] [args]
+
+Commands:
+
+	topsort             print vertices in topological order
+	deps <v>            print dependencies of a vertex in topological order
+	rdeps <v>           print reverse dependencies of a vertex in topological order
+	roots               print root vertices
+	leaves              print leaf vertices
+	path <a> <b>        print the shortest path from a vertex to another vertex
+	validate            check that the graph is valid
+	render              print the graph as a diagram, --format dot|mermaid|plantuml|svg|text|ascii
+	                    & --direction TB|LR|BT|RL, text & ascii are limited to --width columns
+	run                 run the commands of the tasks, --jobs N
+
+Graphs are read from the file given with -f, or from stdin when -f is not given or is "-".
+The --input flag selects the file format:
+
+	pipeline  pipeline file in YAML or JSON, the default
+	json      json encoding of a graph
+	binary    binary encoding of a graph
+	dot       Graphviz digraph
+	gomod     output of go mod graph
+	golist    output of go list -deps -json
+	taskfile  Taskfile of version 3
+	make      Makefile
+
+run requires a pipeline file.
+
+Exit codes:
+
+	0  success
+	1  error
+	2  invalid usage
+	3  the graph has a cycle
+	4  a vertex is not found
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"github.com/aacanakin/dag"
+	"github.com/aacanakin/dag/layout"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitCycle
+	exitNotFound
+)
+
+// cliError carries the exit code of a failed command
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func fail(code int, format string, args ...any) error {
+	return &cliError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+type command struct {
+	usage string
+	args  int
+	run   func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"topsort":  {usage: "topsort", run: (*cli).topsort},
+	"deps":     {usage: "deps <v>", args: 1, run: (*cli).deps},
+	"rdeps":    {usage: "rdeps <v>", args: 1, run: (*cli).rdeps},
+	"roots":    {usage: "roots", run: (*cli).roots},
+	"leaves":   {usage: "leaves", run: (*cli).leaves},
+	"path":     {usage: "path <a> <b>", args: 2, run: (*cli).path},
+	"validate": {usage: "validate", run: (*cli).validate},
+	"render":   {usage: "render [--format dot|mermaid|plantuml|svg|text|ascii] [--direction TB|LR|BT|RL] [--width n]", run: (*cli).render},
+	"run":      {usage: "run [--jobs N] [--logs dir]", run: (*cli).execute},
+}
+
+type cli struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	file      string
+	input     string
+	format    string
+	width     int
+	direction string
+	jobs      int
+	logs      string
+
+	pipeline *dag.Pipeline
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: dag <command> [-f file] [flags] [args]")
+	fmt.Fprintln(w, "commands:")
+	for _, name := range []string{"topsort", "deps", "rdeps", "roots", "leaves", "path", "validate", "render", "run"} {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	cmd, exists := commands[args[0]]
+	if !exists {
+		fmt.Fprintf(stderr, "unknown command %s\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	c := &cli{ctx: ctx, stdin: stdin, stdout: stdout, stderr: stderr}
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&c.file, "f", "-", "graph file, - reads from stdin")
+	flags.StringVar(&c.input, "input", "pipeline", "graph file format, pipeline, json, binary, dot, gomod, golist, taskfile or make")
+	flags.StringVar(&c.format, "format", "dot", "diagram format of render, dot, mermaid, plantuml, svg, text or ascii")
+	flags.IntVar(&c.width, "width", dag.DefaultTextWidth, "width limit of text & ascii render, 0 for no limit")
+	flags.StringVar(&c.direction, "direction", "", "diagram direction of render, TB, LR, BT or RL")
+	flags.IntVar(&c.jobs, "jobs", runtime.NumCPU(), "number of tasks that run concurrently")
+	flags.StringVar(&c.logs, "logs", "", "directory of task logs, logs are discarded when empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != cmd.args {
+		fmt.Fprintf(stderr, "usage: dag %s\n", cmd.usage)
+		return exitUsage
+	}
+
+	err := c.load()
+	if err == nil {
+		err = cmd.run(c, flags.Args())
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		if cliErr, ok := err.(*cliError); ok {
+			return cliErr.code
+		}
+		return exitError
+	}
+	return exitOK
+}
+
+// open returns the reader of the graph file
+func (c *cli) open() (io.ReadCloser, error) {
+	if c.file == "-" {
+		return io.NopCloser(c.stdin), nil
+	}
+	return os.Open(c.file)
+}
+
+// load reads the graph, classifying errors by their exit codes
+func (c *cli) load() error {
+	r, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	err = c.read(r)
+	var cliErr *cliError
+	if err == nil || errors.As(err, &cliErr) {
+		return err
+	}
+	return &cliError{code: loadCode(err), err: err}
+}
+
+// read reads the graph in the input format
+func (c *cli) read(r io.Reader) error {
+	switch c.input {
+	case "pipeline":
+		pipeline, err := dag.LoadPipeline(r)
+		if err != nil {
+			return err
+		}
+		c.pipeline = pipeline
+		return nil
+	case "json":
+		g := &dag.Graph{}
+		if err := json.NewDecoder(r).Decode(g); err != nil {
+			return err
+		}
+		c.pipeline = &dag.Pipeline{Pools: dag.Resources{}, Graph: g}
+		return nil
+	case "dot":
+		dot, err := dag.LoadDOT(r)
+		if err != nil {
+			return err
+		}
+		c.pipeline = &dag.Pipeline{Pools: dag.Resources{}, Graph: dot.Graph}
+		return nil
+	case "binary", "gomod", "golist", "taskfile", "make":
+		loaders := map[string]func(io.Reader) (*dag.Graph, error){
+			"binary":   dag.LoadBinary,
+			"gomod":    dag.LoadGoModGraph,
+			"golist":   dag.LoadGoList,
+			"taskfile": dag.LoadTaskfile,
+			"make":     dag.LoadMakefile,
+		}
+		g, err := loaders[c.input](r)
+		if err != nil {
+			return err
+		}
+		c.pipeline = &dag.Pipeline{Pools: dag.Resources{}, Graph: g}
+		return nil
+	default:
+		return fail(exitUsage, "unknown input format %s, expected pipeline, json, binary, dot, gomod, golist, taskfile or make", c.input)
+	}
+}
+
+// loadCode returns the exit code of an error of a loader by the typed errors of the loader
+func loadCode(err error) int {
+	var pipelineErrs dag.PipelineErrors
+	if errors.As(err, &pipelineErrs) {
+		code := exitError
+		for _, e := range pipelineErrs {
+			if e.Kind == dag.PipelineCycleError {
+				return exitCycle
+			}
+			if e.Kind == dag.PipelineUnknownDepError {
+				code = exitNotFound
+			}
+		}
+		return code
+	}
+
+	var cycleErr *dag.CycleError
+	var dotErr *dag.DOTError
+	var loadErr *dag.LoadError
+	var notFoundErr *dag.VertexNotFoundError
+	switch {
+	case errors.As(err, &cycleErr),
+		errors.As(err, &dotErr) && len(dotErr.Cycle) > 0,
+		errors.As(err, &loadErr) && len(loadErr.Cycle) > 0:
+		return exitCycle
+	case errors.As(err, &notFoundErr):
+		return exitNotFound
+	default:
+		return exitError
+	}
+}
+
+func (c *cli) graph() *dag.Graph {
+	return c.pipeline.Graph
+}
+
+func (c *cli) vertex(v dag.Vertex) error {
+	if !c.graph().Exists(v) {
+		return fail(exitNotFound, "vertex %s is not found in graph", v)
+	}
+	return nil
+}
+
+func (c *cli) print(vertices []dag.Vertex, err error) error {
+	if err != nil {
+		return err
+	}
+	for _, v := range vertices {
+		fmt.Fprintln(c.stdout, v)
+	}
+	return nil
+}
+
+func (c *cli) topsort(args []string) error {
+	return c.print(c.graph().TopSort())
+}
+
+func (c *cli) deps(args []string) error {
+	if err := c.vertex(args[0]); err != nil {
+		return err
+	}
+	return c.print(c.graph().Deps(args[0]))
+}
+
+func (c *cli) rdeps(args []string) error {
+	if err := c.vertex(args[0]); err != nil {
+		return err
+	}
+	return c.print(c.graph().ReverseDeps(args[0]))
+}
+
+func (c *cli) roots(args []string) error {
+	return c.print(c.graph().Roots())
+}
+
+func (c *cli) leaves(args []string) error {
+	return c.print(c.graph().Leaves())
+}
+
+func (c *cli) path(args []string) error {
+	for _, v := range args {
+		if err := c.vertex(v); err != nil {
+			return err
+		}
+	}
+	return c.print(c.graph().Path(args[0], args[1]))
+}
+
+func (c *cli) validate(args []string) error {
+	fmt.Fprintf(c.stdout, "ok: %d vertices\n", len(c.graph().Vertices()))
+	return nil
+}
+
+func (c *cli) render(args []string) error {
+	switch c.direction {
+	case "", "TB", "LR", "BT", "RL":
+	default:
+		return fail(exitUsage, "unknown direction %s, expected TB, LR, BT or RL", c.direction)
+	}
+
+	dotOpts, diagramOpts := []dag.DOTOptions{}, []dag.DiagramOptions{}
+	if c.direction != "" {
+		dotOpts = append(dotOpts, dag.WithRankDir(c.direction))
+		diagramOpts = append(diagramOpts, dag.WithDirection(c.direction))
+	}
+
+	var err error
+	switch c.format {
+	case "dot":
+		err = c.graph().WriteDOT(c.stdout, dotOpts...)
+	case "mermaid":
+		err = c.graph().WriteMermaid(c.stdout, diagramOpts...)
+	case "plantuml":
+		err = c.graph().WritePlantUML(c.stdout, diagramOpts...)
+	case "svg":
+		layoutOpts := []layout.Options{}
+		if c.direction != "" {
+			layoutOpts = append(layoutOpts, layout.WithDirection(c.direction))
+		}
+		var l *layout.Layout
+		if l, err = layout.New(c.graph(), layoutOpts...); err == nil {
+			err = l.WriteSVG(c.stdout)
+		}
+	case "text", "ascii":
+		if c.width < 0 {
+			return fail(exitUsage, "width must not be negative, got %d", c.width)
+		}
+		textOpts := []dag.TextOptions{dag.WithWidth(c.width)}
+		if c.format == "ascii" {
+			textOpts = append(textOpts, dag.WithASCII())
+		}
+		err = c.graph().WriteText(c.stdout, textOpts...)
+	default:
+		return fail(exitUsage, "unknown format %s, expected dot, mermaid, plantuml, svg, text or ascii", c.format)
+	}
+	return err
+}
+
+func (c *cli) execute(args []string) error {
+	if c.input != "pipeline" {
+		return fail(exitUsage, "run requires a pipeline file")
+	}
+	if c.jobs < 1 {
+		return fail(exitUsage, "jobs must be positive, got %d", c.jobs)
+	}
+
+	opts := []dag.CommandRunnerOptions{}
+	if c.logs != "" {
+		opts = append(opts, dag.WithLogDir(c.logs))
+	}
+	runner, err := dag.NewCommandRunner(c.pipeline.Command, opts...)
+	if err != nil {
+		return err
+	}
+
+	hook := func(event dag.Event) {
+		if event.Type != dag.EventVertexFinished {
+			return
+		}
+		line := fmt.Sprintf("%-9s %s (%s)", event.State, event.Vertex, event.Duration.Round(time.Millisecond))
+		if event.Error != "" {
+			line += ": " + event.Error
+		}
+		fmt.Fprintln(c.stdout, line)
+	}
+
+	executor, err := dag.NewExecutor(c.graph(), runner.Task, append(c.pipeline.ExecutorOptions(), dag.WithWorkers(c.jobs), dag.WithHook(hook))...)
+	if err != nil {
+		return err
+	}
+
+	_, err = executor.Run(c.ctx)
+	return err
+}
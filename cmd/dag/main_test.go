@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+// A -> B -> C, A -> D -> E -> F, B -> E
+const sampleGraph = `tasks:
+  A: {}
+  B: {deps: [A]}
+  C: {deps: [B]}
+  D: {deps: [A]}
+  E: {deps: [B, D]}
+  F: {deps: [E]}
+`
+
+func execute(stdin string, args ...string) (code int, stdout string, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestCLI(t *testing.T) {
+	t.Run("queries", func(t *testing.T) {
+		expected := map[string]string{
+			"topsort":     "A\nB\nD\nC\nE\nF\n",
+			"deps E":      "A\nB\nD\n",
+			"rdeps B":     "C\nE\nF\n",
+			"roots":       "A\n",
+			"leaves":      "C\nF\n",
+			"path A F":    "A\nB\nE\nF\n",
+			"validate":    "ok: 6 vertices\n",
+			"deps -f - E": "A\nB\nD\n",
+		}
+		for args, output := range expected {
+			args, output := args, output
+			t.Run("should run "+args, func(t *testing.T) {
+				code, stdout, stderr := execute(sampleGraph, strings.Fields(args)...)
+				assert.Equal(t, exitOK, code, stderr)
+				assert.Equal(t, output, stdout)
+			})
+		}
+	})
+
+	t.Run("input", func(t *testing.T) {
+		t.Run("should read json graphs", func(t *testing.T) {
+			graph := `{"version": 1, "vertices": ["A", "B", "C"], "edges": {"A": ["B"], "B": ["C"]}}`
+			code, stdout, stderr := execute(graph, "topsort", "--input", "json")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "A\nB\nC\n", stdout)
+
+			code, _, _ = execute(`{"version": 1, "vertices": ["A", "B"], "edges": {"A": ["B"], "B": ["A"]}}`, "validate", "--input", "json")
+			assert.Equal(t, exitCycle, code)
+			code, _, _ = execute(`{"version": 1, "vertices": ["A"], "edges": {"A": ["X"]}}`, "validate", "--input", "json")
+			assert.Equal(t, exitNotFound, code)
+			code, _, _ = execute(`{"version": 1, "vertices": ["A"], "payloads": {"X": 1}}`, "validate", "--input", "json")
+			assert.Equal(t, exitNotFound, code)
+		})
+
+		t.Run("should read binary graphs", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B", "C"}), dag.WithEdges(dag.Edges{"A": {"B"}, "B": {"C"}}))
+			assert.Nil(t, err)
+			data, err := g.MarshalBinary()
+			assert.Nil(t, err)
+
+			code, stdout, stderr := execute(string(data), "topsort", "--input", "binary")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "A\nB\nC\n", stdout)
+		})
+
+		t.Run("should read dot graphs", func(t *testing.T) {
+			code, stdout, stderr := execute("digraph { A -> B -> C }", "leaves", "--input", "dot")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "C\n", stdout)
+
+			code, _, _ = execute("digraph { A -> B -> A }", "validate", "--input", "dot")
+			assert.Equal(t, exitCycle, code)
+		})
+
+		t.Run("should read go module & package graphs", func(t *testing.T) {
+			code, stdout, stderr := execute("app a@v1\na@v1 b@v1\napp b@v1", "deps", "--input", "gomod", "app")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "b@v1\na@v1\n", stdout)
+
+			code, stdout, stderr = execute(`{"ImportPath": "fmt"} {"ImportPath": "app", "Imports": ["fmt"]}`, "roots", "--input", "golist")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "fmt\n", stdout)
+
+			code, _, _ = execute("a@v1 b@v1\nb@v1 a@v1", "validate", "--input", "gomod")
+			assert.Equal(t, exitCycle, code)
+		})
+
+		t.Run("should read taskfiles & makefiles", func(t *testing.T) {
+			code, stdout, stderr := execute("version: '3'\ntasks:\n  test: {deps: [build]}\n  build: go build\n", "topsort", "--input", "taskfile")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "build\ntest\n", stdout)
+
+			code, _, _ = execute("version: '3'\ntasks:\n  test: {deps: [build]}\n", "topsort", "--input", "taskfile")
+			assert.Equal(t, exitNotFound, code)
+
+			code, stdout, stderr = execute("app: main.o\nmain.o: main.c\n", "deps", "--input", "make", "app")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "main.c\nmain.o\n", stdout)
+
+			code, _, _ = execute("a: b\nb: a\n", "validate", "--input", "make")
+			assert.Equal(t, exitCycle, code)
+		})
+
+		t.Run("should not run json graphs", func(t *testing.T) {
+			code, _, _ := execute(`{"version": 1, "vertices": ["A"]}`, "run", "--input", "json")
+			assert.Equal(t, exitUsage, code)
+		})
+
+		t.Run("should reject unknown inputs", func(t *testing.T) {
+			code, _, _ := execute(sampleGraph, "topsort", "--input", "xml")
+			assert.Equal(t, exitUsage, code)
+		})
+	})
+
+	t.Run("render", func(t *testing.T) {
+		t.Run("should render dot", func(t *testing.T) {
+			code, stdout, _ := execute("tasks: {A: {}, B: {deps: [A]}}", "render")
+			assert.Equal(t, exitOK, code)
+			assert.Equal(t, "digraph {\n  \"A\";\n  \"B\";\n  \"A\" -> \"B\";\n}\n", stdout)
+		})
+
+		t.Run("should render mermaid", func(t *testing.T) {
+			code, stdout, _ := execute("tasks: {A: {}, B: {deps: [A]}}", "render", "--format", "mermaid")
+			assert.Equal(t, exitOK, code)
+			assert.Equal(t, "flowchart TD\n  v0[\"A\"]\n  v1[\"B\"]\n  v0 --> v1\n", stdout)
+		})
+
+		t.Run("should render plantuml with a direction", func(t *testing.T) {
+			code, stdout, _ := execute("tasks: {A: {}, B: {deps: [A]}}", "render", "--format", "plantuml", "--direction", "LR")
+			assert.Equal(t, exitOK, code)
+			assert.Equal(t, "@startuml\nleft to right direction\nrectangle \"A\" as v0\nrectangle \"B\" as v1\nv0 --> v1\n@enduml\n", stdout)
+
+			code, _, _ = execute("tasks: {A: {}}", "render", "--direction", "UP")
+			assert.Equal(t, exitUsage, code)
+		})
+
+		t.Run("should render svg", func(t *testing.T) {
+			code, stdout, _ := execute("tasks: {A: {}, B: {deps: [A]}}", "render", "--format", "svg", "--direction", "LR")
+			assert.Equal(t, exitOK, code)
+			assert.True(t, strings.HasPrefix(stdout, "<svg "))
+			assert.Contains(t, stdout, `data-vertex="B"`)
+		})
+
+		t.Run("should render text", func(t *testing.T) {
+			code, stdout, _ := execute("tasks: {A: {}, B: {deps: [A]}, C: {deps: [A]}, D: {deps: [A]}}", "render", "--format", "ascii", "--width", "6")
+			assert.Equal(t, exitOK, code)
+			assert.Equal(t, "A\n|-- B\n|-- C\n`-- D\n", stdout)
+		})
+
+		t.Run("should reject unknown formats", func(t *testing.T) {
+			code, _, _ := execute(sampleGraph, "render", "--format", "png")
+			assert.Equal(t, exitUsage, code)
+		})
+	})
+
+	t.Run("run", func(t *testing.T) {
+		t.Run("should run task commands", func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, "pipeline.yml")
+			pipeline := "tasks:\n  hello:\n    command: echo hello > out.txt\n    dir: " + dir + "\n"
+			assert.Nil(t, os.WriteFile(file, []byte(pipeline), 0o644))
+
+			code, stdout, stderr := execute("", "run", "-f", file, "--jobs", "2")
+			assert.Equal(t, exitOK, code, stderr)
+			assert.True(t, strings.HasPrefix(stdout, "succeeded hello"))
+
+			out, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+			assert.Nil(t, err)
+			assert.Equal(t, "hello\n", string(out))
+		})
+
+		t.Run("should fail when a task fails", func(t *testing.T) {
+			code, stdout, _ := execute("tasks: {a: {command: exit 1}, b: {deps: [a], command: 'true'}}", "run")
+			assert.Equal(t, exitError, code)
+			assert.Contains(t, stdout, "failed    a")
+			assert.Contains(t, stdout, "skipped   b")
+		})
+
+		t.Run("should reject invalid jobs", func(t *testing.T) {
+			code, _, _ := execute(sampleGraph, "run", "--jobs", "0")
+			assert.Equal(t, exitUsage, code)
+		})
+	})
+
+	t.Run("exit codes", func(t *testing.T) {
+		t.Run("should return usage code for invalid usage", func(t *testing.T) {
+			for _, args := range [][]string{{}, {"unknown"}, {"deps"}, {"topsort", "--unknown"}} {
+				code, _, _ := execute(sampleGraph, args...)
+				assert.Equal(t, exitUsage, code, args)
+			}
+		})
+
+		t.Run("should return cycle code for cyclic graphs", func(t *testing.T) {
+			code, _, stderr := execute("tasks: {a: {deps: [b]}, b: {deps: [a]}}", "validate")
+			assert.Equal(t, exitCycle, code)
+			assert.Contains(t, stderr, "creates a cycle")
+		})
+
+		t.Run("should return not found code for missing vertices", func(t *testing.T) {
+			code, _, _ := execute("tasks: {a: {deps: [b]}}", "validate")
+			assert.Equal(t, exitNotFound, code)
+
+			code, _, stderr := execute(sampleGraph, "deps", "X")
+			assert.Equal(t, exitNotFound, code)
+			assert.Equal(t, "vertex X is not found in graph\n", stderr)
+
+			code, _, _ = execute(sampleGraph, "path", "A", "X")
+			assert.Equal(t, exitNotFound, code)
+		})
+
+		t.Run("should classify typed load errors", func(t *testing.T) {
+			assert.Equal(t, exitCycle, loadCode(fmt.Errorf("could not load binary graph: %w", &dag.CycleError{From: "B", To: "A", Cycle: []dag.Vertex{"A", "B", "A"}})))
+			assert.Equal(t, exitCycle, loadCode(&dag.LoadError{Line: 1, Message: "cycle", Cycle: []dag.Vertex{"a", "a"}}))
+			assert.Equal(t, exitNotFound, loadCode(&dag.LoadError{Line: 1, Message: "unknown task", Err: &dag.VertexNotFoundError{Vertex: "a"}}))
+			assert.Equal(t, exitError, loadCode(&dag.LoadError{Line: 1, Message: "expected a mapping"}))
+			assert.Equal(t, exitError, loadCode(&dag.DOTError{Line: 1, Message: "syntax error"}))
+		})
+
+		t.Run("should return error code for other errors", func(t *testing.T) {
+			code, _, _ := execute(sampleGraph, "path", "F", "A")
+			assert.Equal(t, exitError, code)
+
+			code, _, _ = execute("", "validate", "-f", filepath.Join(t.TempDir(), "missing.yml"))
+			assert.Equal(t, exitError, code)
+		})
+	})
+}
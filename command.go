@@ -0,0 +1,189 @@
+package dag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvVertex is the environment variable that holds the vertex name of a command
+	EnvVertex = "DAG_VERTEX"
+	// EnvOutput is the environment variable that holds the path of the file that a command writes its output json to
+	EnvOutput = "DAG_OUTPUT"
+	// EnvOutputFD is the environment variable that holds the file descriptor of the output file, it is 3 on unix systems
+	EnvOutputFD = "DAG_OUTPUT_FD"
+)
+
+// Command is the spec of an external command that is run for a vertex
+type Command struct {
+	// Args holds the command path & its arguments, e.g. ["go", "build", "./..."]
+	Args []string `json:"args"`
+	// Env holds KEY=value pairs that are added to the environment of the current process
+	Env []string `json:"env,omitempty"`
+	// Dir is the working directory, the working directory of the current process is used when it is empty
+	Dir string `json:"dir,omitempty"`
+}
+
+// CommandFunc returns the command of a vertex
+type CommandFunc func(vertex Vertex) (Command, error)
+
+// CommandInput is the json document that is written to the stdin of a command
+type CommandInput struct {
+	Vertex Vertex `json:"vertex"`
+	// Inputs maps previous vertices to their outputs. outputs that are not valid json are passed as json strings
+	Inputs map[Vertex]json.RawMessage `json:"inputs"`
+}
+
+type CommandRunnerOptions func(*CommandRunner) error
+
+// WithLogDir streams stdout & stderr of commands to per vertex log files in the given directory.
+// logs of retried commands are appended to the same files
+func WithLogDir(dir string) CommandRunnerOptions {
+	return func(r *CommandRunner) error {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errors.Wrap(err, "could not create log dir")
+		}
+		r.logDir = dir
+		return nil
+	}
+}
+
+// CommandRunner runs vertices as external commands
+//
+// the previous vertex outputs are written to the stdin of a command as a CommandInput json document.
+// the command writes its output json to the file at $DAG_OUTPUT or to the file descriptor $DAG_OUTPUT_FD
+type CommandRunner struct {
+	commands CommandFunc
+	logDir   string
+}
+
+// NewCommandRunner creates a runner that runs the command of each vertex
+func NewCommandRunner(commands CommandFunc, opts ...CommandRunnerOptions) (*CommandRunner, error) {
+	if commands == nil {
+		return nil, fmt.Errorf("could not create command runner. command func is nil")
+	}
+
+	r := &CommandRunner{commands: commands}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, errors.Wrap(err, "could not create command runner")
+		}
+	}
+	return r, nil
+}
+
+// LogPaths returns the stdout & stderr log file paths of a vertex, they are empty when no log dir is set
+func (r *CommandRunner) LogPaths(vertex Vertex) (stdout string, stderr string) {
+	if r.logDir == "" {
+		return "", ""
+	}
+	return vertexFile(r.logDir, vertex, ".stdout.log"), vertexFile(r.logDir, vertex, ".stderr.log")
+}
+
+// Task runs the command of a vertex, it is a TaskFunc that can be passed to NewExecutor
+func (r *CommandRunner) Task(ctx context.Context, vertex Vertex, inputs map[Vertex][]byte) ([]byte, error) {
+	command, err := r.commands(vertex)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not get command of vertex %s", vertex))
+	}
+	if len(command.Args) == 0 {
+		return nil, fmt.Errorf("could not run command of vertex %s. args are empty", vertex)
+	}
+
+	stdin, err := encodeCommandInput(vertex, inputs)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not run command of vertex %s", vertex))
+	}
+
+	output, err := os.CreateTemp("", "dag-output-*.json")
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not run command of vertex %s", vertex))
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	cmd := exec.CommandContext(ctx, command.Args[0], command.Args[1:]...)
+	cmd.Dir = command.Dir
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(), command.Env...)
+	cmd.Env = append(cmd.Env, EnvVertex+"="+vertex, EnvOutput+"="+output.Name())
+	if runtime.GOOS != "windows" {
+		cmd.ExtraFiles = []*os.File{output}
+		cmd.Env = append(cmd.Env, EnvOutputFD+"=3")
+	}
+
+	stdout, stderr, closeLogs, err := r.openLogs(vertex)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not run command of vertex %s", vertex))
+	}
+	defer closeLogs()
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("command of vertex %s failed", vertex))
+	}
+
+	data, err := os.ReadFile(output.Name())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not read output of vertex %s", vertex))
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("could not read output of vertex %s. output is not valid json", vertex)
+	}
+	return data, nil
+}
+
+// openLogs opens the log files of a vertex for appending, the output is discarded when no log dir is set
+func (r *CommandRunner) openLogs(vertex Vertex) (stdout io.Writer, stderr io.Writer, closeLogs func(), err error) {
+	if r.logDir == "" {
+		return io.Discard, io.Discard, func() {}, nil
+	}
+
+	stdoutPath, stderrPath := r.LogPaths(vertex)
+	stdoutFile, err := os.OpenFile(stdoutPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stderrFile, err := os.OpenFile(stderrPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		stdoutFile.Close()
+		return nil, nil, nil, err
+	}
+
+	return stdoutFile, stderrFile, func() {
+		stdoutFile.Close()
+		stderrFile.Close()
+	}, nil
+}
+
+func encodeCommandInput(vertex Vertex, inputs map[Vertex][]byte) ([]byte, error) {
+	input := CommandInput{Vertex: vertex, Inputs: make(map[Vertex]json.RawMessage, len(inputs))}
+	for prevVertex, output := range inputs {
+		if len(output) == 0 {
+			input.Inputs[prevVertex] = json.RawMessage("null")
+			continue
+		}
+		if json.Valid(output) {
+			input.Inputs[prevVertex] = output
+			continue
+		}
+
+		encoded, err := json.Marshal(string(output))
+		if err != nil {
+			return nil, err
+		}
+		input.Inputs[prevVertex] = encoded
+	}
+	return json.Marshal(input)
+}
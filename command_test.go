@@ -0,0 +1,103 @@
+package dag_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandRunner(t *testing.T) {
+	t.Run("NewCommandRunner", func(t *testing.T) {
+		t.Run("should return error when command func is nil", func(t *testing.T) {
+			_, err := dag.NewCommandRunner(nil)
+			assert.NotNil(t, err)
+		})
+	})
+
+	t.Run("Task", func(t *testing.T) {
+		t.Run("should pass inputs on stdin & read output from fd", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B"}), dag.WithEdges(dag.Edges{"A": {"B"}}))
+			assert.Nil(t, err)
+
+			commands := map[dag.Vertex]dag.Command{
+				"A": {Args: []string{"sh", "-c", `printf '{"n": %s}' "$N" > "$DAG_OUTPUT"`}, Env: []string{"N=42"}},
+				"B": {Args: []string{"sh", "-c", `cat >&3`}},
+			}
+			runner, err := dag.NewCommandRunner(func(v dag.Vertex) (dag.Command, error) {
+				return commands[v], nil
+			})
+			assert.Nil(t, err)
+
+			executor, err := dag.NewExecutor(g, runner.Task)
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.JSONEq(t, `{"n": 42}`, string(results["A"].Output))
+			assert.JSONEq(t, `{"vertex": "B", "inputs": {"A": {"n": 42}}}`, string(results["B"].Output))
+		})
+
+		t.Run("should stream stdout & stderr to log files", func(t *testing.T) {
+			runner, err := dag.NewCommandRunner(func(v dag.Vertex) (dag.Command, error) {
+				return dag.Command{Args: []string{"sh", "-c", `echo out; echo err >&2; exit 3`}, Dir: os.TempDir()}, nil
+			}, dag.WithLogDir(t.TempDir()))
+			assert.Nil(t, err)
+
+			_, err = runner.Task(context.Background(), "A", nil)
+			assert.EqualError(t, err, "command of vertex A failed: exit status 3")
+
+			stdoutPath, stderrPath := runner.LogPaths("A")
+			stdout, err := os.ReadFile(stdoutPath)
+			assert.Nil(t, err)
+			assert.Equal(t, "out\n", string(stdout))
+			stderr, err := os.ReadFile(stderrPath)
+			assert.Nil(t, err)
+			assert.Equal(t, "err\n", string(stderr))
+		})
+
+		t.Run("should pass non json outputs as strings", func(t *testing.T) {
+			runner, err := dag.NewCommandRunner(func(v dag.Vertex) (dag.Command, error) {
+				return dag.Command{Args: []string{"sh", "-c", `cat > "$DAG_OUTPUT"`}}, nil
+			})
+			assert.Nil(t, err)
+
+			output, err := runner.Task(context.Background(), "B", map[dag.Vertex][]byte{"A": []byte("plain"), "C": nil})
+			assert.Nil(t, err)
+			assert.JSONEq(t, `{"vertex": "B", "inputs": {"A": "plain", "C": null}}`, string(output))
+		})
+
+		t.Run("should return error for invalid output json", func(t *testing.T) {
+			runner, err := dag.NewCommandRunner(func(v dag.Vertex) (dag.Command, error) {
+				return dag.Command{Args: []string{"sh", "-c", `echo not json > "$DAG_OUTPUT"`}}, nil
+			})
+			assert.Nil(t, err)
+
+			_, err = runner.Task(context.Background(), "A", nil)
+			assert.EqualError(t, err, "could not read output of vertex A. output is not valid json")
+		})
+
+		t.Run("should return nil output when command writes nothing", func(t *testing.T) {
+			runner, err := dag.NewCommandRunner(func(v dag.Vertex) (dag.Command, error) {
+				return dag.Command{Args: []string{"true"}}, nil
+			})
+			assert.Nil(t, err)
+
+			output, err := runner.Task(context.Background(), "A", nil)
+			assert.Nil(t, err)
+			assert.Nil(t, output)
+		})
+
+		t.Run("should return error when args are empty", func(t *testing.T) {
+			runner, err := dag.NewCommandRunner(func(v dag.Vertex) (dag.Command, error) {
+				return dag.Command{}, nil
+			})
+			assert.Nil(t, err)
+
+			_, err = runner.Task(context.Background(), "A", nil)
+			assert.NotNil(t, err)
+		})
+	})
+}
@@ -0,0 +1,379 @@
+package dag
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CSVGraph is a graph that is loaded from an edge list or an adjacency matrix CSV
+type CSVGraph struct {
+	Graph *Graph
+	// Weights holds the weights of edges. for edge lists it holds the edges with a weight cell, for adjacency matrices
+	// the cells of all edges
+	Weights map[Edge]float64
+}
+
+// CSVError is an error at a row of a CSV file
+type CSVError struct {
+	// Row is the line at which the offending record starts, counted from 1 including the header, so that records
+	// with quoted line breaks & skipped empty lines are counted by their lines in the file. errors at the end of the
+	// file, e.g. missing rows, are at the line after the last record
+	Row int
+	// Cycle holds the vertices of a cycle for cycle errors, starting & ending with the tail of the offending edge
+	Cycle   []Vertex
+	Message string
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+type CSVOptions func(*csvFormat) error
+
+// WithWeights writes the weights of edges, as a weight column of edge lists & as the cells of adjacency matrices
+func WithWeights(weights map[Edge]float64) CSVOptions {
+	return func(f *csvFormat) error {
+		if weights == nil {
+			return fmt.Errorf("weights are nil")
+		}
+		f.weights = weights
+		return nil
+	}
+}
+
+// WithIsolatedVertices sets whether vertices without edges are kept, they are kept by default. edge lists keep them
+// as rows with an empty to cell
+func WithIsolatedVertices(keep bool) CSVOptions {
+	return func(f *csvFormat) error {
+		f.isolated = keep
+		return nil
+	}
+}
+
+// WithHeader sets whether edge lists have a header row, they have one by default. adjacency matrices always have one
+func WithHeader(header bool) CSVOptions {
+	return func(f *csvFormat) error {
+		f.header = header
+		return nil
+	}
+}
+
+type csvFormat struct {
+	weights  map[Edge]float64
+	isolated bool
+	header   bool
+}
+
+func newCSVFormat(opts []CSVOptions) (*csvFormat, error) {
+	f := &csvFormat{isolated: true, header: true}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// isolatedVertices returns the vertices without edges
+func isolatedVertices(vertices []Vertex, edges Edges) map[Vertex]bool {
+	isolated := make(map[Vertex]bool, len(vertices))
+	for _, v := range vertices {
+		if len(edges[v]) == 0 {
+			isolated[v] = true
+		}
+	}
+	for _, v := range vertices {
+		for _, next := range edges[v] {
+			delete(isolated, next)
+		}
+	}
+	return isolated
+}
+
+func formatWeight(weight float64) string {
+	return strconv.FormatFloat(weight, 'g', -1, 64)
+}
+
+// WriteEdgeListCSV writes the edges of the graph as from,to rows in vertex & edge order, e.g. for spreadsheets or
+// pandas.read_csv. a weight column is written with WithWeights, edges without weights have empty weight cells
+//
+//	from,to,weight
+//	A,B,1.5
+//	B,C,
+//	D,
+func (g *Graph) WriteEdgeListCSV(w io.Writer, opts ...CSVOptions) error {
+	f, err := newCSVFormat(opts)
+	if err != nil {
+		return errors.Wrap(err, "could not write edge list")
+	}
+
+	vertices, edges := g.snapshot()
+	isolated := isolatedVertices(vertices, edges)
+	out := csv.NewWriter(w)
+	row := func(from Vertex, to Vertex, weight string) {
+		if f.weights != nil {
+			_ = out.Write([]string{from, to, weight})
+		} else {
+			_ = out.Write([]string{from, to})
+		}
+	}
+
+	if f.header {
+		row("from", "to", "weight")
+	}
+	for _, from := range vertices {
+		if isolated[from] && f.isolated {
+			row(from, "", "")
+		}
+		for _, to := range edges[from] {
+			weight := ""
+			if value, ok := f.weights[Edge{From: from, To: to}]; ok {
+				weight = formatWeight(value)
+			}
+			row(from, to, weight)
+		}
+	}
+	out.Flush()
+	return errors.Wrap(out.Error(), "could not write edge list")
+}
+
+// LoadEdgeListCSVFile loads a graph from an edge list CSV file
+func LoadEdgeListCSVFile(path string, opts ...CSVOptions) (*CSVGraph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load edge list")
+	}
+	defer file.Close()
+	return LoadEdgeListCSV(file, opts...)
+}
+
+// LoadEdgeListCSV loads a graph from from,to rows with an optional weight column, e.g. the output of
+// WriteEdgeListCSV. vertices are added in the order they are mentioned, rows with an empty to cell add isolated
+// vertices. the header row is skipped unless WithHeader(false) is given.
+//
+// invalid rows, duplicate edges & cycles are returned as *CSVError
+func LoadEdgeListCSV(r io.Reader, opts ...CSVOptions) (*CSVGraph, error) {
+	f, err := newCSVFormat(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load edge list")
+	}
+
+	g, err := New()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load edge list")
+	}
+	loaded := &CSVGraph{Graph: g, Weights: map[Edge]float64{}}
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	add := func(v Vertex) {
+		if !g.Exists(v) {
+			_ = g.Add(v)
+		}
+	}
+
+	for first, end := true, 1; ; first = false {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvReadError(err, end)
+		}
+		row, _ := in.FieldPos(0)
+		end = csvEnd(record, row)
+		if first && f.header {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, &CSVError{Row: row, Message: fmt.Sprintf("expected from, to & an optional weight, got %d columns", len(record))}
+		}
+
+		from, to := record[0], record[1]
+		if from == "" {
+			return nil, &CSVError{Row: row, Message: "from is empty"}
+		}
+		if to == "" {
+			if f.isolated {
+				add(from)
+			}
+			continue
+		}
+
+		add(from)
+		add(to)
+		if err := connectCSV(g, from, to, row); err != nil {
+			return nil, err
+		}
+		if len(record) == 3 && record[2] != "" {
+			weight, err := strconv.ParseFloat(record[2], 64)
+			if err != nil {
+				return nil, &CSVError{Row: row, Message: fmt.Sprintf("invalid weight %q", record[2])}
+			}
+			loaded.Weights[Edge{From: from, To: to}] = weight
+		}
+	}
+	return loaded, nil
+}
+
+// connectCSV connects two vertices of a row, returning duplicate edges & cycles as errors
+func connectCSV(g *Graph, from Vertex, to Vertex, row int) error {
+	if next, _ := g.Next(from); includes(next, to) {
+		return &CSVError{Row: row, Message: fmt.Sprintf("duplicate edge %s -> %s", from, to)}
+	}
+	if path, err := g.Path(to, from); err == nil {
+		cycle := append([]Vertex{from}, path...)
+		return &CSVError{Row: row, Cycle: cycle, Message: fmt.Sprintf("edge %s -> %s creates a cycle %s", from, to, strings.Join(cycle, " -> "))}
+	}
+	return g.Connect(from, to)
+}
+
+// csvEnd returns the line after a record that starts at a line
+func csvEnd(record []string, line int) int {
+	for _, field := range record {
+		line += strings.Count(field, "\n")
+	}
+	return line + 1
+}
+
+// csvReadError returns a csv read error at its line, errors without a line are at the end of the read records
+func csvReadError(err error, end int) error {
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return &CSVError{Row: parseErr.StartLine, Message: parseErr.Err.Error()}
+	}
+	return &CSVError{Row: end, Message: err.Error()}
+}
+
+// WriteAdjacencyMatrixCSV writes the graph as a square adjacency matrix with a header row & a header column of
+// vertices. a cell is 1 when there is an edge from the vertex of its row to the vertex of its column & 0 otherwise,
+// with WithWeights cells of weighted edges hold their weights, so edges with a weight of 0 are loaded as missing edges
+//
+//	,A,B,C
+//	A,0,1,1
+//	B,0,0,1
+//	C,0,0,0
+func (g *Graph) WriteAdjacencyMatrixCSV(w io.Writer, opts ...CSVOptions) error {
+	f, err := newCSVFormat(opts)
+	if err != nil {
+		return errors.Wrap(err, "could not write adjacency matrix")
+	}
+
+	vertices, edges := g.snapshot()
+	if !f.isolated {
+		isolated := isolatedVertices(vertices, edges)
+		vertices = filter(vertices, func(v Vertex) bool { return !isolated[v] })
+	}
+
+	out := csv.NewWriter(w)
+	_ = out.Write(append([]string{""}, vertices...))
+	for _, from := range vertices {
+		record := make([]string, 0, len(vertices)+1)
+		record = append(record, from)
+		for _, to := range vertices {
+			cell := "0"
+			if includes(edges[from], to) {
+				cell = "1"
+				if weight, ok := f.weights[Edge{From: from, To: to}]; ok {
+					cell = formatWeight(weight)
+				}
+			}
+			record = append(record, cell)
+		}
+		_ = out.Write(record)
+	}
+	out.Flush()
+	return errors.Wrap(out.Error(), "could not write adjacency matrix")
+}
+
+// LoadAdjacencyMatrixCSVFile loads a graph from an adjacency matrix CSV file
+func LoadAdjacencyMatrixCSVFile(path string, opts ...CSVOptions) (*CSVGraph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load adjacency matrix")
+	}
+	defer file.Close()
+	return LoadAdjacencyMatrixCSV(file, opts...)
+}
+
+// LoadAdjacencyMatrixCSV loads a graph from a square adjacency matrix, e.g. the output of WriteAdjacencyMatrixCSV or
+// pandas.DataFrame.to_csv. rows must be in the order of the header, cells that are empty or 0 mean no edge & the other
+// cells are the weights of edges. vertices are added in header order.
+//
+// matrices that are not square, invalid cells & cycles are returned as *CSVError
+func LoadAdjacencyMatrixCSV(r io.Reader, opts ...CSVOptions) (*CSVGraph, error) {
+	f, err := newCSVFormat(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load adjacency matrix")
+	}
+
+	in := csv.NewReader(r)
+	var records [][]string
+	var rows []int
+	end := 1
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvReadError(err, end)
+		}
+		row, _ := in.FieldPos(0)
+		records, rows = append(records, record), append(rows, row)
+		end = csvEnd(record, row)
+	}
+	if len(records) == 0 {
+		return nil, &CSVError{Row: end, Message: "header is missing"}
+	}
+
+	vertices := records[0][1:]
+	g, err := New()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load adjacency matrix")
+	}
+	for i, v := range vertices {
+		if err := g.Add(v); err != nil {
+			return nil, &CSVError{Row: rows[0], Message: fmt.Sprintf("column %d: %s", i+2, err)}
+		}
+	}
+	if len(records)-1 != len(vertices) {
+		return nil, &CSVError{Row: end, Message: fmt.Sprintf("expected %d rows of vertices, got %d", len(vertices), len(records)-1)}
+	}
+
+	loaded := &CSVGraph{Graph: g, Weights: map[Edge]float64{}}
+	for i, record := range records[1:] {
+		row := rows[i+1]
+		if record[0] != vertices[i] {
+			return nil, &CSVError{Row: row, Message: fmt.Sprintf("expected vertex %s, got %s", vertices[i], record[0])}
+		}
+		for j, cell := range record[1:] {
+			if cell == "" {
+				continue
+			}
+			weight, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return nil, &CSVError{Row: row, Message: fmt.Sprintf("column %d: invalid cell %q", j+2, cell)}
+			}
+			if weight == 0 {
+				continue
+			}
+			if err := connectCSV(g, vertices[i], vertices[j], row); err != nil {
+				return nil, err
+			}
+			loaded.Weights[Edge{From: vertices[i], To: vertices[j]}] = weight
+		}
+	}
+
+	if !f.isolated {
+		edges := g.Edges()
+		for v := range isolatedVertices(vertices, edges) {
+			_, _ = g.Remove(v)
+		}
+	}
+	return loaded, nil
+}
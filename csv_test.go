@@ -0,0 +1,123 @@
+package dag_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdgeListCSV(t *testing.T) {
+	t.Run("should write edges & isolated vertices", func(t *testing.T) {
+		g := createGraph()
+		assert.Nil(t, g.Add("G, H"))
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteEdgeListCSV(&out, dag.WithWeights(map[dag.Edge]float64{{From: "A", To: "B"}: 1.5})))
+		assert.Equal(t, "from,to,weight\nA,B,1.5\nA,D,\nB,C,\nB,E,\nD,E,\nE,F,\n\"G, H\",,\n", out.String())
+
+		out.Reset()
+		assert.Nil(t, g.WriteEdgeListCSV(&out, dag.WithHeader(false), dag.WithIsolatedVertices(false)))
+		assert.Equal(t, "A,B\nA,D\nB,C\nB,E\nD,E\nE,F\n", out.String())
+	})
+
+	t.Run("should round trip vertices, edges & weights", func(t *testing.T) {
+		g := createGraph()
+		assert.Nil(t, g.Add("G"))
+		weights := map[dag.Edge]float64{{From: "A", To: "B"}: 1.5, {From: "E", To: "F"}: -2}
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteEdgeListCSV(&out, dag.WithWeights(weights)))
+		loaded, err := dag.LoadEdgeListCSV(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B", "D", "C", "E", "F", "G"}, loaded.Graph.Vertices())
+		assert.Equal(t, g.Edges(), loaded.Graph.Edges())
+		assert.Equal(t, weights, loaded.Weights)
+	})
+
+	t.Run("should drop isolated vertices", func(t *testing.T) {
+		loaded, err := dag.LoadEdgeListCSV(strings.NewReader("A,B\nC,\n"), dag.WithHeader(false), dag.WithIsolatedVertices(false))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B"}, loaded.Graph.Vertices())
+		assert.Empty(t, loaded.Weights)
+	})
+
+	t.Run("should return errors with rows", func(t *testing.T) {
+		inputs := map[string]string{
+			"from,to\nA,B\nA":        "row 3: expected from, to & an optional weight, got 1 columns",
+			"from,to\nA,B\n,C":       "row 3: from is empty",
+			"from,to\nA,B,x":         "row 2: invalid weight \"x\"",
+			"from,to\nA,B\nA,B":      "row 3: duplicate edge A -> B",
+			"from,to\nA,B\n\"A,C\n":  "row 3: extraneous or missing \" in quoted-field",
+			"from,to\nA,B\nB,C\nC,A": "row 4: edge C -> A creates a cycle C -> A -> B -> C",
+			// rows are lines, so quoted line breaks & empty lines are counted
+			"from,to\n\"A\nB\",C\n\nC,x,y": "row 5: invalid weight \"y\"",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadEdgeListCSV(strings.NewReader(input))
+			assert.EqualError(t, err, message, input)
+		}
+
+		_, err := dag.LoadEdgeListCSV(strings.NewReader("A,A"), dag.WithHeader(false))
+		var csvErr *dag.CSVError
+		assert.True(t, errors.As(err, &csvErr))
+		assert.Equal(t, 1, csvErr.Row)
+		assert.Equal(t, []dag.Vertex{"A", "A"}, csvErr.Cycle)
+	})
+}
+
+func TestAdjacencyMatrixCSV(t *testing.T) {
+	t.Run("should write a square matrix", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B", "C", "D"}), dag.WithEdges(dag.Edges{"A": {"B", "C"}, "B": {"C"}}))
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteAdjacencyMatrixCSV(&out, dag.WithWeights(map[dag.Edge]float64{{From: "A", To: "C"}: 0.5})))
+		assert.Equal(t, ",A,B,C,D\nA,0,1,0.5,0\nB,0,0,1,0\nC,0,0,0,0\nD,0,0,0,0\n", out.String())
+
+		out.Reset()
+		assert.Nil(t, g.WriteAdjacencyMatrixCSV(&out, dag.WithIsolatedVertices(false)))
+		assert.Equal(t, ",A,B,C\nA,0,1,1\nB,0,0,1\nC,0,0,0\n", out.String())
+	})
+
+	t.Run("should round trip vertices & edges", func(t *testing.T) {
+		g := createGraph()
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteAdjacencyMatrixCSV(&out))
+
+		loaded, err := dag.LoadAdjacencyMatrixCSV(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, g.Vertices(), loaded.Graph.Vertices())
+		assert.Equal(t, g.Edges(), loaded.Graph.Edges())
+		assert.Equal(t, 1.0, loaded.Weights[dag.Edge{From: "A", To: "B"}])
+	})
+
+	t.Run("should load weights & drop isolated vertices", func(t *testing.T) {
+		loaded, err := dag.LoadAdjacencyMatrixCSV(strings.NewReader("node,A,B,C\nA,,2.5,0\nB,0,0,0\nC,0,0,0\n"), dag.WithIsolatedVertices(false))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B"}, loaded.Graph.Vertices())
+		assert.Equal(t, map[dag.Edge]float64{{From: "A", To: "B"}: 2.5}, loaded.Weights)
+	})
+
+	t.Run("should return errors with rows", func(t *testing.T) {
+		inputs := map[string]string{
+			"":                     "row 1: header is missing",
+			",A,B\nA,0,1\n":        "row 3: expected 2 rows of vertices, got 1",
+			",A,B\nA,0,1\nB,0\n":   "row 3: wrong number of fields",
+			",A,B\nB,0,1\nA,0,0\n": "row 2: expected vertex A, got B",
+			",A,B\nA,0,x\nB,0,0\n": "row 2: column 3: invalid cell \"x\"",
+			",A,B\nA,0,1\nB,1,0\n": "row 3: edge B -> A creates a cycle B -> A -> B",
+			",A,A\nA,0,0\nA,0,0\n": "row 1: column 3: vertex A already added. vertices must be unique",
+			// rows are lines, so quoted line breaks & empty lines are counted
+			",\"A\nB\",C\n\n\"A\nB\",0,1\nC,0,x\n": "row 6: column 3: invalid cell \"x\"",
+			",A,B\n\"A\n\",0,1\n":                  "row 4: expected 2 rows of vertices, got 1",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadAdjacencyMatrixCSV(strings.NewReader(input))
+			assert.EqualError(t, err, message, input)
+		}
+	})
+}
@@ -0,0 +1,282 @@
+package dag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Shape is the shape of a vertex in Mermaid & PlantUML diagrams
+type Shape string
+
+const (
+	// ShapeBox is a rectangle, it is the default shape
+	ShapeBox Shape = "box"
+	// ShapeRound is a rectangle with rounded corners
+	ShapeRound Shape = "round"
+	// ShapeStadium is a pill shape, it is an oval in PlantUML
+	ShapeStadium Shape = "stadium"
+	ShapeCircle  Shape = "circle"
+	// ShapeDiamond is a rhombus, it is a rectangle in PlantUML which has no diamond element
+	ShapeDiamond  Shape = "diamond"
+	ShapeHexagon  Shape = "hexagon"
+	ShapeDatabase Shape = "database"
+	// ShapeComponent is a subroutine box in Mermaid & a component in PlantUML
+	ShapeComponent Shape = "component"
+)
+
+// mermaidShapes holds the opening & closing brackets of shapes in Mermaid flowcharts
+var mermaidShapes = map[Shape][2]string{
+	ShapeBox:       {"[", "]"},
+	ShapeRound:     {"(", ")"},
+	ShapeStadium:   {"([", "])"},
+	ShapeCircle:    {"((", "))"},
+	ShapeDiamond:   {"{", "}"},
+	ShapeHexagon:   {"{{", "}}"},
+	ShapeDatabase:  {"[(", ")]"},
+	ShapeComponent: {"[[", "]]"},
+}
+
+// plantUMLShapes holds the element keywords of shapes in PlantUML component diagrams
+var plantUMLShapes = map[Shape]string{
+	ShapeBox:       "rectangle",
+	ShapeRound:     "card",
+	ShapeStadium:   "usecase",
+	ShapeCircle:    "circle",
+	ShapeDiamond:   "rectangle",
+	ShapeHexagon:   "hexagon",
+	ShapeDatabase:  "database",
+	ShapeComponent: "component",
+}
+
+// ShapeFunc returns the shape of a vertex, ShapeBox is used when it is empty
+type ShapeFunc func(vertex Vertex) Shape
+
+type DiagramOptions func(*diagram) error
+
+// WithDirection sets the direction of the diagram, one of TB, LR, BT or RL
+func WithDirection(dir string) DiagramOptions {
+	return func(d *diagram) error {
+		if err := validateDirection(dir); err != nil {
+			return err
+		}
+		d.direction = dir
+		return nil
+	}
+}
+
+// WithShapes sets the shapes of vertices
+func WithShapes(shape ShapeFunc) DiagramOptions {
+	return func(d *diagram) error {
+		if shape == nil {
+			return fmt.Errorf("shape func is nil")
+		}
+		d.shape = shape
+		return nil
+	}
+}
+
+// WithGroups groups vertices into labeled subgraphs, vertices with an empty group name are not grouped
+func WithGroups(group ClusterFunc) DiagramOptions {
+	return func(d *diagram) error {
+		if group == nil {
+			return fmt.Errorf("group func is nil")
+		}
+		d.group = group
+		return nil
+	}
+}
+
+type diagram struct {
+	direction string
+	shape     ShapeFunc
+	group     ClusterFunc
+}
+
+// newDiagram applies the options & validates the returned shapes
+func newDiagram(vertices []Vertex, opts []DiagramOptions) (*diagram, map[Vertex]Shape, error) {
+	d := &diagram{direction: "TB"}
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	shapes := make(map[Vertex]Shape, len(vertices))
+	for _, v := range vertices {
+		shape := ShapeBox
+		if d.shape != nil {
+			if s := d.shape(v); s != "" {
+				shape = s
+			}
+		}
+		if _, ok := mermaidShapes[shape]; !ok {
+			return nil, nil, fmt.Errorf("unknown shape %s of vertex %s", shape, v)
+		}
+		shapes[v] = shape
+	}
+	return d, shapes, nil
+}
+
+// diagramWriter writes the statements of a diagram format
+type diagramWriter interface {
+	vertex(nested bool, id string, v Vertex)
+	openGroup(id string, name string)
+	closeGroup()
+	edge(from string, to string)
+}
+
+// write writes the vertices with their groups in vertex order, then the edges. vertices are written by their ids,
+// v0, v1 & so on, so arbitrary vertex strings can be used as labels
+func (d *diagram) write(w diagramWriter, vertices []Vertex, edges Edges) {
+	ids := make(map[Vertex]string, len(vertices))
+	for i, v := range vertices {
+		ids[v] = fmt.Sprintf("v%d", i)
+	}
+
+	groupOf, members := groupVertices(vertices, d.group)
+	written := map[string]bool{}
+	for _, v := range vertices {
+		name, ok := groupOf[v]
+		if !ok {
+			w.vertex(false, ids[v], v)
+			continue
+		}
+		if written[name] {
+			continue
+		}
+
+		w.openGroup(fmt.Sprintf("g%d", len(written)), name)
+		for _, member := range members[name] {
+			w.vertex(true, ids[member], member)
+		}
+		w.closeGroup()
+		written[name] = true
+	}
+
+	for _, from := range vertices {
+		for _, to := range edges[from] {
+			w.edge(ids[from], ids[to])
+		}
+	}
+}
+
+// mermaidQuote escapes labels with Mermaid entity codes, newlines become line breaks
+var mermaidQuote = strings.NewReplacer(
+	`"`, "#quot;", "#", "#35;", "<", "#lt;", ">", "#gt;", "&", "#amp;", "`", "#96;", "\n", "<br>",
+)
+
+type mermaidWriter struct {
+	out    io.Writer
+	shapes map[Vertex]Shape
+}
+
+func (m *mermaidWriter) vertex(nested bool, id string, v Vertex) {
+	indent := "  "
+	if nested {
+		indent = "    "
+	}
+	brackets := mermaidShapes[m.shapes[v]]
+	fmt.Fprintf(m.out, "%s%s%s\"%s\"%s\n", indent, id, brackets[0], mermaidQuote.Replace(v), brackets[1])
+}
+
+func (m *mermaidWriter) openGroup(id string, name string) {
+	fmt.Fprintf(m.out, "  subgraph %s[\"%s\"]\n", id, mermaidQuote.Replace(name))
+}
+
+func (m *mermaidWriter) closeGroup() {
+	fmt.Fprintln(m.out, "  end")
+}
+
+func (m *mermaidWriter) edge(from string, to string) {
+	fmt.Fprintf(m.out, "  %s --> %s\n", from, to)
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, e.g. for Markdown docs
+//
+//	flowchart LR
+//	  v0["A"]
+//	  subgraph g0["build"]
+//	    v1(["B"])
+//	  end
+//	  v0 --> v1
+func (g *Graph) WriteMermaid(w io.Writer, opts ...DiagramOptions) error {
+	vertices, edges := g.snapshot()
+	d, shapes, err := newDiagram(vertices, opts)
+	if err != nil {
+		return errors.Wrap(err, "could not write mermaid")
+	}
+
+	direction := d.direction
+	if direction == "TB" {
+		direction = "TD"
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "flowchart %s\n", direction)
+	d.write(&mermaidWriter{out: out, shapes: shapes}, vertices, edges)
+	return out.Flush()
+}
+
+// plantUMLQuote escapes labels with PlantUML unicode escapes, newlines become line breaks
+var plantUMLQuote = strings.NewReplacer(`\`, `\\`, `"`, "<U+0022>", "<", "<U+003C>", "\n", `\n`)
+
+// plantUMLArrows holds the arrows of directions. PlantUML only has a left to right direction besides the default,
+// the other directions are drawn with directed arrows
+var plantUMLArrows = map[string]string{"TB": "-->", "LR": "-->", "BT": "-up->", "RL": "-left->"}
+
+type plantUMLWriter struct {
+	out    io.Writer
+	shapes map[Vertex]Shape
+	arrow  string
+}
+
+func (p *plantUMLWriter) vertex(nested bool, id string, v Vertex) {
+	indent := ""
+	if nested {
+		indent = "  "
+	}
+	fmt.Fprintf(p.out, "%s%s \"%s\" as %s\n", indent, plantUMLShapes[p.shapes[v]], plantUMLQuote.Replace(v), id)
+}
+
+func (p *plantUMLWriter) openGroup(id string, name string) {
+	fmt.Fprintf(p.out, "package \"%s\" as %s {\n", plantUMLQuote.Replace(name), id)
+}
+
+func (p *plantUMLWriter) closeGroup() {
+	fmt.Fprintln(p.out, "}")
+}
+
+func (p *plantUMLWriter) edge(from string, to string) {
+	fmt.Fprintf(p.out, "%s %s %s\n", from, p.arrow, to)
+}
+
+// WritePlantUML writes the graph as a PlantUML component diagram
+//
+//	@startuml
+//	left to right direction
+//	rectangle "A" as v0
+//	package "build" as g0 {
+//	  usecase "B" as v1
+//	}
+//	v0 --> v1
+//	@enduml
+func (g *Graph) WritePlantUML(w io.Writer, opts ...DiagramOptions) error {
+	vertices, edges := g.snapshot()
+	d, shapes, err := newDiagram(vertices, opts)
+	if err != nil {
+		return errors.Wrap(err, "could not write plantuml")
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "@startuml")
+	if d.direction == "LR" {
+		fmt.Fprintln(out, "left to right direction")
+	}
+	d.write(&plantUMLWriter{out: out, shapes: shapes, arrow: plantUMLArrows[d.direction]}, vertices, edges)
+	fmt.Fprintln(out, "@enduml")
+	return out.Flush()
+}
@@ -0,0 +1,114 @@
+package dag_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagrams(t *testing.T) {
+	newGraph := func(t *testing.T) *dag.Graph {
+		// A -> B -> C, A -> D
+		g, err := dag.New(
+			dag.WithVertices([]dag.Vertex{"A", "B", "C", "D"}),
+			dag.WithEdges(dag.Edges{"A": {"B", "D"}, "B": {"C"}}),
+		)
+		assert.Nil(t, err)
+		return g
+	}
+	shapes := func(v dag.Vertex) dag.Shape {
+		return map[dag.Vertex]dag.Shape{"A": dag.ShapeStadium, "C": dag.ShapeDatabase}[v]
+	}
+	groups := func(v dag.Vertex) string {
+		return map[dag.Vertex]string{"B": "build", "D": "build"}[v]
+	}
+
+	t.Run("WriteMermaid", func(t *testing.T) {
+		t.Run("should write a top down flowchart by default", func(t *testing.T) {
+			var out bytes.Buffer
+			assert.Nil(t, newGraph(t).WriteMermaid(&out))
+			assert.Equal(t, `flowchart TD
+  v0["A"]
+  v1["B"]
+  v2["C"]
+  v3["D"]
+  v0 --> v1
+  v0 --> v3
+  v1 --> v2
+`, out.String())
+		})
+
+		t.Run("should write direction, shapes & groups", func(t *testing.T) {
+			var out bytes.Buffer
+			err := newGraph(t).WriteMermaid(&out, dag.WithDirection("LR"), dag.WithShapes(shapes), dag.WithGroups(groups))
+			assert.Nil(t, err)
+			assert.Equal(t, `flowchart LR
+  v0(["A"])
+  subgraph g0["build"]
+    v1["B"]
+    v3["D"]
+  end
+  v2[("C")]
+  v0 --> v1
+  v0 --> v3
+  v1 --> v2
+`, out.String())
+		})
+
+		t.Run("should escape labels", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"say \"hi\" <b>#1</b> & `x`\nend"}))
+			assert.Nil(t, err)
+
+			var out bytes.Buffer
+			assert.Nil(t, g.WriteMermaid(&out))
+			assert.Equal(t, "flowchart TD\n  v0[\"say #quot;hi#quot; #lt;b#gt;#35;1#lt;/b#gt; #amp; #96;x#96;<br>end\"]\n", out.String())
+		})
+	})
+
+	t.Run("WritePlantUML", func(t *testing.T) {
+		t.Run("should write a component diagram", func(t *testing.T) {
+			var out bytes.Buffer
+			err := newGraph(t).WritePlantUML(&out, dag.WithDirection("LR"), dag.WithShapes(shapes), dag.WithGroups(groups))
+			assert.Nil(t, err)
+			assert.Equal(t, `@startuml
+left to right direction
+usecase "A" as v0
+package "build" as g0 {
+  rectangle "B" as v1
+  rectangle "D" as v3
+}
+database "C" as v2
+v0 --> v1
+v0 --> v3
+v1 --> v2
+@enduml
+`, out.String())
+		})
+
+		t.Run("should draw other directions with directed arrows", func(t *testing.T) {
+			var out bytes.Buffer
+			assert.Nil(t, newGraph(t).WritePlantUML(&out, dag.WithDirection("BT")))
+			assert.Contains(t, out.String(), "v0 -up-> v1\n")
+		})
+
+		t.Run("should escape labels", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"say \"hi\" <b>\\o/\nend"}))
+			assert.Nil(t, err)
+
+			var out bytes.Buffer
+			assert.Nil(t, g.WritePlantUML(&out))
+			assert.Equal(t, "@startuml\nrectangle \"say <U+0022>hi<U+0022> <U+003C>b>\\\\o/\\nend\" as v0\n@enduml\n", out.String())
+		})
+	})
+
+	t.Run("should return error for invalid options", func(t *testing.T) {
+		var out bytes.Buffer
+		err := newGraph(t).WriteMermaid(&out, dag.WithDirection("UP"))
+		assert.EqualError(t, err, "could not write mermaid: unknown direction UP, expected TB, LR, BT or RL")
+
+		err = newGraph(t).WritePlantUML(&out, dag.WithShapes(func(v dag.Vertex) dag.Shape { return "star" }))
+		assert.EqualError(t, err, "could not write plantuml: unknown shape star of vertex A")
+	})
+}
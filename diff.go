@@ -0,0 +1,364 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DiffAddedAttributes are the graphviz attributes of added vertices & edges in GraphDiff.WriteDOT
+var DiffAddedAttributes = DOTAttributes{"color": "forestgreen", "fontcolor": "forestgreen", "penwidth": "2"}
+
+// DiffRemovedAttributes are the graphviz attributes of removed vertices & edges in GraphDiff.WriteDOT
+var DiffRemovedAttributes = DOTAttributes{"color": "red", "fontcolor": "red", "style": "dashed"}
+
+// DiffChangedAttributes are the graphviz attributes of vertices with changed payloads in GraphDiff.WriteDOT
+var DiffChangedAttributes = DOTAttributes{"color": "orange", "penwidth": "2"}
+
+// GraphDiff is the difference of two graphs, it can be applied to a graph as a patch with Graph.Apply
+type GraphDiff struct {
+	// AddedVertices holds the vertices that are only in the second graph, in its vertex order
+	AddedVertices []Vertex
+	// RemovedVertices holds the vertices that are only in the first graph, in its vertex order
+	RemovedVertices []Vertex
+	// AddedEdges holds the edges that are only in the second graph, in its vertex & edge order
+	AddedEdges []Edge
+	// RemovedEdges holds the edges that are only in the first graph, in its vertex & edge order
+	RemovedEdges []Edge
+	// ChangedPayloads holds the payloads of vertices that are not deeply equal in both graphs & the payloads of added
+	// vertices, in the vertex order of the second graph
+	ChangedPayloads []PayloadChange
+}
+
+// PayloadChange is a changed payload of a vertex, Before & After are nil when the vertex has no payload
+type PayloadChange struct {
+	Vertex Vertex
+	Before any
+	After  any
+}
+
+// Diff returns the difference from graph a to graph b, vertices & edges are compared by name & payloads are compared
+// with reflect.DeepEqual, so a vertex without a payload equals a vertex with a nil payload
+func Diff(a *Graph, b *Graph) *GraphDiff {
+	aVertices, aEdges := a.snapshot()
+	bVertices, bEdges := b.snapshot()
+	aPayloads, bPayloads := a.payloadSnapshot(), b.payloadSnapshot()
+
+	diff := &GraphDiff{}
+	for _, vertex := range bVertices {
+		if _, ok := aEdges[vertex]; !ok {
+			diff.AddedVertices = append(diff.AddedVertices, vertex)
+		}
+	}
+	for _, vertex := range aVertices {
+		if _, ok := bEdges[vertex]; !ok {
+			diff.RemovedVertices = append(diff.RemovedVertices, vertex)
+		}
+	}
+
+	diff.AddedEdges = missingEdges(bVertices, bEdges, aEdges)
+	diff.RemovedEdges = missingEdges(aVertices, aEdges, bEdges)
+
+	for _, vertex := range bVertices {
+		if !reflect.DeepEqual(aPayloads[vertex], bPayloads[vertex]) {
+			diff.ChangedPayloads = append(diff.ChangedPayloads, PayloadChange{Vertex: vertex, Before: aPayloads[vertex], After: bPayloads[vertex]})
+		}
+	}
+	return diff
+}
+
+// missingEdges returns the edges of vertices that are not in other, in vertex & edge order
+func missingEdges(vertices []Vertex, edges Edges, other Edges) []Edge {
+	var missing []Edge
+	for _, from := range vertices {
+		if len(edges[from]) == 0 {
+			continue
+		}
+		otherNext := make(map[Vertex]bool, len(other[from]))
+		for _, to := range other[from] {
+			otherNext[to] = true
+		}
+		for _, to := range edges[from] {
+			if !otherNext[to] {
+				missing = append(missing, Edge{From: from, To: to})
+			}
+		}
+	}
+	return missing
+}
+
+// payloadSnapshot returns a copy of the payloads of the graph
+func (g *Graph) payloadSnapshot() map[Vertex]any {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	payloads := make(map[Vertex]any, len(g.payloads))
+	for vertex, payload := range g.payloads {
+		payloads[vertex] = payload
+	}
+	return payloads
+}
+
+// Empty returns whether the graphs of the diff are equal
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedVertices) == 0 && len(d.RemovedVertices) == 0 && len(d.AddedEdges) == 0 &&
+		len(d.RemovedEdges) == 0 && len(d.ChangedPayloads) == 0
+}
+
+// String returns the diff as lines of added (+), removed (-) & changed (~) vertices, edges & payloads
+//
+//	fmt.Print(dag.Diff(before, after))
+//	// + vertex G
+//	// - vertex D
+//	// + edge C -> G
+//	// - edge A -> D
+//	// ~ payload B: none -> {"cmd":"make"}
+func (d *GraphDiff) String() string {
+	var out strings.Builder
+	for _, vertex := range d.AddedVertices {
+		fmt.Fprintf(&out, "+ vertex %s\n", vertex)
+	}
+	for _, vertex := range d.RemovedVertices {
+		fmt.Fprintf(&out, "- vertex %s\n", vertex)
+	}
+	for _, edge := range d.AddedEdges {
+		fmt.Fprintf(&out, "+ edge %s -> %s\n", edge.From, edge.To)
+	}
+	for _, edge := range d.RemovedEdges {
+		fmt.Fprintf(&out, "- edge %s -> %s\n", edge.From, edge.To)
+	}
+	for _, change := range d.ChangedPayloads {
+		fmt.Fprintf(&out, "~ payload %s: %s -> %s\n", change.Vertex, formatPayload(change.Before), formatPayload(change.After))
+	}
+	return out.String()
+}
+
+// formatPayload formats a payload for the text of a diff, json payloads are written as json
+func formatPayload(payload any) string {
+	switch p := payload.(type) {
+	case nil:
+		return "none"
+	case json.RawMessage:
+		return string(p)
+	case string:
+		return p
+	}
+	if encoded, err := json.Marshal(payload); err == nil {
+		return string(encoded)
+	}
+	return fmt.Sprintf("%v", payload)
+}
+
+// WriteDOT writes the union of the base graph, i.e. the first graph of the diff, & the diff in Graphviz DOT format.
+// added vertices & edges are drawn with DiffAddedAttributes, removed ones with DiffRemovedAttributes & vertices with
+// changed payloads with DiffChangedAttributes. the options of Graph.WriteDOT can be given, their attributes are
+// overridden by the attributes of the diff
+func (d *GraphDiff) WriteDOT(w io.Writer, base *Graph, opts ...DOTOptions) error {
+	vertices, edges := base.snapshot()
+	for _, vertex := range append(append([]Vertex{}, d.RemovedVertices...), d.AddedVertices...) {
+		if _, ok := edges[vertex]; !ok {
+			vertices = append(vertices, vertex)
+			edges[vertex] = []Vertex{}
+		}
+	}
+	for _, edge := range append(append([]Edge{}, d.RemovedEdges...), d.AddedEdges...) {
+		if _, ok := edges[edge.From]; !ok {
+			return fmt.Errorf("could not write diff dot. vertex %s is not found in graph", edge.From)
+		}
+		if _, ok := edges[edge.To]; !ok {
+			return fmt.Errorf("could not write diff dot. vertex %s is not found in graph", edge.To)
+		}
+		if !includes(edges[edge.From], edge.To) {
+			edges[edge.From] = append(edges[edge.From], edge.To)
+		}
+	}
+
+	vertexAttributes := map[Vertex]DOTAttributes{}
+	for _, change := range d.ChangedPayloads {
+		vertexAttributes[change.Vertex] = DiffChangedAttributes
+	}
+	for _, vertex := range d.AddedVertices {
+		vertexAttributes[vertex] = DiffAddedAttributes
+	}
+	for _, vertex := range d.RemovedVertices {
+		vertexAttributes[vertex] = DiffRemovedAttributes
+	}
+	edgeAttributes := map[Edge]DOTAttributes{}
+	for _, edge := range d.AddedEdges {
+		edgeAttributes[edge] = DiffAddedAttributes
+	}
+	for _, edge := range d.RemovedEdges {
+		edgeAttributes[edge] = DiffRemovedAttributes
+	}
+
+	// the union can have cycles, e.g. when an edge is reversed, so it is written without Connect
+	union := &Graph{vertices: vertices, edges: edges, payloads: base.payloadSnapshot()}
+	opts = append(opts, WithVertexAttributes(func(vertex Vertex) DOTAttributes {
+		return vertexAttributes[vertex]
+	}), WithEdgeAttributes(func(from Vertex, to Vertex) DOTAttributes {
+		return edgeAttributes[Edge{From: from, To: to}]
+	}))
+	return errors.Wrap(union.WriteDOT(w, opts...), "could not write diff dot")
+}
+
+// Conflict is a change of a diff that does not match the graph it is applied to, Edge is empty for vertex & payload
+// conflicts
+type Conflict struct {
+	Vertex  Vertex
+	Edge    Edge
+	Message string
+}
+
+// ConflictError is returned by Graph.Apply when changes of a diff conflict with the graph
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	messages := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		messages[i] = conflict.Message
+	}
+	return fmt.Sprintf("could not apply diff. %d conflicts: %s", len(e.Conflicts), strings.Join(messages, ", "))
+}
+
+// Apply applies a diff to the graph atomically, e.g. to replay the changes between two builds on another graph.
+//
+// the graph must have the removed vertices & edges & the payloads before the changes, it must not have the added
+// vertices & edges & removed vertices must not have edges that are not removed by the diff. all conflicts are returned
+// as *ConflictError & the graph is not changed, added edges that create cycles are conflicts too
+func (g *Graph) Apply(diff *GraphDiff) error {
+	// conflicts are checked in the transaction, so that writers can not change the graph before the diff is applied
+	err := g.Tx(func(tx *Tx) error {
+		if conflicts := tx.Graph().conflicts(diff); len(conflicts) > 0 {
+			return &ConflictError{Conflicts: conflicts}
+		}
+		for _, edge := range diff.RemovedEdges {
+			if err := tx.DisconnectEdge(edge.From, edge.To); err != nil {
+				return err
+			}
+		}
+		// removed vertices have no edges to kept vertices anymore, so Remove only removes the vertices of the diff
+		for _, vertex := range diff.RemovedVertices {
+			if tx.Graph().Exists(vertex) {
+				if _, err := tx.Remove(vertex); err != nil {
+					return err
+				}
+			}
+		}
+		if len(diff.AddedVertices) > 0 {
+			if err := tx.Add(diff.AddedVertices...); err != nil {
+				return err
+			}
+		}
+		for _, edge := range diff.AddedEdges {
+			if err := tx.Connect(edge.From, edge.To); err != nil {
+				return err
+			}
+		}
+		for _, change := range diff.ChangedPayloads {
+			if err := tx.SetPayload(change.Vertex, change.After); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if _, ok := err.(*ConflictError); ok {
+		return err
+	}
+	return errors.Wrap(err, "could not apply diff")
+}
+
+// conflicts returns the changes of a diff that do not match the graph
+func (g *Graph) conflicts(diff *GraphDiff) []Conflict {
+	vertices, edges := g.snapshot()
+	payloads := g.payloadSnapshot()
+	var conflicts []Conflict
+	vertexConflict := func(vertex Vertex, format string) {
+		conflicts = append(conflicts, Conflict{Vertex: vertex, Message: fmt.Sprintf(format, vertex)})
+	}
+	edgeConflict := func(edge Edge, format string) {
+		conflicts = append(conflicts, Conflict{Edge: edge, Message: fmt.Sprintf(format, edge.From, edge.To)})
+	}
+	exists := func(vertex Vertex) bool {
+		_, ok := edges[vertex]
+		return ok
+	}
+
+	removed, removedEdges := map[Vertex]bool{}, map[Edge]bool{}
+	for _, edge := range diff.RemovedEdges {
+		removedEdges[edge] = true
+		if !includes(edges[edge.From], edge.To) {
+			edgeConflict(edge, "edge %s -> %s does not exist")
+		}
+	}
+	for _, vertex := range diff.RemovedVertices {
+		removed[vertex] = true
+		if !exists(vertex) {
+			vertexConflict(vertex, "vertex %s does not exist")
+		}
+	}
+	for _, from := range vertices {
+		for _, to := range edges[from] {
+			edge := Edge{From: from, To: to}
+			if (removed[from] || removed[to]) && !removedEdges[edge] {
+				edgeConflict(edge, "edge %s -> %s of a removed vertex is not removed")
+			}
+		}
+	}
+
+	added := map[Vertex]bool{}
+	for _, vertex := range diff.AddedVertices {
+		added[vertex] = true
+		if exists(vertex) {
+			vertexConflict(vertex, "vertex %s already exists")
+		}
+	}
+
+	// added edges are connected in a scratch graph of the result in diff order, so that edges that create cycles with
+	// the graph or with earlier added edges are conflicts
+	scratch := &Graph{edges: make(Edges, len(edges)+len(diff.AddedVertices))}
+	for from, next := range edges {
+		if !removed[from] {
+			scratch.edges[from] = filter(next, func(to Vertex) bool { return !removed[to] && !removedEdges[Edge{From: from, To: to}] })
+		}
+	}
+	for _, vertex := range diff.AddedVertices {
+		if _, ok := scratch.edges[vertex]; !ok {
+			scratch.edges[vertex] = []Vertex{}
+		}
+	}
+	for _, edge := range diff.AddedEdges {
+		switch {
+		case (!exists(edge.From) || removed[edge.From]) && !added[edge.From]:
+			conflicts = append(conflicts, Conflict{Edge: edge, Message: fmt.Sprintf("edge %s -> %s: vertex %s does not exist", edge.From, edge.To, edge.From)})
+		case (!exists(edge.To) || removed[edge.To]) && !added[edge.To]:
+			conflicts = append(conflicts, Conflict{Edge: edge, Message: fmt.Sprintf("edge %s -> %s: vertex %s does not exist", edge.From, edge.To, edge.To)})
+		case includes(edges[edge.From], edge.To) && !removedEdges[edge]:
+			edgeConflict(edge, "edge %s -> %s already exists")
+		default:
+			if path, err := scratch.Path(edge.To, edge.From); err == nil {
+				cycle := append([]Vertex{edge.From}, path...)
+				conflicts = append(conflicts, Conflict{Edge: edge, Message: fmt.Sprintf("edge %s -> %s creates a cycle %s", edge.From, edge.To, strings.Join(cycle, " -> "))})
+			} else {
+				scratch.edges[edge.From] = append(scratch.edges[edge.From], edge.To)
+			}
+		}
+	}
+
+	for _, change := range diff.ChangedPayloads {
+		if added[change.Vertex] {
+			continue
+		}
+		if !exists(change.Vertex) || removed[change.Vertex] {
+			vertexConflict(change.Vertex, "vertex %s of a changed payload does not exist")
+		} else if !reflect.DeepEqual(payloads[change.Vertex], change.Before) {
+			vertexConflict(change.Vertex, "payload of vertex %s is changed")
+		}
+	}
+	return conflicts
+}
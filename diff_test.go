@@ -0,0 +1,179 @@
+package dag_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+// changedGraph returns the graph of createGraph with D removed, G added after C & F & a payload on B
+func changedGraph(t *testing.T) *dag.Graph {
+	g := createGraph()
+	assert.Nil(t, g.DisconnectEdge("A", "D"))
+	assert.Nil(t, g.DisconnectEdge("D", "E"))
+	_, err := g.Remove("D")
+	assert.Nil(t, err)
+	assert.Nil(t, g.Append("G", []dag.Vertex{"C", "F"}))
+	assert.Nil(t, g.SetPayload("B", "b"))
+	return g
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("should return changes in graph order", func(t *testing.T) {
+		diff := dag.Diff(createGraph(), changedGraph(t))
+		assert.Equal(t, &dag.GraphDiff{
+			AddedVertices:   []dag.Vertex{"G"},
+			RemovedVertices: []dag.Vertex{"D"},
+			AddedEdges:      []dag.Edge{{From: "C", To: "G"}, {From: "F", To: "G"}},
+			RemovedEdges:    []dag.Edge{{From: "A", To: "D"}, {From: "D", To: "E"}},
+			ChangedPayloads: []dag.PayloadChange{{Vertex: "B", After: "b"}},
+		}, diff)
+		assert.False(t, diff.Empty())
+		assert.True(t, dag.Diff(createGraph(), createGraph()).Empty())
+	})
+
+	t.Run("should include payloads of added vertices", func(t *testing.T) {
+		b := createGraph()
+		assert.Nil(t, b.Append("G", []dag.Vertex{"F"}))
+		assert.Nil(t, b.SetPayload("G", map[string]any{"cmd": "make"}))
+		assert.Nil(t, b.SetPayload("A", nil))
+
+		diff := dag.Diff(createGraph(), b)
+		assert.Equal(t, []dag.PayloadChange{{Vertex: "G", After: map[string]any{"cmd": "make"}}}, diff.ChangedPayloads)
+	})
+
+	t.Run("should write text", func(t *testing.T) {
+		a := createGraph()
+		assert.Nil(t, a.SetPayload("C", map[string]any{"cmd": "make"}))
+		b := changedGraph(t)
+
+		assert.Equal(t, `+ vertex G
+- vertex D
++ edge C -> G
++ edge F -> G
+- edge A -> D
+- edge D -> E
+~ payload B: none -> b
+~ payload C: {"cmd":"make"} -> none
+`, dag.Diff(a, b).String())
+		assert.Empty(t, dag.Diff(a, a).String())
+	})
+
+	t.Run("should write dot overlay", func(t *testing.T) {
+		a := createGraph()
+		diff := dag.Diff(a, changedGraph(t))
+
+		var out bytes.Buffer
+		assert.Nil(t, diff.WriteDOT(&out, a, dag.WithRankDir("LR")))
+		assert.Equal(t, `digraph {
+  rankdir="LR";
+  "A";
+  "B" [color="orange", penwidth="2"];
+  "C";
+  "D" [color="red", fontcolor="red", style="dashed"];
+  "E";
+  "F";
+  "G" [color="forestgreen", fontcolor="forestgreen", penwidth="2"];
+  "A" -> "B";
+  "A" -> "D" [color="red", fontcolor="red", style="dashed"];
+  "B" -> "C";
+  "B" -> "E";
+  "C" -> "G" [color="forestgreen", fontcolor="forestgreen", penwidth="2"];
+  "D" -> "E" [color="red", fontcolor="red", style="dashed"];
+  "E" -> "F";
+  "F" -> "G" [color="forestgreen", fontcolor="forestgreen", penwidth="2"];
+}
+`, out.String())
+	})
+
+	t.Run("should write reversed edges", func(t *testing.T) {
+		a, b := createGraph(), createGraph()
+		assert.Nil(t, b.DisconnectEdge("E", "F"))
+		assert.Nil(t, b.Connect("F", "E"))
+
+		var out bytes.Buffer
+		assert.Nil(t, dag.Diff(a, b).WriteDOT(&out, a))
+		assert.Contains(t, out.String(), `"E" -> "F" [color="red", fontcolor="red", style="dashed"];`)
+		assert.Contains(t, out.String(), `"F" -> "E" [color="forestgreen", fontcolor="forestgreen", penwidth="2"];`)
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Run("should apply diff to another graph", func(t *testing.T) {
+		a, b := createGraph(), changedGraph(t)
+		other := createGraph()
+		assert.Nil(t, other.Append("H", []dag.Vertex{"B"}))
+
+		assert.Nil(t, other.Apply(dag.Diff(a, b)))
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "E", "F", "H", "G"}, other.Vertices())
+		assert.Equal(t, dag.Edges{"A": {"B"}, "B": {"C", "E", "H"}, "C": {"G"}, "E": {"F"}, "F": {"G"}, "G": {}, "H": {}}, other.Edges())
+		payload, _ := other.Payload("B")
+		assert.Equal(t, "b", payload)
+
+		// applying the reverse diff restores the graph
+		assert.Nil(t, other.Apply(dag.Diff(b, a)))
+		expected := createGraph()
+		assert.Nil(t, expected.Append("H", []dag.Vertex{"B"}))
+		assert.True(t, dag.Diff(expected, other).Empty())
+	})
+
+	t.Run("should return all conflicts & keep graph unchanged", func(t *testing.T) {
+		a, b := createGraph(), changedGraph(t)
+		other := createGraph()
+		assert.Nil(t, other.Append("G", []dag.Vertex{"A"}))
+		assert.Nil(t, other.Connect("D", "F"))
+		assert.Nil(t, other.DisconnectEdge("A", "D"))
+		assert.Nil(t, other.SetPayload("B", "changed"))
+
+		err := other.Apply(dag.Diff(a, b))
+		var conflictErr *dag.ConflictError
+		assert.True(t, errors.As(err, &conflictErr))
+		assert.Equal(t, []dag.Conflict{
+			{Edge: dag.Edge{From: "A", To: "D"}, Message: "edge A -> D does not exist"},
+			{Edge: dag.Edge{From: "D", To: "F"}, Message: "edge D -> F of a removed vertex is not removed"},
+			{Vertex: "G", Message: "vertex G already exists"},
+			{Vertex: "B", Message: "payload of vertex B is changed"},
+		}, conflictErr.Conflicts)
+		assert.EqualError(t, err, "could not apply diff. 4 conflicts: edge A -> D does not exist, "+
+			"edge D -> F of a removed vertex is not removed, vertex G already exists, payload of vertex B is changed")
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F", "G"}, other.Vertices())
+	})
+
+	t.Run("should return cycles as conflicts", func(t *testing.T) {
+		a, b := createGraph(), createGraph()
+		assert.Nil(t, b.Connect("C", "F"))
+		other := createGraph()
+		assert.Nil(t, other.Append("G", []dag.Vertex{"F"}))
+		assert.Nil(t, other.Connect("G", "C"))
+		assert.Nil(t, other.SetPayload("A", "a"))
+
+		diff := dag.Diff(a, b)
+		diff.ChangedPayloads = []dag.PayloadChange{{Vertex: "A", Before: "a"}}
+		err := other.Apply(diff)
+		assert.EqualError(t, err, "could not apply diff. 1 conflicts: edge C -> F creates a cycle C -> F -> G -> C")
+		next, _ := other.Next("C")
+		assert.Empty(t, next)
+		payload, _ := other.Payload("A")
+		assert.Equal(t, "a", payload)
+	})
+
+	t.Run("should return cycles of added edges with all other conflicts", func(t *testing.T) {
+		a, b := createGraph(), createGraph()
+		assert.Nil(t, b.Append("G", []dag.Vertex{"C"}))
+		assert.Nil(t, b.Append("H", []dag.Vertex{"G"}))
+		assert.Nil(t, b.Connect("A", "H"))
+		diff := dag.Diff(a, b)
+		diff.AddedEdges = append(diff.AddedEdges, dag.Edge{From: "H", To: "C"}, dag.Edge{From: "B", To: "C"})
+
+		err := createGraph().Apply(diff)
+		var conflictErr *dag.ConflictError
+		assert.True(t, errors.As(err, &conflictErr))
+		assert.Equal(t, []dag.Conflict{
+			{Edge: dag.Edge{From: "H", To: "C"}, Message: "edge H -> C creates a cycle H -> C -> G -> H"},
+			{Edge: dag.Edge{From: "B", To: "C"}, Message: "edge B -> C already exists"},
+		}, conflictErr.Conflicts)
+	})
+}
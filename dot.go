@@ -0,0 +1,327 @@
+package dag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DOTAttributes are the graphviz attributes of a vertex or an edge, e.g. {"color": "red", "label": "build"}.
+// values are written as quoted strings, values of DOTHTML are written as html labels
+type DOTAttributes map[string]string
+
+// dotHTMLPrefix marks the attribute values of DOTHTML, NUL is not valid in DOT, so no quoted string starts with it
+const dotHTMLPrefix = "\x00html:"
+
+// DOTHTML returns an attribute value that is written as an html label, e.g. {"label": DOTHTML("<b>build</b>")} is
+// written as label=<<b>build</b>>. the html is written as is, so it must not be derived from untrusted input
+func DOTHTML(html string) string {
+	return dotHTMLPrefix + html
+}
+
+// ParseDOTHTML returns the html of an attribute value that is created with DOTHTML, ok is false for other values
+func ParseDOTHTML(value string) (html string, ok bool) {
+	if !strings.HasPrefix(value, dotHTMLPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, dotHTMLPrefix), true
+}
+
+// VertexAttributesFunc returns the graphviz attributes of a vertex, nil for no attributes
+type VertexAttributesFunc func(vertex Vertex) DOTAttributes
+
+// EdgeAttributesFunc returns the graphviz attributes of an edge, nil for no attributes
+type EdgeAttributesFunc func(from Vertex, to Vertex) DOTAttributes
+
+// ClusterFunc returns the cluster name of a vertex, vertices with an empty name are not clustered
+type ClusterFunc func(vertex Vertex) string
+
+// DefaultHighlight are the attributes of highlighted vertices & edges when no highlight attributes are given
+var DefaultHighlight = DOTAttributes{"color": "red", "penwidth": "2"}
+
+type DOTOptions func(*dotWriter) error
+
+// WithRankDir sets the direction of the layout, one of TB, LR, BT or RL
+func WithRankDir(dir string) DOTOptions {
+	return func(d *dotWriter) error {
+		if err := validateDirection(dir); err != nil {
+			return err
+		}
+		d.rankDir = dir
+		return nil
+	}
+}
+
+// WithVertexAttributes adds the attributes of vertices, e.g. colours by state or labels by payload.
+// attributes of later options override the attributes of earlier options
+func WithVertexAttributes(attributes VertexAttributesFunc) DOTOptions {
+	return func(d *dotWriter) error {
+		if attributes == nil {
+			return fmt.Errorf("vertex attributes func is nil")
+		}
+		d.vertexAttributes = append(d.vertexAttributes, attributes)
+		return nil
+	}
+}
+
+// WithEdgeAttributes adds the attributes of edges. attributes of later options override the attributes of earlier options
+func WithEdgeAttributes(attributes EdgeAttributesFunc) DOTOptions {
+	return func(d *dotWriter) error {
+		if attributes == nil {
+			return fmt.Errorf("edge attributes func is nil")
+		}
+		d.edgeAttributes = append(d.edgeAttributes, attributes)
+		return nil
+	}
+}
+
+// WithClusters groups vertices into clusters that are drawn as labeled boxes, the cluster name is the label
+func WithClusters(cluster ClusterFunc) DOTOptions {
+	return func(d *dotWriter) error {
+		if cluster == nil {
+			return fmt.Errorf("cluster func is nil")
+		}
+		d.cluster = cluster
+		return nil
+	}
+}
+
+// WithHighlight highlights the given vertices & the edges between them, e.g. a vertex & its Deps
+func WithHighlight(vertices []Vertex) DOTOptions {
+	return func(d *dotWriter) error {
+		for _, v := range vertices {
+			d.highlighted[v] = true
+			d.highlightedSet[v] = true
+		}
+		return nil
+	}
+}
+
+// WithHighlightPath highlights the vertices of a path & the edges between consecutive vertices, e.g. a path that is
+// returned by Path
+func WithHighlightPath(path []Vertex) DOTOptions {
+	return func(d *dotWriter) error {
+		for i, v := range path {
+			d.highlighted[v] = true
+			if i > 0 {
+				d.highlightedEdges[[2]Vertex{path[i-1], v}] = true
+			}
+		}
+		return nil
+	}
+}
+
+// WithHighlightAttributes sets the attributes of highlighted vertices & edges, DefaultHighlight is used when it is not set
+func WithHighlightAttributes(attributes DOTAttributes) DOTOptions {
+	return func(d *dotWriter) error {
+		d.highlight = attributes
+		return nil
+	}
+}
+
+// StateAttributes returns vertex attributes that fill vertices with the colour of their state in results,
+// it can be passed to WithVertexAttributes
+func StateAttributes(results Results) VertexAttributesFunc {
+	colors := map[State]string{
+		StateRunning:   "lightyellow",
+		StateSucceeded: "palegreen",
+		StateFailed:    "lightcoral",
+		StateSkipped:   "lightgray",
+	}
+	return func(vertex Vertex) DOTAttributes {
+		color, ok := colors[results[vertex].State]
+		if !ok {
+			return nil
+		}
+		return DOTAttributes{"style": "filled", "fillcolor": color}
+	}
+}
+
+type dotWriter struct {
+	rankDir          string
+	vertexAttributes []VertexAttributesFunc
+	edgeAttributes   []EdgeAttributesFunc
+	cluster          ClusterFunc
+	highlighted      map[Vertex]bool
+	// highlightedSet holds the vertices of WithHighlight, the edges between them are highlighted
+	highlightedSet map[Vertex]bool
+	// highlightedEdges holds the edges of WithHighlightPath
+	highlightedEdges map[[2]Vertex]bool
+	highlight        DOTAttributes
+}
+
+var dotQuote = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteDOT writes the graph in Graphviz DOT format. vertices are written in insertion order, clustered vertices are
+// written in the cluster of their first vertex & edges are written after all vertices
+//
+//	digraph {
+//	  rankdir="LR";
+//	  subgraph "cluster_0" {
+//	    label="build";
+//	    "A" [color="red", penwidth="2"];
+//	  }
+//	  "B";
+//	  "A" -> "B" [color="red", penwidth="2"];
+//	}
+//
+// the attribute funcs are called without holding the lock of the graph, so they can read the graph, e.g. Payload
+func (g *Graph) WriteDOT(w io.Writer, opts ...DOTOptions) error {
+	d := &dotWriter{
+		highlighted:      map[Vertex]bool{},
+		highlightedSet:   map[Vertex]bool{},
+		highlightedEdges: map[[2]Vertex]bool{},
+		highlight:        DefaultHighlight,
+	}
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return errors.Wrap(err, "could not write dot")
+		}
+	}
+
+	vertices, edges := g.snapshot()
+
+	for v := range d.highlighted {
+		if _, ok := edges[v]; !ok {
+			return fmt.Errorf("could not write dot. vertex %s is not found in graph", v)
+		}
+	}
+
+	clusterOf, clustered := groupVertices(vertices, d.cluster)
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph {")
+	if d.rankDir != "" {
+		fmt.Fprintf(out, "  rankdir=\"%s\";\n", d.rankDir)
+	}
+	written := map[string]bool{}
+	for _, v := range vertices {
+		name, ok := clusterOf[v]
+		if !ok {
+			d.writeVertex(out, "  ", v)
+			continue
+		}
+		if written[name] {
+			continue
+		}
+
+		fmt.Fprintf(out, "  subgraph \"cluster_%d\" {\n", len(written))
+		fmt.Fprintf(out, "    label=\"%s\";\n", dotQuote.Replace(name))
+		for _, member := range clustered[name] {
+			d.writeVertex(out, "    ", member)
+		}
+		fmt.Fprintln(out, "  }")
+		written[name] = true
+	}
+	for _, from := range vertices {
+		for _, to := range edges[from] {
+			attributes := DOTAttributes{}
+			for _, f := range d.edgeAttributes {
+				attributes.merge(f(from, to))
+			}
+			if d.highlightedSet[from] && d.highlightedSet[to] || d.highlightedEdges[[2]Vertex{from, to}] {
+				attributes.merge(d.highlight)
+			}
+			fmt.Fprintf(out, "  \"%s\" -> \"%s\"%s;\n", dotQuote.Replace(from), dotQuote.Replace(to), attributes.list())
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// validateDirection checks if a layout direction is one of TB, LR, BT or RL
+func validateDirection(dir string) error {
+	switch dir {
+	case "TB", "LR", "BT", "RL":
+		return nil
+	default:
+		return fmt.Errorf("unknown direction %s, expected TB, LR, BT or RL", dir)
+	}
+}
+
+// snapshot copies the vertices & edges of the graph, so they can be written without holding the lock
+func (g *Graph) snapshot() ([]Vertex, Edges) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	vertices := append([]Vertex{}, g.vertices...)
+	edges := make(Edges, len(g.edges))
+	for vertex, next := range g.edges {
+		edges[vertex] = append([]Vertex{}, next...)
+	}
+	return vertices, edges
+}
+
+// groupVertices returns the group of each grouped vertex & the members of each group in vertex order
+func groupVertices(vertices []Vertex, group ClusterFunc) (groupOf map[Vertex]string, members map[string][]Vertex) {
+	groupOf, members = map[Vertex]string{}, map[string][]Vertex{}
+	if group == nil {
+		return groupOf, members
+	}
+	for _, v := range vertices {
+		if name := group(v); name != "" {
+			groupOf[v] = name
+			members[name] = append(members[name], v)
+		}
+	}
+	return groupOf, members
+}
+
+func (d *dotWriter) writeVertex(out io.Writer, indent string, v Vertex) {
+	attributes := DOTAttributes{}
+	for _, f := range d.vertexAttributes {
+		attributes.merge(f(v))
+	}
+	if d.highlighted[v] {
+		attributes.merge(d.highlight)
+	}
+	fmt.Fprintf(out, "%s\"%s\"%s;\n", indent, dotQuote.Replace(v), attributes.list())
+}
+
+// list formats the attributes as a DOT attribute list sorted by name, e.g. ` [color="red", label="A"]`. names that
+// are not DOT identifiers, e.g. with spaces or quotes, are quoted. it is empty when there are no attributes
+func (a DOTAttributes) list() string {
+	if len(a) == 0 {
+		return ""
+	}
+
+	names := keys(a)
+	sort.Strings(names)
+	list := make([]string, 0, len(names))
+	for _, name := range names {
+		value := a[name]
+		if !isDOTID(name) {
+			name = fmt.Sprintf("\"%s\"", dotQuote.Replace(name))
+		}
+		if html, ok := ParseDOTHTML(value); ok {
+			list = append(list, fmt.Sprintf("%s=<%s>", name, html))
+			continue
+		}
+		list = append(list, fmt.Sprintf("%s=\"%s\"", name, dotQuote.Replace(value)))
+	}
+	return " [" + strings.Join(list, ", ") + "]"
+}
+
+// isDOTID returns whether a name is a DOT identifier, which is written without quotes
+func isDOTID(name string) bool {
+	if name == "" || !isIDStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIDStart(name[i]) && !isDigit(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// merge sets the attributes of other, overriding the attributes with the same names
+func (a DOTAttributes) merge(other DOTAttributes) {
+	for name, value := range other {
+		a[name] = value
+	}
+}
@@ -0,0 +1,197 @@
+package dag_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDOT(t *testing.T) {
+	newGraph := func(t *testing.T) *dag.Graph {
+		// A -> B -> C, A -> D -> C
+		g, err := dag.New(
+			dag.WithVertices([]dag.Vertex{"A", "B", "C", "D"}),
+			dag.WithEdges(dag.Edges{"A": {"B", "D"}, "B": {"C"}, "D": {"C"}}),
+		)
+		assert.Nil(t, err)
+		return g
+	}
+
+	t.Run("should write vertices & edges in graph order", func(t *testing.T) {
+		var out bytes.Buffer
+		err := newGraph(t).WriteDOT(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, `digraph {
+  "A";
+  "B";
+  "C";
+  "D";
+  "A" -> "B";
+  "A" -> "D";
+  "B" -> "C";
+  "D" -> "C";
+}
+`, out.String())
+	})
+
+	t.Run("should write attributes of vertices & edges", func(t *testing.T) {
+		g := newGraph(t)
+		assert.Nil(t, g.SetPayload("A", "make build"))
+
+		results := dag.Results{"A": {State: dag.StateSucceeded}, "B": {State: dag.StateFailed}}
+		var out bytes.Buffer
+		err := g.WriteDOT(&out,
+			dag.WithRankDir("LR"),
+			dag.WithVertexAttributes(dag.StateAttributes(results)),
+			dag.WithVertexAttributes(func(v dag.Vertex) dag.DOTAttributes {
+				if payload, ok := g.Payload(v); ok {
+					return dag.DOTAttributes{"label": fmt.Sprintf("%s\n%s", v, payload)}
+				}
+				return nil
+			}),
+			dag.WithEdgeAttributes(func(from dag.Vertex, to dag.Vertex) dag.DOTAttributes {
+				if to == "C" {
+					return dag.DOTAttributes{"style": "dashed"}
+				}
+				return nil
+			}),
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, `digraph {
+  rankdir="LR";
+  "A" [fillcolor="palegreen", label="A\nmake build", style="filled"];
+  "B" [fillcolor="lightcoral", style="filled"];
+  "C";
+  "D";
+  "A" -> "B";
+  "A" -> "D";
+  "B" -> "C" [style="dashed"];
+  "D" -> "C" [style="dashed"];
+}
+`, out.String())
+	})
+
+	t.Run("should group vertices into clusters", func(t *testing.T) {
+		groups := map[dag.Vertex]string{"B": "build", "D": "build", "C": "test"}
+		var out bytes.Buffer
+		err := newGraph(t).WriteDOT(&out, dag.WithClusters(func(v dag.Vertex) string {
+			return groups[v]
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, `digraph {
+  "A";
+  subgraph "cluster_0" {
+    label="build";
+    "B";
+    "D";
+  }
+  subgraph "cluster_1" {
+    label="test";
+    "C";
+  }
+  "A" -> "B";
+  "A" -> "D";
+  "B" -> "C";
+  "D" -> "C";
+}
+`, out.String())
+	})
+
+	t.Run("should highlight a path", func(t *testing.T) {
+		g := newGraph(t)
+		path, err := g.Path("A", "C")
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		err = g.WriteDOT(&out, dag.WithHighlightPath(path), dag.WithHighlightAttributes(dag.DOTAttributes{"color": "blue"}))
+		assert.Nil(t, err)
+		assert.Equal(t, `digraph {
+  "A" [color="blue"];
+  "B" [color="blue"];
+  "C" [color="blue"];
+  "D";
+  "A" -> "B" [color="blue"];
+  "A" -> "D";
+  "B" -> "C" [color="blue"];
+  "D" -> "C";
+}
+`, out.String())
+	})
+
+	t.Run("should highlight a subgraph", func(t *testing.T) {
+		g := newGraph(t)
+		deps, err := g.Deps("C")
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		err = g.WriteDOT(&out, dag.WithHighlight(append(deps, "C")))
+		assert.Nil(t, err)
+		assert.Contains(t, out.String(), `"A" -> "B" [color="red", penwidth="2"];`)
+		assert.Contains(t, out.String(), `"D" -> "C" [color="red", penwidth="2"];`)
+	})
+
+	t.Run("should not highlight edges between a path & a subgraph", func(t *testing.T) {
+		g := newGraph(t)
+		path, err := g.Path("A", "C")
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		err = g.WriteDOT(&out, dag.WithHighlightPath(path), dag.WithHighlight([]dag.Vertex{"D"}))
+		assert.Nil(t, err)
+		assert.Contains(t, out.String(), `"D" [color="red", penwidth="2"];`)
+		assert.Contains(t, out.String(), `"A" -> "D";`)
+		assert.Contains(t, out.String(), `"D" -> "C";`)
+	})
+
+	t.Run("should quote names & keep html labels", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{`say "hi"`}))
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		err = g.WriteDOT(&out, dag.WithVertexAttributes(func(v dag.Vertex) dag.DOTAttributes {
+			return dag.DOTAttributes{"label": dag.DOTHTML("<b>hi</b>")}
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, "digraph {\n  \"say \\\"hi\\\"\" [label=<<b>hi</b>>];\n}\n", out.String())
+	})
+
+	t.Run("should quote values in angle brackets", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"A"}))
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		err = g.WriteDOT(&out, dag.WithVertexAttributes(func(v dag.Vertex) dag.DOTAttributes {
+			return dag.DOTAttributes{"label": "<script>", "tooltip": `<a href="x">`}
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, "digraph {\n  \"A\" [label=\"<script>\", tooltip=\"<a href=\\\"x\\\">\"];\n}\n", out.String())
+	})
+
+	t.Run("should quote attribute names that are not identifiers", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"A"}))
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		err = g.WriteDOT(&out, dag.WithVertexAttributes(func(v dag.Vertex) dag.DOTAttributes {
+			return dag.DOTAttributes{"my attr": "1", `a="b`: "2", "_x1": "3"}
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, "digraph {\n  \"A\" [_x1=\"3\", \"a=\\\"b\"=\"2\", \"my attr\"=\"1\"];\n}\n", out.String())
+
+		loaded, err := dag.LoadDOT(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, dag.DOTAttributes{"my attr": "1", `a="b`: "2", "_x1": "3"}, loaded.VertexAttributes("A"))
+	})
+
+	t.Run("should return error for invalid options", func(t *testing.T) {
+		var out bytes.Buffer
+		err := newGraph(t).WriteDOT(&out, dag.WithRankDir("UP"))
+		assert.EqualError(t, err, "could not write dot: unknown direction UP, expected TB, LR, BT or RL")
+
+		err = newGraph(t).WriteDOT(&out, dag.WithHighlight([]dag.Vertex{"X"}))
+		assert.EqualError(t, err, "could not write dot. vertex X is not found in graph")
+	})
+}
@@ -0,0 +1,647 @@
+package dag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DOTGraph is a graph that is loaded from DOT
+type DOTGraph struct {
+	// Name is the id of the digraph, it is empty for anonymous graphs
+	Name string
+	// Attributes holds the attributes of the digraph, attributes of subgraphs are not kept
+	Attributes DOTAttributes
+	// Graph holds the vertices & edges, the payloads of vertices with attributes are DOTAttributes
+	Graph *Graph
+	// Edges holds the attributes of edges, edges without attributes are omitted. ports of edges are kept as
+	// tailport & headport attributes
+	Edges map[Edge]DOTAttributes
+}
+
+// VertexAttributes returns the attributes of a vertex, it can be passed to WithVertexAttributes to write the graph back
+func (d *DOTGraph) VertexAttributes(vertex Vertex) DOTAttributes {
+	payload, _ := d.Graph.Payload(vertex)
+	attributes, _ := payload.(DOTAttributes)
+	return attributes
+}
+
+// EdgeAttributes returns the attributes of an edge, it can be passed to WithEdgeAttributes to write the graph back
+func (d *DOTGraph) EdgeAttributes(from Vertex, to Vertex) DOTAttributes {
+	return d.Edges[Edge{From: from, To: to}]
+}
+
+// DOTError is an error at a position of a DOT file
+type DOTError struct {
+	Line   int
+	Column int
+	// Cycle holds the vertices of a cycle for cycle errors, starting & ending with the head of the offending edge
+	Cycle   []Vertex
+	Message string
+}
+
+func (e *DOTError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// LoadDOTFile loads a graph from a Graphviz DOT file
+func LoadDOTFile(path string) (*DOTGraph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load dot")
+	}
+	defer file.Close()
+	return LoadDOT(file)
+}
+
+// LoadDOT loads a graph from Graphviz DOT, e.g. the output of WriteDOT, terraform graph or bazel query --output graph
+//
+//	strict digraph deps {
+//	  rankdir=LR;                      // attributes of the digraph
+//	  node [shape=box];                // defaults of the following vertices & edges of the (sub)graph
+//	  "build" [label="go build"];      // vertices are created in the order they are mentioned
+//	  generate -> build -> test;       // edge chains
+//	  subgraph cluster_lint { vet; lint }
+//	  {vet lint} -> test [style=dashed] // subgraphs as edge operands connect all of their vertices
+//	}
+//
+// attributes of vertices & edges are merged in statement order. duplicate edges are merged as in strict graphs.
+// quoted strings are unescaped the way WriteDOT escapes them, \\ \" and \n, other escapes such as \l are kept as is.
+// html strings of attribute values are returned as DOTHTML values.
+//
+// undirected graphs & edges, cycles and syntax errors are returned as *DOTError
+func LoadDOT(r io.Reader) (*DOTGraph, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load dot")
+	}
+
+	tokens, err := lexDOT(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := New()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load dot")
+	}
+	p := &dotParser{
+		tokens:     tokens,
+		dot:        &DOTGraph{Attributes: DOTAttributes{}, Graph: g, Edges: map[Edge]DOTAttributes{}},
+		attributes: map[Vertex]DOTAttributes{},
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	for vertex, attributes := range p.attributes {
+		if len(attributes) > 0 {
+			if err := g.SetPayload(vertex, attributes); err != nil {
+				return nil, errors.Wrap(err, "could not load dot")
+			}
+		}
+	}
+	return p.dot, nil
+}
+
+type dotTokenKind int
+
+const (
+	dotEOF dotTokenKind = iota
+	// dotID is an identifier, a numeral or a quoted string
+	dotID
+	dotHTML
+	// dotPunct is one of { } [ ] ; , = : +
+	dotPunct
+	dotEdgeOp
+)
+
+type dotToken struct {
+	kind   dotTokenKind
+	text   string
+	quoted bool
+	line   int
+	column int
+}
+
+// lexDOT splits DOT source into tokens, dropping whitespace, comments & preprocessor lines
+func lexDOT(src string) ([]dotToken, error) {
+	tokens := []dotToken{}
+	line, column := 1, 1
+	lineStart := true
+
+	advance := func(n int) {
+		for _, c := range src[:n] {
+			if c == '\n' {
+				line, column, lineStart = line+1, 1, true
+			} else {
+				column++
+			}
+		}
+		src = src[n:]
+	}
+	fail := func(format string, args ...any) error {
+		return &DOTError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+	}
+
+	for len(src) > 0 {
+		c := src[0]
+		switch {
+		case c == '\n' || c == ' ' || c == '\t' || c == '\r':
+			advance(1)
+			continue
+		case c == '#' && lineStart:
+			advance(lineEnd(src))
+			continue
+		case strings.HasPrefix(src, "//"):
+			advance(lineEnd(src))
+			continue
+		case strings.HasPrefix(src, "/*"):
+			end := strings.Index(src[2:], "*/")
+			if end < 0 {
+				return nil, fail("comment is not terminated")
+			}
+			advance(end + 4)
+			continue
+		}
+
+		lineStart = false
+		token := dotToken{line: line, column: column}
+		n := 0
+		switch {
+		case c == '"':
+			text, length, ok := unquoteDOT(src)
+			if !ok {
+				return nil, fail("quoted string is not terminated")
+			}
+			token.kind, token.text, token.quoted, n = dotID, text, true, length
+		case c == '<':
+			depth := 0
+			for n < len(src) {
+				if src[n] == '<' {
+					depth++
+				} else if src[n] == '>' {
+					depth--
+				}
+				n++
+				if depth == 0 {
+					break
+				}
+			}
+			if depth > 0 {
+				return nil, fail("html string is not terminated")
+			}
+			token.kind, token.text = dotHTML, src[:n]
+		case strings.HasPrefix(src, "->") || strings.HasPrefix(src, "--"):
+			token.kind, token.text, n = dotEdgeOp, src[:2], 2
+		case c == '-' || c == '.' || isDigit(c):
+			n = 1
+			for n < len(src) && (isDigit(src[n]) || src[n] == '.') {
+				n++
+			}
+			token.kind, token.text = dotID, src[:n]
+		case isIDStart(c):
+			for n < len(src) && (isIDStart(src[n]) || isDigit(src[n])) {
+				n++
+			}
+			token.kind, token.text = dotID, src[:n]
+		case strings.IndexByte("{}[];,=:+", c) >= 0:
+			token.kind, token.text, n = dotPunct, src[:1], 1
+		default:
+			return nil, fail("unexpected character %q", c)
+		}
+
+		tokens = append(tokens, token)
+		advance(n)
+	}
+
+	return append(tokens, dotToken{kind: dotEOF, line: line, column: column}), nil
+}
+
+// unquoteDOT reads the quoted string at the start of src, returning its unescaped text & its length in src
+func unquoteDOT(src string) (text string, length int, ok bool) {
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '"':
+			return b.String(), i + 1, true
+		case '\\':
+			if i+1 == len(src) {
+				return "", 0, false
+			}
+			i++
+			switch src[i] {
+			case '"', '\\':
+				b.WriteByte(src[i])
+			case 'n':
+				b.WriteByte('\n')
+			case '\n':
+				// line continuation
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(src[i])
+			}
+		default:
+			b.WriteByte(src[i])
+		}
+	}
+	return "", 0, false
+}
+
+func lineEnd(src string) int {
+	if end := strings.IndexByte(src, '\n'); end >= 0 {
+		return end
+	}
+	return len(src)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIDStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// dotScope holds the node & edge defaults of a (sub)graph
+type dotScope struct {
+	node DOTAttributes
+	edge DOTAttributes
+}
+
+type dotParser struct {
+	tokens     []dotToken
+	pos        int
+	dot        *DOTGraph
+	attributes map[Vertex]DOTAttributes
+	// subgraphs collects the vertices that are mentioned in each open subgraph
+	subgraphs []*[]Vertex
+}
+
+func (p *dotParser) peek() dotToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dotParser) next() dotToken {
+	token := p.tokens[p.pos]
+	if token.kind != dotEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *dotParser) fail(token dotToken, format string, args ...any) *DOTError {
+	return &DOTError{Line: token.line, Column: token.column, Message: fmt.Sprintf(format, args...)}
+}
+
+// is checks if a token is the given punctuation or the given keyword, keywords are case insensitive
+func (t dotToken) is(text string) bool {
+	if t.kind == dotPunct {
+		return t.text == text
+	}
+	return t.kind == dotID && !t.quoted && strings.EqualFold(t.text, text)
+}
+
+func (t dotToken) String() string {
+	switch t.kind {
+	case dotEOF:
+		return "end of file"
+	case dotID:
+		if t.quoted {
+			return fmt.Sprintf("%q", t.text)
+		}
+	}
+	return t.text
+}
+
+func (p *dotParser) expect(text string) error {
+	if token := p.next(); !token.is(text) {
+		return p.fail(token, "expected %s, got %s", text, token)
+	}
+	return nil
+}
+
+// id reads an identifier, a numeral, an html string or a concatenation of quoted strings
+func (p *dotParser) id() (string, error) {
+	token := p.next()
+	if token.kind != dotID && token.kind != dotHTML {
+		return "", p.fail(token, "expected id, got %s", token)
+	}
+
+	text := token.text
+	for token.quoted && p.peek().is("+") {
+		p.next()
+		token = p.next()
+		if token.kind != dotID || !token.quoted {
+			return "", p.fail(token, "expected quoted string, got %s", token)
+		}
+		text += token.text
+	}
+	return text, nil
+}
+
+// value reads an attribute value, html strings are returned as DOTHTML values without their outer angle brackets
+func (p *dotParser) value() (string, error) {
+	if p.peek().kind == dotHTML {
+		token := p.next()
+		return DOTHTML(token.text[1 : len(token.text)-1]), nil
+	}
+	return p.id()
+}
+
+func (p *dotParser) parse() error {
+	if p.peek().is("strict") {
+		p.next()
+	}
+	token := p.next()
+	if token.is("graph") {
+		return p.fail(token, "undirected graphs are not supported, expected digraph")
+	}
+	if !token.is("digraph") {
+		return p.fail(token, "expected digraph, got %s", token)
+	}
+
+	if kind := p.peek().kind; kind == dotID || kind == dotHTML {
+		name, err := p.id()
+		if err != nil {
+			return err
+		}
+		p.dot.Name = name
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.statements(dotScope{node: DOTAttributes{}, edge: DOTAttributes{}}); err != nil {
+		return err
+	}
+	if err := p.expect("}"); err != nil {
+		return err
+	}
+
+	if token := p.peek(); token.kind != dotEOF {
+		return p.fail(token, "unexpected %s after digraph", token)
+	}
+	return nil
+}
+
+// statements reads the statements of a (sub)graph up to its closing brace
+func (p *dotParser) statements(scope dotScope) error {
+	for {
+		token := p.peek()
+		switch {
+		case token.is("}"):
+			return nil
+		case token.kind == dotEOF:
+			return p.fail(token, "expected }, got %s", token)
+		}
+
+		if err := p.statement(&scope); err != nil {
+			return err
+		}
+		if p.peek().is(";") {
+			p.next()
+		}
+	}
+}
+
+func (p *dotParser) statement(scope *dotScope) error {
+	token := p.peek()
+	following := token
+	if token.kind != dotEOF {
+		following = p.tokens[p.pos+1]
+	}
+
+	switch {
+	case token.is("graph") || token.is("node") || token.is("edge"):
+		p.next()
+		attributes, err := p.attributeList()
+		if err != nil {
+			return err
+		}
+		switch {
+		case token.is("node"):
+			scope.node = scope.node.with(attributes)
+		case token.is("edge"):
+			scope.edge = scope.edge.with(attributes)
+		case len(p.subgraphs) == 0:
+			p.dot.Attributes.merge(attributes)
+		}
+		return nil
+
+	case (token.kind == dotID || token.kind == dotHTML) && following.is("="):
+		name, _ := p.id()
+		p.next()
+		value, err := p.value()
+		if err != nil {
+			return err
+		}
+		if len(p.subgraphs) == 0 {
+			p.dot.Attributes[name] = value
+		}
+		return nil
+	}
+
+	vertices, port, err := p.operand(*scope)
+	if err != nil {
+		return err
+	}
+	if p.peek().kind == dotEdgeOp {
+		return p.edges(*scope, vertices, port)
+	}
+
+	attributes, err := p.attributeList()
+	if err != nil {
+		return err
+	}
+	if !token.is("subgraph") && !token.is("{") {
+		p.attributes[vertices[0]].merge(attributes)
+	}
+	return nil
+}
+
+// operand reads a vertex with an optional port or a subgraph, returning the vertices it mentions
+func (p *dotParser) operand(scope dotScope) (vertices []Vertex, port string, err error) {
+	token := p.peek()
+	if token.is("subgraph") || token.is("{") {
+		vertices, err := p.subgraph(scope)
+		return vertices, "", err
+	}
+	if token.kind != dotID && token.kind != dotHTML {
+		return nil, "", p.fail(token, "expected statement, got %s", token)
+	}
+
+	vertex, err := p.id()
+	if err != nil {
+		return nil, "", err
+	}
+	for i := 0; i < 2 && p.peek().is(":"); i++ {
+		p.next()
+		id, err := p.id()
+		if err != nil {
+			return nil, "", err
+		}
+		if port != "" {
+			port += ":"
+		}
+		port += id
+	}
+
+	if err := p.vertex(vertex, scope); err != nil {
+		return nil, "", p.fail(token, "%s", err)
+	}
+	return []Vertex{vertex}, port, nil
+}
+
+func (p *dotParser) subgraph(scope dotScope) ([]Vertex, error) {
+	if p.peek().is("subgraph") {
+		p.next()
+		if kind := p.peek().kind; kind == dotID || kind == dotHTML {
+			if _, err := p.id(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	vertices := []Vertex{}
+	p.subgraphs = append(p.subgraphs, &vertices)
+	err := p.statements(scope)
+	p.subgraphs = p.subgraphs[:len(p.subgraphs)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	return vertices, p.expect("}")
+}
+
+// vertex adds a vertex with the node defaults when it is mentioned first & records it in the open subgraphs
+func (p *dotParser) vertex(vertex Vertex, scope dotScope) error {
+	if _, ok := p.attributes[vertex]; !ok {
+		if err := p.dot.Graph.Add(vertex); err != nil {
+			return err
+		}
+		p.attributes[vertex] = DOTAttributes{}.with(scope.node)
+	}
+
+	for _, subgraph := range p.subgraphs {
+		if !includes(*subgraph, vertex) {
+			*subgraph = append(*subgraph, vertex)
+		}
+	}
+	return nil
+}
+
+// edges reads an edge chain after its first operand & connects each operand to the next one
+func (p *dotParser) edges(scope dotScope, from []Vertex, fromPort string) error {
+	type link struct {
+		op               dotToken
+		from, to         []Vertex
+		fromPort, toPort string
+	}
+
+	links := []link{}
+	for p.peek().kind == dotEdgeOp {
+		op := p.next()
+		if op.text == "--" {
+			return p.fail(op, "undirected edges are not supported, expected ->")
+		}
+		to, toPort, err := p.operand(scope)
+		if err != nil {
+			return err
+		}
+		links = append(links, link{op: op, from: from, to: to, fromPort: fromPort, toPort: toPort})
+		from, fromPort = to, toPort
+	}
+
+	attributes, err := p.attributeList()
+	if err != nil {
+		return err
+	}
+
+	for _, l := range links {
+		edgeAttributes := scope.edge.with(attributes)
+		if l.fromPort != "" {
+			edgeAttributes["tailport"] = l.fromPort
+		}
+		if l.toPort != "" {
+			edgeAttributes["headport"] = l.toPort
+		}
+
+		for _, from := range l.from {
+			for _, to := range l.to {
+				if err := p.connect(l.op, from, to, edgeAttributes); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *dotParser) connect(op dotToken, from Vertex, to Vertex, attributes DOTAttributes) error {
+	g := p.dot.Graph
+	edge := Edge{From: from, To: to}
+
+	exists, err := g.hasNext(from, to)
+	if err != nil {
+		return p.fail(op, "%s", err)
+	}
+	if !exists {
+		if path, err := g.Path(to, from); err == nil {
+			cycle := append(path, to)
+			derr := p.fail(op, "edge %s -> %s creates a cycle %s", from, to, strings.Join(cycle, " -> "))
+			derr.Cycle = cycle
+			return derr
+		}
+		if err := g.Connect(from, to); err != nil {
+			return p.fail(op, "%s", err)
+		}
+	}
+
+	if len(attributes) > 0 {
+		p.dot.Edges[edge] = p.dot.Edges[edge].with(attributes)
+	}
+	return nil
+}
+
+// attributeList reads the attribute lists of a statement, e.g. [color=red; label="A"][shape=box]
+func (p *dotParser) attributeList() (DOTAttributes, error) {
+	attributes := DOTAttributes{}
+	for p.peek().is("[") {
+		p.next()
+		for !p.peek().is("]") {
+			name, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			attributes[name] = value
+
+			if p.peek().is(",") || p.peek().is(";") {
+				p.next()
+			}
+		}
+		p.next()
+	}
+	return attributes, nil
+}
+
+// with returns a copy of the attributes that is merged with other
+func (a DOTAttributes) with(other DOTAttributes) DOTAttributes {
+	merged := make(DOTAttributes, len(a)+len(other))
+	merged.merge(a)
+	merged.merge(other)
+	return merged
+}
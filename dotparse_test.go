@@ -0,0 +1,111 @@
+package dag_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDOT(t *testing.T) {
+	t.Run("should load vertices, edge chains & subgraphs", func(t *testing.T) {
+		dot, err := dag.LoadDOT(strings.NewReader(`
+# generated
+strict digraph "deps" {
+  rankdir=LR; /* layout */
+  node [shape=box]
+  "build" [label="go build"]
+  generate -> build -> test // chain
+  subgraph cluster_lint { vet; lint }
+  {vet lint} -> test [style=dashed, color="gr" + "ay"]
+}
+`))
+		assert.Nil(t, err)
+		assert.Equal(t, "deps", dot.Name)
+		assert.Equal(t, dag.DOTAttributes{"rankdir": "LR"}, dot.Attributes)
+		assert.Equal(t, []dag.Vertex{"build", "generate", "test", "vet", "lint"}, dot.Graph.Vertices())
+		assert.Equal(t, dag.Edges{
+			"build":    {"test"},
+			"generate": {"build"},
+			"test":     {},
+			"vet":      {"test"},
+			"lint":     {"test"},
+		}, dot.Graph.Edges())
+
+		assert.Equal(t, dag.DOTAttributes{"shape": "box", "label": "go build"}, dot.VertexAttributes("build"))
+		assert.Equal(t, dag.DOTAttributes{"shape": "box"}, dot.VertexAttributes("vet"))
+		assert.Equal(t, dag.DOTAttributes{"style": "dashed", "color": "gray"}, dot.EdgeAttributes("lint", "test"))
+		assert.Nil(t, dot.EdgeAttributes("generate", "build"))
+	})
+
+	t.Run("should merge attributes of duplicate statements & keep ports", func(t *testing.T) {
+		dot, err := dag.LoadDOT(strings.NewReader(`digraph {
+  edge [color=red]
+  a:out -> b:in:n [weight=2]
+  a -> b [label=<<b>x</b>>]
+  a [label="line\nbreak"]
+  a [shape=circle]
+}`))
+		assert.Nil(t, err)
+		assert.Equal(t, dag.DOTAttributes{"label": "line\nbreak", "shape": "circle"}, dot.VertexAttributes("a"))
+		assert.Nil(t, dot.VertexAttributes("b"))
+		assert.Equal(t, dag.DOTAttributes{
+			"color": "red", "weight": "2", "tailport": "out", "headport": "in:n", "label": dag.DOTHTML("<b>x</b>"),
+		}, dot.EdgeAttributes("a", "b"))
+	})
+
+	t.Run("should load the output of WriteDOT", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{`say "hi"`, "B"}), dag.WithEdges(dag.Edges{`say "hi"`: {"B"}}))
+		assert.Nil(t, err)
+		assert.Nil(t, g.SetPayload("B", dag.DOTAttributes{"label": "b\nc"}))
+
+		var out bytes.Buffer
+		vertexAttributes := func(v dag.Vertex) dag.DOTAttributes {
+			payload, _ := g.Payload(v)
+			attributes, _ := payload.(dag.DOTAttributes)
+			return attributes
+		}
+		assert.Nil(t, g.WriteDOT(&out, dag.WithVertexAttributes(vertexAttributes), dag.WithClusters(func(v dag.Vertex) string {
+			return "cluster"
+		})))
+
+		dot, err := dag.LoadDOT(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, g.Vertices(), dot.Graph.Vertices())
+		assert.Equal(t, g.Edges(), dot.Graph.Edges())
+		assert.Equal(t, dag.DOTAttributes{"label": "b\nc"}, dot.VertexAttributes("B"))
+	})
+
+	t.Run("should return errors with positions", func(t *testing.T) {
+		inputs := map[string]string{
+			"graph { a -- b }":                "line 1, column 1: undirected graphs are not supported, expected digraph",
+			"digraph {\n  a -- b\n}":          "line 2, column 5: undirected edges are not supported, expected ->",
+			"digraph {\n  a [label=x\n}":      "line 3, column 1: expected id, got }",
+			"digraph {\n  a -> b\n":           "line 3, column 1: expected }, got end of file",
+			"digraph {\n  \"a\n}":             "line 2, column 3: quoted string is not terminated",
+			"digraph { a } digraph { b }":     "line 1, column 15: unexpected digraph after digraph",
+			"digraph {\n  a -> a\n}":          "line 2, column 5: edge a -> a creates a cycle a -> a",
+			"digraph {\n  a -> b -> {c d}\n}": "",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadDOT(strings.NewReader(input))
+			if message == "" {
+				assert.Nil(t, err, input)
+				continue
+			}
+			assert.EqualError(t, err, message, input)
+		}
+	})
+
+	t.Run("should return cycles", func(t *testing.T) {
+		_, err := dag.LoadDOT(strings.NewReader("digraph {\n  a -> b -> c\n  c -> a\n}"))
+		var dotErr *dag.DOTError
+		assert.True(t, errors.As(err, &dotErr))
+		assert.Equal(t, 3, dotErr.Line)
+		assert.Equal(t, []dag.Vertex{"a", "b", "c", "a"}, dotErr.Cycle)
+		assert.EqualError(t, err, "line 3, column 5: edge c -> a creates a cycle a -> b -> c -> a")
+	})
+}
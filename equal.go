@@ -0,0 +1,207 @@
+package dag
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Equal returns whether the graphs have the same vertices & edges, the order of vertices & next vertices & payloads
+// are ignored
+func Equal(a *Graph, b *Graph) bool {
+	aVertices, aEdges := a.snapshot()
+	_, bEdges := b.snapshot()
+	if len(aEdges) != len(bEdges) {
+		return false
+	}
+
+	for _, vertex := range aVertices {
+		bNext, ok := bEdges[vertex]
+		if !ok || len(aEdges[vertex]) != len(bNext) {
+			return false
+		}
+		// next vertices are unique, so equal lengths & inclusion mean equal sets
+		for _, next := range aEdges[vertex] {
+			if !includes(bNext, next) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isoGraph is the snapshot of a graph with the previous vertices & the colours of its vertices for Isomorphic
+type isoGraph struct {
+	vertices []Vertex
+	edges    Edges
+	next     map[Vertex]map[Vertex]bool
+	prev     map[Vertex]map[Vertex]bool
+	colours  map[Vertex]int
+}
+
+func newIsoGraph(g *Graph) *isoGraph {
+	vertices, edges := g.snapshot()
+	iso := &isoGraph{vertices: vertices, edges: edges, next: map[Vertex]map[Vertex]bool{}, prev: map[Vertex]map[Vertex]bool{}, colours: map[Vertex]int{}}
+	for _, vertex := range vertices {
+		iso.next[vertex], iso.prev[vertex] = map[Vertex]bool{}, map[Vertex]bool{}
+	}
+	for _, from := range vertices {
+		for _, to := range edges[from] {
+			iso.next[from][to] = true
+			iso.prev[to][from] = true
+		}
+	}
+	return iso
+}
+
+// signature returns the colour of a vertex with the sorted colours of its next & previous vertices
+func (iso *isoGraph) signature(vertex Vertex) string {
+	colours := func(vertices map[Vertex]bool) []int {
+		list := make([]int, 0, len(vertices))
+		for v := range vertices {
+			list = append(list, iso.colours[v])
+		}
+		sort.Ints(list)
+		return list
+	}
+	return fmt.Sprintf("%d|%v|%v", iso.colours[vertex], colours(iso.next[vertex]), colours(iso.prev[vertex]))
+}
+
+// histogram returns the number of vertices of each colour
+func (iso *isoGraph) histogram() map[int]int {
+	histogram := map[int]int{}
+	for _, colour := range iso.colours {
+		histogram[colour]++
+	}
+	return histogram
+}
+
+// topSort returns the vertices in topological order, so that matched vertices are mostly connected to matched vertices
+func (iso *isoGraph) topSort() []Vertex {
+	inDegree := make(map[Vertex]int, len(iso.vertices))
+	var sorted []Vertex
+	for _, vertex := range iso.vertices {
+		if inDegree[vertex] = len(iso.prev[vertex]); inDegree[vertex] == 0 {
+			sorted = append(sorted, vertex)
+		}
+	}
+	for i := 0; i < len(sorted); i++ {
+		for _, next := range iso.edges[sorted[i]] {
+			if inDegree[next]--; inDegree[next] == 0 {
+				sorted = append(sorted, next)
+			}
+		}
+	}
+	return sorted
+}
+
+// Isomorphic returns whether the graphs have the same structure regardless of vertex names & the mapping of the vertices
+// of a to the vertices of b, e.g. to check that a generated pipeline has the shape of a template.
+//
+// vertices are coloured by their degrees & the colours of their next & previous vertices until the colours are stable,
+// graphs with different colour counts are not isomorphic. vertices are then matched in topological order to vertices
+// of the same colour, backtracking when the edges to matched vertices differ
+func Isomorphic(a *Graph, b *Graph) (map[Vertex]Vertex, bool) {
+	isoA, isoB := newIsoGraph(a), newIsoGraph(b)
+	if len(isoA.vertices) != len(isoB.vertices) {
+		return nil, false
+	}
+
+	// both graphs share the signatures, so that equal colours mean equal neighbourhoods in both graphs
+	for _, iso := range []*isoGraph{isoA, isoB} {
+		for _, vertex := range iso.vertices {
+			iso.colours[vertex] = len(iso.next[vertex])*(len(iso.vertices)+1) + len(iso.prev[vertex])
+		}
+	}
+	for count := 0; ; {
+		signatures := map[string]int{}
+		colours := make([]map[Vertex]int, 2)
+		for i, iso := range []*isoGraph{isoA, isoB} {
+			colours[i] = make(map[Vertex]int, len(iso.vertices))
+			for _, vertex := range iso.vertices {
+				signature := iso.signature(vertex)
+				if _, ok := signatures[signature]; !ok {
+					signatures[signature] = len(signatures)
+				}
+				colours[i][vertex] = signatures[signature]
+			}
+		}
+		isoA.colours, isoB.colours = colours[0], colours[1]
+
+		histogramA, histogramB := isoA.histogram(), isoB.histogram()
+		if len(histogramA) != len(histogramB) {
+			return nil, false
+		}
+		for colour, n := range histogramA {
+			if histogramB[colour] != n {
+				return nil, false
+			}
+		}
+		if len(signatures) == count {
+			break
+		}
+		count = len(signatures)
+	}
+
+	m := &isoMatcher{a: isoA, b: isoB, order: isoA.topSort(), classes: map[int][]Vertex{}, mapping: map[Vertex]Vertex{}, reverse: map[Vertex]Vertex{}}
+	for _, vertex := range isoB.vertices {
+		m.classes[isoB.colours[vertex]] = append(m.classes[isoB.colours[vertex]], vertex)
+	}
+	if !m.match(0) {
+		return nil, false
+	}
+	return m.mapping, true
+}
+
+// isoMatcher matches the vertices of a to the vertices of b with backtracking
+type isoMatcher struct {
+	a, b  *isoGraph
+	order []Vertex
+	// classes holds the vertices of b by colour in vertex order
+	classes map[int][]Vertex
+	mapping map[Vertex]Vertex
+	reverse map[Vertex]Vertex
+}
+
+func (m *isoMatcher) match(i int) bool {
+	if i == len(m.order) {
+		return true
+	}
+
+	vertex := m.order[i]
+	for _, candidate := range m.classes[m.a.colours[vertex]] {
+		if _, ok := m.reverse[candidate]; ok {
+			continue
+		}
+		if !m.consistent(m.a.next[vertex], m.b.next[candidate]) || !m.consistent(m.a.prev[vertex], m.b.prev[candidate]) {
+			continue
+		}
+
+		m.mapping[vertex], m.reverse[candidate] = candidate, vertex
+		if m.match(i + 1) {
+			return true
+		}
+		delete(m.mapping, vertex)
+		delete(m.reverse, candidate)
+	}
+	return false
+}
+
+// consistent returns whether the matched neighbours of a vertex of a are matched to the matched neighbours of a vertex
+// of b
+func (m *isoMatcher) consistent(aNeighbours map[Vertex]bool, bNeighbours map[Vertex]bool) bool {
+	matched := 0
+	for neighbour := range aNeighbours {
+		if mapped, ok := m.mapping[neighbour]; ok {
+			if !bNeighbours[mapped] {
+				return false
+			}
+			matched++
+		}
+	}
+	for neighbour := range bNeighbours {
+		if _, ok := m.reverse[neighbour]; ok {
+			matched--
+		}
+	}
+	return matched == 0
+}
@@ -0,0 +1,132 @@
+package dag_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+// renamedGraph returns the graph of createGraph with vertices renamed by prefix & added & connected in reverse order
+func renamedGraph(t *testing.T, prefix string) *dag.Graph {
+	g, err := dag.New()
+	assert.Nil(t, err)
+	for _, v := range []dag.Vertex{"F", "E", "D", "C", "B", "A"} {
+		assert.Nil(t, g.Add(prefix+v))
+	}
+	for _, edge := range []dag.Edge{{From: "E", To: "F"}, {From: "D", To: "E"}, {From: "B", To: "E"}, {From: "B", To: "C"}, {From: "A", To: "D"}, {From: "A", To: "B"}} {
+		assert.Nil(t, g.Connect(prefix+edge.From, prefix+edge.To))
+	}
+	return g
+}
+
+func TestEqual(t *testing.T) {
+	t.Run("should ignore order of vertices & edges", func(t *testing.T) {
+		a, b := createGraph(), renamedGraph(t, "")
+		assert.NotEqual(t, a.Edges(), b.Edges())
+		assert.True(t, dag.Equal(a, b))
+		assert.True(t, dag.Equal(b, a))
+
+		assert.Nil(t, b.SetPayload("A", "a"))
+		assert.True(t, dag.Equal(a, b))
+	})
+
+	t.Run("should compare vertices & edges", func(t *testing.T) {
+		a, b := createGraph(), createGraph()
+		assert.Nil(t, b.Add("G"))
+		assert.False(t, dag.Equal(a, b))
+		assert.False(t, dag.Equal(b, a))
+
+		b = createGraph()
+		assert.Nil(t, b.DisconnectEdge("D", "E"))
+		assert.Nil(t, b.Connect("D", "F"))
+		assert.False(t, dag.Equal(a, b))
+
+		empty, err := dag.New()
+		assert.Nil(t, err)
+		assert.False(t, dag.Equal(a, empty))
+		other, _ := dag.New()
+		assert.True(t, dag.Equal(empty, other))
+	})
+}
+
+func TestIsomorphic(t *testing.T) {
+	t.Run("should return vertex mapping", func(t *testing.T) {
+		mapping, ok := dag.Isomorphic(createGraph(), renamedGraph(t, "x"))
+		assert.True(t, ok)
+		assert.Equal(t, map[dag.Vertex]dag.Vertex{"A": "xA", "B": "xB", "C": "xC", "D": "xD", "E": "xE", "F": "xF"}, mapping)
+	})
+
+	t.Run("should map equal graphs to themselves", func(t *testing.T) {
+		a := createGraph()
+		mapping, ok := dag.Isomorphic(a, a)
+		assert.True(t, ok)
+		for vertex, mapped := range mapping {
+			assert.Equal(t, vertex, mapped)
+		}
+	})
+
+	t.Run("should not match different structures", func(t *testing.T) {
+		b := renamedGraph(t, "x")
+		assert.Nil(t, b.DisconnectEdge("xD", "xE"))
+		assert.Nil(t, b.Connect("xD", "xF"))
+		_, ok := dag.Isomorphic(createGraph(), b)
+		assert.False(t, ok)
+
+		b = renamedGraph(t, "x")
+		assert.Nil(t, b.Add("xG"))
+		_, ok = dag.Isomorphic(createGraph(), b)
+		assert.False(t, ok)
+
+		// the same shape with other vertex names
+		a, _ := dag.New()
+		assert.Nil(t, a.Add("A", "B", "C", "D"))
+		assert.Nil(t, a.Connect("A", "B"))
+		assert.Nil(t, a.Connect("C", "D"))
+		assert.Nil(t, a.Connect("A", "D"))
+		b, _ = dag.New()
+		assert.Nil(t, b.Add("A", "B", "C", "D"))
+		assert.Nil(t, b.Connect("A", "B"))
+		assert.Nil(t, b.Connect("C", "D"))
+		assert.Nil(t, b.Connect("C", "B"))
+		mapping, ok := dag.Isomorphic(a, b)
+		assert.True(t, ok)
+		assert.Equal(t, map[dag.Vertex]dag.Vertex{"A": "C", "B": "D", "C": "A", "D": "B"}, mapping)
+		// B is between A & D instead of a sink
+		assert.Nil(t, b.DisconnectEdge("C", "B"))
+		assert.Nil(t, b.Connect("B", "D"))
+		_, ok = dag.Isomorphic(a, b)
+		assert.False(t, ok)
+	})
+
+	t.Run("should backtrack on symmetric graphs", func(t *testing.T) {
+		// two diamonds whose vertices have the same colours, so sources are matched by backtracking
+		build := func(joins [][2]int) *dag.Graph {
+			g, _ := dag.New()
+			for i := 0; i < 8; i++ {
+				assert.Nil(t, g.Add(fmt.Sprintf("v%d", i)))
+			}
+			for _, join := range joins {
+				assert.Nil(t, g.Connect(fmt.Sprintf("v%d", join[0]), fmt.Sprintf("v%d", join[1])))
+			}
+			return g
+		}
+		a := build([][2]int{{0, 1}, {0, 2}, {1, 3}, {2, 3}, {4, 5}, {4, 6}, {5, 7}, {6, 7}})
+		b := build([][2]int{{4, 6}, {6, 7}, {4, 5}, {5, 7}, {0, 2}, {0, 1}, {2, 3}, {1, 3}})
+		mapping, ok := dag.Isomorphic(a, b)
+		assert.True(t, ok)
+		assert.Len(t, mapping, 8)
+		for from, next := range a.Edges() {
+			for _, to := range next {
+				mappedNext, _ := b.Next(mapping[from])
+				assert.Contains(t, mappedNext, mapping[to])
+			}
+		}
+
+		empty, _ := dag.New()
+		mapping, ok = dag.Isomorphic(empty, empty)
+		assert.True(t, ok)
+		assert.Empty(t, mapping)
+	})
+}
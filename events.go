@@ -0,0 +1,148 @@
+package dag
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EventType represents the kind of an execution event
+type EventType string
+
+const (
+	// EventRunStarted is published once when a run starts
+	EventRunStarted EventType = "run_started"
+	// EventVertexReady is published when all dependencies of a vertex are finished
+	EventVertexReady EventType = "vertex_ready"
+	// EventVertexStarted is published when the task of a vertex is started
+	EventVertexStarted EventType = "vertex_started"
+	// EventVertexRetrying is published when a failed task is about to be retried
+	EventVertexRetrying EventType = "vertex_retrying"
+	// EventVertexFinished is published when a vertex reaches a terminal state
+	EventVertexFinished EventType = "vertex_finished"
+	// EventRunFinished is published once when a run ends
+	EventRunFinished EventType = "run_finished"
+)
+
+// Event is a structured execution event
+type Event struct {
+	Type   EventType `json:"type"`
+	Time   time.Time `json:"time"`
+	Vertex Vertex    `json:"vertex,omitempty"`
+	// State is set for EventVertexFinished
+	State State `json:"state,omitempty"`
+	// Attempt is the 1 based attempt number for EventVertexStarted & EventVertexRetrying
+	Attempt int `json:"attempt,omitempty"`
+	// Duration is the time since the vertex started for EventVertexFinished & the time since the run started for EventRunFinished
+	Duration time.Duration `json:"duration,omitempty"`
+	// Err is the error of a failed attempt, vertex or run, Error holds its message
+	Err   error  `json:"-"`
+	Error string `json:"error,omitempty"`
+}
+
+// Hook is a callback that receives execution events. hooks are called one at a time, in the order of events
+type Hook func(event Event)
+
+// WithEvents publishes execution events on the given channel. sends are blocking, the channel must be drained
+// while the executor runs. the channel is not closed by the executor
+func WithEvents(events chan<- Event) ExecutorOptions {
+	return func(e *Executor) error {
+		if events == nil {
+			return fmt.Errorf("events channel is nil")
+		}
+		e.events = append(e.events, events)
+		return nil
+	}
+}
+
+// WithHook adds a callback that receives execution events
+func WithHook(hook Hook) ExecutorOptions {
+	return func(e *Executor) error {
+		if hook == nil {
+			return fmt.Errorf("hook is nil")
+		}
+		e.hooks = append(e.hooks, hook)
+		return nil
+	}
+}
+
+// emit publishes an event to hooks & channels
+func (e *Executor) emit(event Event) {
+	if len(e.hooks) == 0 && len(e.events) == 0 {
+		return
+	}
+
+	event.Time = time.Now()
+	if event.Err != nil {
+		event.Error = event.Err.Error()
+	}
+
+	e.emitMu.Lock()
+	defer e.emitMu.Unlock()
+	for _, hook := range e.hooks {
+		hook(event)
+	}
+	for _, events := range e.events {
+		events <- event
+	}
+}
+
+// JSONLogger writes events as json lines, e.g. to inspect a run afterwards
+type JSONLogger struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+// NewJSONLogger creates a logger that writes a json object per line to w
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{encoder: json.NewEncoder(w)}
+}
+
+// Log writes an event. it can be used as a hook with WithHook(logger.Log)
+func (l *JSONLogger) Log(event Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	if err := l.encoder.Encode(event); err != nil {
+		l.err = errors.Wrap(err, "could not log event")
+	}
+}
+
+// Err returns the first write error of the logger, later events are dropped after an error
+func (l *JSONLogger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// ReadEvents reads events written by a JSONLogger
+func ReadEvents(r io.Reader) ([]Event, error) {
+	events := []Event{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		event := Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("could not read event at line %d", line))
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read events")
+	}
+	return events, nil
+}
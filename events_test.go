@@ -0,0 +1,130 @@
+package dag_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	t.Run("WithHook", func(t *testing.T) {
+		t.Run("should return error when hook is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithHook(nil))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should publish lifecycle events in order", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B"}), dag.WithEdges(dag.Edges{"A": {"B"}}))
+			assert.Nil(t, err)
+
+			attempts := 0
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "B" {
+					attempts++
+					if attempts == 1 {
+						return nil, fmt.Errorf("flaky")
+					}
+				}
+				return concatTask(ctx, vertex, inputs)
+			}
+
+			events := []dag.Event{}
+			executor, err := dag.NewExecutor(g, task, dag.WithRetries(1, 0), dag.WithHook(func(event dag.Event) {
+				events = append(events, event)
+			}))
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+
+			types := []string{}
+			for _, event := range events {
+				types = append(types, fmt.Sprintf("%s %s %s %d %s", event.Type, event.Vertex, event.State, event.Attempt, event.Error))
+			}
+			expected := []string{
+				"run_started   0 ",
+				"vertex_ready A  0 ",
+				"vertex_started A  1 ",
+				"vertex_finished A succeeded 0 ",
+				"vertex_ready B  0 ",
+				"vertex_started B  1 ",
+				"vertex_retrying B  2 flaky",
+				"vertex_finished B succeeded 0 ",
+				"run_finished   0 ",
+			}
+			assert.Equal(t, expected, types)
+		})
+	})
+
+	t.Run("WithEvents", func(t *testing.T) {
+		t.Run("should return error when channel is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithEvents(nil))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should publish events on channel", func(t *testing.T) {
+			events := make(chan dag.Event)
+			executor, err := dag.NewExecutor(createGraph(), concatTask, dag.WithEvents(events))
+			assert.Nil(t, err)
+
+			go func() {
+				_, _ = executor.Run(context.Background())
+				close(events)
+			}()
+
+			finished := 0
+			last := dag.Event{}
+			for event := range events {
+				if event.Type == dag.EventVertexFinished {
+					finished++
+				}
+				last = event
+			}
+			assert.Equal(t, 6, finished)
+			assert.Equal(t, dag.EventRunFinished, last.Type)
+		})
+	})
+
+	t.Run("JSONLogger", func(t *testing.T) {
+		t.Run("should write events that can be read back", func(t *testing.T) {
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "E" {
+					return nil, fmt.Errorf("E failed")
+				}
+				return nil, nil
+			}
+
+			var buf bytes.Buffer
+			logger := dag.NewJSONLogger(&buf)
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithHook(logger.Log))
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.NotNil(t, err)
+			assert.Nil(t, logger.Err())
+
+			events, err := dag.ReadEvents(&buf)
+			assert.Nil(t, err)
+			assert.Equal(t, dag.EventRunStarted, events[0].Type)
+
+			states := map[dag.Vertex]dag.State{}
+			for _, event := range events {
+				if event.Type == dag.EventVertexFinished {
+					states[event.Vertex] = event.State
+				}
+			}
+			assert.Equal(t, dag.StateFailed, states["E"])
+			assert.Equal(t, dag.StateSkipped, states["F"])
+			assert.Equal(t, "could not run graph. 1 vertices failed", events[len(events)-1].Error)
+		})
+
+		t.Run("should return error for invalid lines", func(t *testing.T) {
+			_, err := dag.ReadEvents(bytes.NewBufferString("{\"type\":\"run_started\"}\nnot json\n"))
+			assert.EqualError(t, err, "could not read event at line 2: invalid character 'o' in literal null (expecting 'u')")
+		})
+	})
+}
@@ -3,6 +3,7 @@ package dag_test
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/aacanakin/dag"
 )
@@ -45,6 +46,24 @@ func ExampleGraph() {
 	// F
 }
 
+func ExampleGraph_WriteText() {
+	graph := createGraph()
+
+	// Draw the graph for terminals, it falls back to a tree when the graph is wider than the width
+	graph.WriteText(os.Stdout, dag.WithWidth(80))
+
+	// Output:
+	// ● A
+	// ├─╮
+	// ● │ B
+	// ├─│─╮
+	// │ ● │ D
+	// ● │ │ C
+	// ╭─┴─╯
+	// ● E
+	// ● F
+}
+
 func ExampleGraph_Append() {
 	graph := createGraph()
 
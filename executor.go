@@ -0,0 +1,377 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TaskFunc is the unit of work run for a vertex. inputs holds the outputs of the previous vertices of the vertex
+type TaskFunc func(ctx context.Context, vertex Vertex, inputs map[Vertex][]byte) (output []byte, err error)
+
+// State represents the execution state of a vertex
+type State string
+
+const (
+	// StatePending means the vertex has not run yet
+	StatePending State = "pending"
+	// StateRunning means the vertex task is running
+	StateRunning State = "running"
+	// StateSucceeded means the vertex task returned without error
+	StateSucceeded State = "succeeded"
+	// StateFailed means the vertex task returned an error
+	StateFailed State = "failed"
+	// StateSkipped means the vertex did not run because one of its dependencies did not succeed
+	StateSkipped State = "skipped"
+)
+
+// Terminal checks if the state is a final state of a run
+func (s State) Terminal() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateSkipped
+}
+
+// VertexResult is the outcome of a vertex in a run
+type VertexResult struct {
+	State  State
+	Output []byte
+	Err    error
+	// Resumed is true when the result is loaded from a checkpoint instead of running the task
+	Resumed bool
+	// UpToDate is true when the fingerprint of the vertex matched the cache & the task is not run
+	UpToDate bool
+	// Stale explains why the task is run when fingerprints are enabled
+	Stale *Staleness
+}
+
+// Results maps vertices to their outcomes in a run
+type Results map[Vertex]VertexResult
+
+type ExecutorOptions func(*Executor) error
+
+// WithWorkers sets the maximum number of tasks that run concurrently
+func WithWorkers(workers int) ExecutorOptions {
+	return func(e *Executor) error {
+		if workers < 1 {
+			return fmt.Errorf("workers must be positive, got %d", workers)
+		}
+		e.workers = workers
+		return nil
+	}
+}
+
+// RetryPolicy returns the number of times a failed task of a vertex is retried & the delay before each retry.
+// tasks that expanded the graph are not retried, as their expansions are already applied
+type RetryPolicy func(vertex Vertex) (retries int, delay time.Duration)
+
+// WithRetries retries failed tasks of all vertices the given number of times, waiting delay before each retry
+func WithRetries(retries int, delay time.Duration) ExecutorOptions {
+	return WithRetryPolicy(func(Vertex) (int, time.Duration) {
+		return retries, delay
+	})
+}
+
+// WithRetryPolicy sets the retry policy of the executor
+func WithRetryPolicy(policy RetryPolicy) ExecutorOptions {
+	return func(e *Executor) error {
+		if policy == nil {
+			return fmt.Errorf("retry policy is nil")
+		}
+		e.retries = policy
+		return nil
+	}
+}
+
+// Executor runs a task for every vertex of a graph, respecting the edges as dependencies
+type Executor struct {
+	graph      *Graph
+	task       TaskFunc
+	workers    int
+	checkpoint CheckpointStore
+	retries    RetryPolicy
+
+	triggerRules TriggerRuleFunc
+
+	pools    Resources
+	demand   DemandFunc
+	priority PriorityFunc
+
+	inputHash    InputHashFunc
+	fingerprints FingerprintCache
+
+	expandMu sync.Mutex
+
+	emitMu sync.Mutex
+	hooks  []Hook
+	events []chan<- Event
+}
+
+// NewExecutor creates an executor that runs task for each vertex of the graph
+func NewExecutor(g *Graph, task TaskFunc, opts ...ExecutorOptions) (*Executor, error) {
+	if g == nil {
+		return nil, fmt.Errorf("could not create executor. graph is nil")
+	}
+	if task == nil {
+		return nil, fmt.Errorf("could not create executor. task is nil")
+	}
+
+	e := &Executor{
+		graph:   g,
+		task:    task,
+		workers: runtime.NumCPU(),
+	}
+
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, errors.Wrap(err, "could not create executor")
+		}
+	}
+	return e, nil
+}
+
+type taskResult struct {
+	vertex   Vertex
+	output   []byte
+	err      error
+	upToDate bool
+	stale    *Staleness
+}
+
+// Run executes the graph. A vertex is started once all of its previous vertices are finished & its trigger rule,
+// by default all previous vertices succeeded, is satisfied. otherwise the vertex is skipped. tasks can add vertices below their own vertex through ExpanderFrom.
+//
+// returns error if any of the vertices failed or the context is cancelled before the run finishes
+func (e *Executor) Run(ctx context.Context) (Results, error) {
+	start := time.Now()
+	e.emit(Event{Type: EventRunStarted})
+	results, err := e.run(ctx)
+	e.emit(Event{Type: EventRunFinished, Duration: time.Since(start), Err: err})
+	return results, err
+}
+
+func (e *Executor) run(ctx context.Context) (Results, error) {
+	vertices, err := e.graph.TopSort()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not run graph")
+	}
+
+	prev, err := e.graph.ReverseEdges()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not run graph")
+	}
+
+	results := make(Results, len(vertices))
+	for _, vertex := range vertices {
+		results[vertex] = VertexResult{State: StatePending}
+	}
+
+	if err := e.resume(vertices, prev, results); err != nil {
+		return results, errors.Wrap(err, "could not run graph")
+	}
+
+	inDegree := make(map[Vertex]int, len(vertices))
+	for _, vertex := range vertices {
+		if results[vertex].State.Terminal() {
+			continue
+		}
+		for _, prevVertex := range prev[vertex] {
+			if !results[prevVertex].State.Terminal() {
+				inDegree[vertex]++
+			}
+		}
+	}
+
+	demands := make(map[Vertex]Resources, len(vertices))
+	for _, vertex := range vertices {
+		if demands[vertex], err = e.demandOf(vertex); err != nil {
+			return results, errors.Wrap(err, "could not run graph")
+		}
+	}
+	available := Resources{}
+	available.release(e.pools)
+
+	priority, err := e.prioritize()
+	if err != nil {
+		return results, errors.Wrap(err, "could not run graph")
+	}
+
+	ready := []Vertex{}
+	for _, vertex := range vertices {
+		if results[vertex].Resumed {
+			e.emit(Event{Type: EventVertexFinished, Vertex: vertex, State: StateSucceeded})
+		} else if inDegree[vertex] == 0 {
+			ready = append(ready, vertex)
+			e.emit(Event{Type: EventVertexReady, Vertex: vertex})
+		}
+	}
+	started := map[Vertex]time.Time{}
+	expanders := map[Vertex]*Expander{}
+
+	blocked := func(vertex Vertex) bool {
+		return !e.triggered(vertex, prev[vertex], results)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan taskResult, e.workers)
+	running := 0
+
+	for {
+		for ctx.Err() == nil && running < e.workers && len(ready) > 0 {
+			i := pick(ready, priority, available, demands, blocked)
+			if i < 0 {
+				break
+			}
+			vertex := ready[i]
+			ready = append(ready[:i], ready[i+1:]...)
+
+			if blocked(vertex) {
+				if err := e.finish(vertex, VertexResult{State: StateSkipped}, results, time.Now()); err != nil {
+					wg.Wait()
+					return results, errors.Wrap(err, "could not run graph")
+				}
+				ready = e.release(vertex, inDegree, ready)
+				continue
+			}
+
+			inputs := make(map[Vertex][]byte, len(prev[vertex]))
+			for _, prevVertex := range prev[vertex] {
+				inputs[prevVertex] = results[prevVertex].Output
+			}
+
+			available.acquire(demands[vertex])
+			results[vertex] = VertexResult{State: StateRunning}
+			started[vertex] = time.Now()
+			e.emit(Event{Type: EventVertexStarted, Vertex: vertex, Attempt: 1})
+			taskCtx, expander := e.newExpander(ctx, vertex)
+			expanders[vertex] = expander
+			running++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				done <- e.execute(taskCtx, vertex, inputs)
+			}()
+		}
+
+		if running == 0 {
+			break
+		}
+
+		res := <-done
+		running--
+		available.release(demands[res.vertex])
+
+		// vertices added by the task hang below it, so they are scheduled before the task is released
+		added, edges := expanders[res.vertex].close()
+		delete(expanders, res.vertex)
+		for _, vertex := range added {
+			results[vertex] = VertexResult{State: StatePending}
+			if demands[vertex], err = e.demandOf(vertex); err != nil {
+				wg.Wait()
+				return results, errors.Wrap(err, "could not run graph")
+			}
+		}
+		for _, edge := range edges {
+			prev[edge[1]] = append(prev[edge[1]], edge[0])
+			inDegree[edge[1]]++
+		}
+		if len(added) > 0 && e.priority == nil && e.demand != nil {
+			if priority, err = e.prioritize(); err != nil {
+				wg.Wait()
+				return results, errors.Wrap(err, "could not run graph")
+			}
+		}
+
+		result := VertexResult{State: StateSucceeded, Output: res.output, UpToDate: res.upToDate, Stale: res.stale}
+		if res.err != nil {
+			result = VertexResult{State: StateFailed, Err: res.err, Stale: res.stale}
+		}
+		if err := e.finish(res.vertex, result, results, started[res.vertex]); err != nil {
+			wg.Wait()
+			return results, errors.Wrap(err, "could not run graph")
+		}
+		ready = e.release(res.vertex, inDegree, ready)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, errors.Wrap(err, "could not run graph")
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.State == StateFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("could not run graph. %d vertices failed", failed)
+	}
+
+	return results, nil
+}
+
+// attempt runs the task of a vertex, retrying failures according to the retry policy
+func (e *Executor) attempt(ctx context.Context, vertex Vertex, inputs map[Vertex][]byte) (output []byte, err error) {
+	retries, delay := 0, time.Duration(0)
+	if e.retries != nil {
+		retries, delay = e.retries(vertex)
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err = e.call(ctx, vertex, inputs)
+		if err == nil || attempt > retries || ctx.Err() != nil {
+			return output, err
+		}
+		if x, ok := ExpanderFrom(ctx); ok && x.expanded() {
+			return output, err
+		}
+
+		e.emit(Event{Type: EventVertexRetrying, Vertex: vertex, Attempt: attempt + 1, Err: err})
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// call runs the task of a vertex, converting panics to errors
+func (e *Executor) call(ctx context.Context, vertex Vertex, inputs map[Vertex][]byte) (output []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task of vertex %s panicked: %v", vertex, r)
+		}
+	}()
+	return e.task(ctx, vertex, inputs)
+}
+
+// finish records the final result of a vertex
+func (e *Executor) finish(vertex Vertex, result VertexResult, results Results, start time.Time) error {
+	results[vertex] = result
+	e.emit(Event{Type: EventVertexFinished, Vertex: vertex, State: result.State, Duration: time.Since(start), Err: result.Err})
+	if e.checkpoint == nil {
+		return nil
+	}
+	return e.checkpoint.Save(vertex, newCheckpoint(result))
+}
+
+// release decrements the in degree of next vertices of a finished vertex, appending the ones that are ready
+func (e *Executor) release(vertex Vertex, inDegree map[Vertex]int, ready []Vertex) []Vertex {
+	next, err := e.graph.Next(vertex)
+	if err != nil {
+		return ready
+	}
+	for _, nextVertex := range next {
+		inDegree[nextVertex]--
+		if inDegree[nextVertex] == 0 {
+			ready = append(ready, nextVertex)
+			e.emit(Event{Type: EventVertexReady, Vertex: nextVertex})
+		}
+	}
+	return ready
+}
@@ -0,0 +1,176 @@
+package dag_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+// concatTask outputs the vertex name prefixed by the sorted outputs of its inputs
+func concatTask(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+	parts := []string{}
+	for _, input := range inputs {
+		parts = append(parts, string(input))
+	}
+	sort.Strings(parts)
+	return []byte(strings.Join(append(parts, vertex), "+")), nil
+}
+
+func TestExecutor(t *testing.T) {
+	t.Run("NewExecutor", func(t *testing.T) {
+		t.Run("should return error when graph is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(nil, concatTask)
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should return error when task is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), nil)
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should return error when workers is not positive", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithWorkers(0))
+			assert.NotNil(t, err)
+		})
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		t.Run("should run vertices after their dependencies", func(t *testing.T) {
+			var mu sync.Mutex
+			order := []dag.Vertex{}
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				mu.Lock()
+				order = append(order, vertex)
+				mu.Unlock()
+				return concatTask(ctx, vertex, inputs)
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithWorkers(3))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, 6, len(order))
+
+			position := map[dag.Vertex]int{}
+			for i, vertex := range order {
+				position[vertex] = i
+			}
+			for vertex, next := range createGraph().Edges() {
+				for _, nextVertex := range next {
+					assert.Less(t, position[vertex], position[nextVertex])
+				}
+			}
+
+			for _, result := range results {
+				assert.Equal(t, dag.StateSucceeded, result.State)
+			}
+			assert.Equal(t, "A+B+A+D+E+F", string(results["F"].Output))
+		})
+
+		t.Run("should skip descendants of a failed vertex", func(t *testing.T) {
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "D" {
+					return nil, fmt.Errorf("D failed")
+				}
+				return concatTask(ctx, vertex, inputs)
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithWorkers(1))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.NotNil(t, err)
+			assert.Equal(t, dag.StateSucceeded, results["A"].State)
+			assert.Equal(t, dag.StateSucceeded, results["B"].State)
+			assert.Equal(t, dag.StateSucceeded, results["C"].State)
+			assert.Equal(t, dag.StateFailed, results["D"].State)
+			assert.EqualError(t, results["D"].Err, "D failed")
+			assert.Equal(t, dag.StateSkipped, results["E"].State)
+			assert.Equal(t, dag.StateSkipped, results["F"].State)
+		})
+
+		t.Run("should convert task panics to failures", func(t *testing.T) {
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				panic("boom")
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task)
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.NotNil(t, err)
+			assert.Equal(t, dag.StateFailed, results["A"].State)
+			assert.Equal(t, dag.StateSkipped, results["F"].State)
+		})
+
+		t.Run("should not start vertices after context is cancelled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				cancel()
+				return nil, nil
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithWorkers(1))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(ctx)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Equal(t, dag.StateSucceeded, results["A"].State)
+			assert.Equal(t, dag.StatePending, results["B"].State)
+		})
+	})
+
+	t.Run("WithRetries", func(t *testing.T) {
+		t.Run("should retry failed tasks", func(t *testing.T) {
+			attempts := 0
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "A" {
+					attempts++
+					if attempts < 3 {
+						return nil, fmt.Errorf("attempt %d failed", attempts)
+					}
+				}
+				return concatTask(ctx, vertex, inputs)
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithRetries(2, time.Millisecond))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, 3, attempts)
+			assert.Equal(t, dag.StateSucceeded, results["F"].State)
+		})
+
+		t.Run("should fail after retries are exhausted", func(t *testing.T) {
+			attempts := 0
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				attempts++
+				return nil, fmt.Errorf("attempt %d failed", attempts)
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithRetryPolicy(func(vertex dag.Vertex) (int, time.Duration) {
+				return 1, 0
+			}))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.NotNil(t, err)
+			assert.Equal(t, 2, attempts)
+			assert.EqualError(t, results["A"].Err, "attempt 2 failed")
+		})
+
+		t.Run("should return error when policy is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithRetryPolicy(nil))
+			assert.NotNil(t, err)
+		})
+	})
+}
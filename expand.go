@@ -0,0 +1,122 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type expanderKey struct{}
+
+// Expander lets a running task add vertices & edges below its own vertex in the live graph.
+// new vertices can not start before the task returns, the executor schedules them when it does
+//
+// expansions are not checkpointed, a vertex that is resumed from a checkpoint does not expand again. expansions are
+// applied once, a failed task that expanded the graph is not retried
+type Expander struct {
+	e      *Executor
+	vertex Vertex
+
+	mu     sync.Mutex
+	closed bool
+	added  []Vertex
+	edges  [][2]Vertex
+}
+
+// ExpanderFrom returns the expander of the running task, ok is false when ctx is not the context of a task
+func ExpanderFrom(ctx context.Context) (x *Expander, ok bool) {
+	x, ok = ctx.Value(expanderKey{}).(*Expander)
+	return x, ok
+}
+
+func (e *Executor) newExpander(ctx context.Context, vertex Vertex) (context.Context, *Expander) {
+	x := &Expander{e: e, vertex: vertex}
+	return context.WithValue(ctx, expanderKey{}, x), x
+}
+
+// below checks if vertices are the running vertex or its descendants.
+// only these vertices are guaranteed not to be started yet
+func (x *Expander) below(vertices ...Vertex) error {
+	descendants, err := x.e.graph.DFS(x.vertex)
+	if err != nil {
+		return err
+	}
+	for _, v := range vertices {
+		if !includes(descendants, v) {
+			return fmt.Errorf("vertex %s is not below running vertex %s", v, x.vertex)
+		}
+	}
+	return nil
+}
+
+// Append adds a new vertex after the given previous vertices, which must be the running vertex or its descendants.
+// the new vertex is appended after the running vertex when no previous vertices are given
+func (x *Expander) Append(v Vertex, prevVertices ...Vertex) error {
+	x.e.expandMu.Lock()
+	defer x.e.expandMu.Unlock()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.closed {
+		return fmt.Errorf("could not expand graph. task of vertex %s already returned", x.vertex)
+	}
+	if len(prevVertices) == 0 {
+		prevVertices = []Vertex{x.vertex}
+	}
+	if err := x.below(prevVertices...); err != nil {
+		return errors.Wrap(err, "could not expand graph")
+	}
+	if _, err := x.e.demandOf(v); err != nil {
+		return errors.Wrap(err, "could not expand graph")
+	}
+
+	if err := x.e.graph.Append(v, prevVertices); err != nil {
+		return errors.Wrap(err, "could not expand graph")
+	}
+
+	x.added = append(x.added, v)
+	for _, prevVertex := range prevVertices {
+		x.edges = append(x.edges, [2]Vertex{prevVertex, v})
+	}
+	return nil
+}
+
+// Connect connects two vertices that are the running vertex or its descendants, e.g. a new vertex to an existing
+// vertex that merges the results. cyclic edges are not allowed
+func (x *Expander) Connect(from Vertex, to Vertex) error {
+	x.e.expandMu.Lock()
+	defer x.e.expandMu.Unlock()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.closed {
+		return fmt.Errorf("could not expand graph. task of vertex %s already returned", x.vertex)
+	}
+	if err := x.below(from, to); err != nil {
+		return errors.Wrap(err, "could not expand graph")
+	}
+
+	if err := x.e.graph.Connect(from, to); err != nil {
+		return errors.Wrap(err, "could not expand graph")
+	}
+
+	x.edges = append(x.edges, [2]Vertex{from, to})
+	return nil
+}
+
+// expanded returns whether the task added vertices or edges
+func (x *Expander) expanded() bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.added) > 0 || len(x.edges) > 0
+}
+
+// close stops the expander & returns the vertices & edges that are added by the task
+func (x *Expander) close() (added []Vertex, edges [][2]Vertex) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.closed = true
+	return x.added, x.edges
+}
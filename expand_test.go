@@ -0,0 +1,179 @@
+package dag_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpander(t *testing.T) {
+	t.Run("ExpanderFrom", func(t *testing.T) {
+		t.Run("should return false outside of a task", func(t *testing.T) {
+			_, ok := dag.ExpanderFrom(context.Background())
+			assert.False(t, ok)
+		})
+	})
+
+	t.Run("Append", func(t *testing.T) {
+		t.Run("should schedule vertices added by a running task", func(t *testing.T) {
+			// discover -> merge, discover fans out into shards that merge depends on
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"discover", "merge"}), dag.WithEdges(dag.Edges{"discover": {"merge"}}))
+			assert.Nil(t, err)
+
+			var mu sync.Mutex
+			ran := []dag.Vertex{}
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				mu.Lock()
+				ran = append(ran, vertex)
+				mu.Unlock()
+
+				switch {
+				case vertex == "discover":
+					expander, ok := dag.ExpanderFrom(ctx)
+					if !ok {
+						return nil, fmt.Errorf("no expander")
+					}
+					for i := 0; i < 3; i++ {
+						shard := fmt.Sprintf("shard-%d", i)
+						if err := expander.Append(shard); err != nil {
+							return nil, err
+						}
+						if err := expander.Connect(shard, "merge"); err != nil {
+							return nil, err
+						}
+					}
+					return []byte("discovered"), nil
+				case strings.HasPrefix(vertex, "shard-"):
+					return []byte(vertex), nil
+				default:
+					parts := []string{}
+					for _, input := range inputs {
+						parts = append(parts, string(input))
+					}
+					sort.Strings(parts)
+					return []byte(strings.Join(parts, ",")), nil
+				}
+			}
+
+			executor, err := dag.NewExecutor(g, task)
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, 5, len(results))
+			assert.Equal(t, "discovered,shard-0,shard-1,shard-2", string(results["merge"].Output))
+			assert.Equal(t, dag.Vertex("merge"), ran[len(ran)-1])
+			assert.True(t, g.Exists("shard-2"))
+		})
+
+		t.Run("should reject vertices that are not below the running vertex", func(t *testing.T) {
+			errs := map[dag.Vertex]error{}
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "B" {
+					expander, _ := dag.ExpanderFrom(ctx)
+					errs["append"] = expander.Append("X", "D")
+					errs["duplicate"] = expander.Append("C")
+					errs["cycle"] = expander.Connect("C", "B")
+					errs["above"] = expander.Connect("B", "A")
+				}
+				return nil, nil
+			}
+
+			g := createGraph()
+			executor, err := dag.NewExecutor(g, task, dag.WithWorkers(1))
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+			for name, err := range errs {
+				assert.NotNil(t, err, name)
+			}
+			assert.False(t, g.Exists("X"))
+		})
+
+		t.Run("should fail the run when the demand of an added vertex is invalid", func(t *testing.T) {
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "F" {
+					expander, _ := dag.ExpanderFrom(ctx)
+					return nil, expander.Append("G")
+				}
+				return nil, nil
+			}
+			// the demand of G is valid when it is added & invalid when it is scheduled
+			var mu sync.Mutex
+			calls := 0
+			demand := func(vertex dag.Vertex) dag.Resources {
+				if vertex != "G" {
+					return nil
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				calls++
+				return dag.Resources{"cpu": calls}
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithResources(dag.Resources{"cpu": 1}, demand))
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.EqualError(t, err, "could not run graph: vertex G demands 2 of resource pool cpu with capacity 1")
+		})
+
+		t.Run("should not retry tasks that expanded the graph", func(t *testing.T) {
+			var mu sync.Mutex
+			attempts := map[dag.Vertex]int{}
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				mu.Lock()
+				attempts[vertex]++
+				attempt := attempts[vertex]
+				mu.Unlock()
+
+				if vertex == "F" {
+					expander, _ := dag.ExpanderFrom(ctx)
+					if err := expander.Append("G"); err != nil {
+						return nil, err
+					}
+				}
+				if (vertex == "E" || vertex == "F") && attempt == 1 {
+					return nil, fmt.Errorf("flaky")
+				}
+				return nil, nil
+			}
+
+			g := createGraph()
+			executor, err := dag.NewExecutor(g, task, dag.WithRetries(2, 0))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.EqualError(t, err, "could not run graph. 1 vertices failed")
+			assert.Equal(t, 2, attempts["E"])
+			assert.Equal(t, 1, attempts["F"])
+			assert.EqualError(t, results["F"].Err, "flaky")
+			assert.Equal(t, dag.StateSkipped, results["G"].State)
+			assert.True(t, g.Exists("G"))
+		})
+
+		t.Run("should reject expansions after the task returns", func(t *testing.T) {
+			var leaked *dag.Expander
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "F" {
+					leaked, _ = dag.ExpanderFrom(ctx)
+				}
+				return nil, nil
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task)
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.NotNil(t, leaked.Append("G"))
+		})
+	})
+}
@@ -0,0 +1,38 @@
+package dag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// vertexFile returns the path of the file that stores data of a vertex in a directory.
+// file names are the sha256 of vertex names, so that any vertex, however long, maps to a valid & distinct file name
+func vertexFile(dir string, vertex Vertex, ext string) string {
+	sum := sha256.Sum256([]byte(vertex))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+ext)
+}
+
+// writeFileAtomic replaces the file at path with data through a synced temp file in the same directory,
+// so a crash never leaves a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,211 @@
+package dag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// InputHashFunc returns the hash of the own inputs of a vertex, e.g. the digest of its source files or its configuration
+type InputHashFunc func(vertex Vertex) (string, error)
+
+// Fingerprint is the cached record of the last successful run of a vertex
+type Fingerprint struct {
+	// Input is the hash returned by the InputHashFunc of the vertex
+	Input string `json:"input"`
+	// Deps maps the previous vertices of the vertex to the hashes of their outputs
+	Deps map[Vertex]string `json:"deps"`
+	// Key is the combined hash of Input & Deps
+	Key    string `json:"key"`
+	Output []byte `json:"output,omitempty"`
+}
+
+// FingerprintCache stores the fingerprints of vertices between runs
+type FingerprintCache interface {
+	// Load returns the fingerprint of a vertex, ok is false when the vertex is not cached
+	Load(vertex Vertex) (fingerprint Fingerprint, ok bool, err error)
+	// Save stores the fingerprint of a vertex, replacing the existing one
+	Save(vertex Vertex, fingerprint Fingerprint) error
+}
+
+// StaleReason represents why a vertex is not up to date
+type StaleReason string
+
+const (
+	// StaleNotCached means the vertex has no fingerprint in the cache
+	StaleNotCached StaleReason = "not cached"
+	// StaleInputChanged means the input hash of the vertex changed
+	StaleInputChanged StaleReason = "input changed"
+	// StaleDepsChanged means the output of at least one of the previous vertices changed
+	StaleDepsChanged StaleReason = "dependencies changed"
+)
+
+// Staleness explains why a vertex is run again
+type Staleness struct {
+	Reason StaleReason
+	// Changed holds the previous vertices whose output changed, sorted by name
+	Changed []Vertex
+}
+
+// String returns a readable explanation, e.g. "dependencies changed: B, D"
+func (s Staleness) String() string {
+	if len(s.Changed) == 0 {
+		return string(s.Reason)
+	}
+	return fmt.Sprintf("%s: %s", s.Reason, strings.Join(s.Changed, ", "))
+}
+
+// WithFingerprint enables make style up to date checks
+//
+// before running a vertex, its input hash is combined with the output hashes of its previous vertices.
+// when the result matches the fingerprint in the cache, the cached output is used instead of running the task.
+// a vertex therefore runs again only if its own input changed or the output of one of its previous vertices changed
+func WithFingerprint(hash InputHashFunc, cache FingerprintCache) ExecutorOptions {
+	return func(e *Executor) error {
+		if hash == nil {
+			return fmt.Errorf("input hash func is nil")
+		}
+		if cache == nil {
+			return fmt.Errorf("fingerprint cache is nil")
+		}
+		e.inputHash = hash
+		e.fingerprints = cache
+		return nil
+	}
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newFingerprint combines the input hash of a vertex with the output hashes of its previous vertices
+func newFingerprint(input string, inputs map[Vertex][]byte) Fingerprint {
+	deps := make(map[Vertex]string, len(inputs))
+	names := make([]Vertex, 0, len(inputs))
+	for vertex, output := range inputs {
+		deps[vertex] = hashBytes(output)
+		names = append(names, vertex)
+	}
+	sort.Strings(names)
+
+	// length prefixes keep the key unambiguous for arbitrary vertex names
+	var key strings.Builder
+	fmt.Fprintf(&key, "%d:%s", len(input), input)
+	for _, name := range names {
+		fmt.Fprintf(&key, "%d:%s%s", len(name), name, deps[name])
+	}
+
+	return Fingerprint{Input: input, Deps: deps, Key: hashBytes([]byte(key.String()))}
+}
+
+// staleness compares a fresh fingerprint with the cached one, returns nil when the vertex is up to date
+func staleness(current Fingerprint, cached Fingerprint, ok bool) *Staleness {
+	if !ok {
+		return &Staleness{Reason: StaleNotCached}
+	}
+	if current.Input != cached.Input {
+		return &Staleness{Reason: StaleInputChanged}
+	}
+	if current.Key == cached.Key {
+		return nil
+	}
+
+	changed := []Vertex{}
+	for vertex, hash := range current.Deps {
+		if cachedHash, exists := cached.Deps[vertex]; !exists || cachedHash != hash {
+			changed = append(changed, vertex)
+		}
+	}
+	for vertex := range cached.Deps {
+		if _, exists := current.Deps[vertex]; !exists {
+			changed = append(changed, vertex)
+		}
+	}
+	sort.Strings(changed)
+	return &Staleness{Reason: StaleDepsChanged, Changed: changed}
+}
+
+// execute runs the task of a vertex unless its fingerprint shows that it is up to date
+func (e *Executor) execute(ctx context.Context, vertex Vertex, inputs map[Vertex][]byte) taskResult {
+	if e.fingerprints == nil {
+		output, err := e.attempt(ctx, vertex, inputs)
+		return taskResult{vertex: vertex, output: output, err: err}
+	}
+
+	input, err := e.inputHash(vertex)
+	if err != nil {
+		return taskResult{vertex: vertex, err: errors.Wrap(err, fmt.Sprintf("could not hash inputs of vertex %s", vertex))}
+	}
+	fingerprint := newFingerprint(input, inputs)
+
+	cached, ok, err := e.fingerprints.Load(vertex)
+	if err != nil {
+		return taskResult{vertex: vertex, err: errors.Wrap(err, fmt.Sprintf("could not load fingerprint of vertex %s", vertex))}
+	}
+
+	stale := staleness(fingerprint, cached, ok)
+	if stale == nil {
+		return taskResult{vertex: vertex, output: cached.Output, upToDate: true}
+	}
+
+	output, err := e.attempt(ctx, vertex, inputs)
+	if err != nil {
+		return taskResult{vertex: vertex, err: err, stale: stale}
+	}
+
+	fingerprint.Output = output
+	if err := e.fingerprints.Save(vertex, fingerprint); err != nil {
+		return taskResult{vertex: vertex, err: errors.Wrap(err, fmt.Sprintf("could not save fingerprint of vertex %s", vertex)), stale: stale}
+	}
+	return taskResult{vertex: vertex, output: output, stale: stale}
+}
+
+// DirFingerprintCache is a FingerprintCache that keeps a json file per vertex in a local directory
+type DirFingerprintCache struct {
+	dir string
+}
+
+// NewDirFingerprintCache creates a fingerprint cache in the given directory, creating the directory if it does not exist
+func NewDirFingerprintCache(dir string) (*DirFingerprintCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "could not create fingerprint cache")
+	}
+	return &DirFingerprintCache{dir: dir}, nil
+}
+
+// Load returns the fingerprint of a vertex
+func (c *DirFingerprintCache) Load(vertex Vertex) (fingerprint Fingerprint, ok bool, err error) {
+	data, err := os.ReadFile(vertexFile(c.dir, vertex, ".json"))
+	if os.IsNotExist(err) {
+		return Fingerprint{}, false, nil
+	}
+	if err != nil {
+		return Fingerprint{}, false, errors.Wrap(err, "could not read fingerprint")
+	}
+
+	if err := json.Unmarshal(data, &fingerprint); err != nil {
+		return Fingerprint{}, false, errors.Wrap(err, "could not decode fingerprint")
+	}
+	return fingerprint, true, nil
+}
+
+// Save writes the fingerprint of a vertex, replacing the existing fingerprint file atomically
+func (c *DirFingerprintCache) Save(vertex Vertex, fingerprint Fingerprint) error {
+	data, err := json.Marshal(fingerprint)
+	if err != nil {
+		return errors.Wrap(err, "could not encode fingerprint")
+	}
+
+	if err := writeFileAtomic(vertexFile(c.dir, vertex, ".json"), data); err != nil {
+		return errors.Wrap(err, "could not write fingerprint")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package dag_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Run("WithFingerprint", func(t *testing.T) {
+		t.Run("should return error when hash func or cache is nil", func(t *testing.T) {
+			cache, err := dag.NewDirFingerprintCache(t.TempDir())
+			assert.Nil(t, err)
+
+			_, err = dag.NewExecutor(createGraph(), concatTask, dag.WithFingerprint(nil, cache))
+			assert.NotNil(t, err)
+
+			_, err = dag.NewExecutor(createGraph(), concatTask, dag.WithFingerprint(func(dag.Vertex) (string, error) {
+				return "", nil
+			}, nil))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should run only stale vertices", func(t *testing.T) {
+			cache, err := dag.NewDirFingerprintCache(t.TempDir())
+			assert.Nil(t, err)
+
+			inputs := map[dag.Vertex]string{"A": "1", "B": "1", "C": "1", "D": "1", "E": "1", "F": "1"}
+			hash := func(vertex dag.Vertex) (string, error) {
+				return inputs[vertex], nil
+			}
+
+			var mu sync.Mutex
+			ran := []dag.Vertex{}
+			outputs := map[dag.Vertex]string{}
+			task := func(ctx context.Context, vertex dag.Vertex, in map[dag.Vertex][]byte) ([]byte, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				ran = append(ran, vertex)
+				if output, ok := outputs[vertex]; ok {
+					return []byte(output), nil
+				}
+				return concatTask(ctx, vertex, in)
+			}
+
+			executor, err := dag.NewExecutor(createGraph(), task, dag.WithFingerprint(hash, cache))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, 6, len(ran))
+			assert.Equal(t, dag.StaleNotCached, results["A"].Stale.Reason)
+
+			ran = []dag.Vertex{}
+			results, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Empty(t, ran)
+			assert.True(t, results["F"].UpToDate)
+			assert.Nil(t, results["F"].Stale)
+			assert.Equal(t, "A+B+A+D+E+F", string(results["F"].Output))
+
+			// D re-runs with the same output, so E stays up to date
+			inputs["D"] = "2"
+			ran = []dag.Vertex{}
+			results, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, []dag.Vertex{"D"}, ran)
+			assert.Equal(t, dag.StaleInputChanged, results["D"].Stale.Reason)
+			assert.True(t, results["E"].UpToDate)
+
+			// B re-runs with a different output, so C & E & F are stale
+			inputs["B"] = "2"
+			outputs["B"] = "changed"
+			ran = []dag.Vertex{}
+			results, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, []dag.Vertex{"B", "C", "E", "F"}, ran)
+			assert.Equal(t, "dependencies changed: B", results["E"].Stale.String())
+			assert.Equal(t, "dependencies changed: E", results["F"].Stale.String())
+			assert.True(t, results["D"].UpToDate)
+		})
+	})
+}
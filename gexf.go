@@ -0,0 +1,316 @@
+package dag
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	gexfNamespace = "http://gexf.net/1.3"
+	gexfVersion   = "1.3"
+)
+
+// gexfTypes maps attribute types to GEXF types
+var gexfTypes = map[string]string{
+	attributeString:  "string",
+	attributeBoolean: "boolean",
+	attributeInt:     "integer",
+	attributeLong:    "long",
+	attributeFloat:   "float",
+	attributeDouble:  "double",
+}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr,omitempty"`
+	Version string    `xml:"version,attr,omitempty"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr,omitempty"`
+	Mode            string           `xml:"mode,attr,omitempty"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Mode       string          `xml:"mode,attr,omitempty"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID      string  `xml:"id,attr"`
+	Title   string  `xml:"title,attr"`
+	Type    string  `xml:"type,attr"`
+	Default *string `xml:"default"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr,omitempty"`
+	Values *gexfValues `xml:"attvalues"`
+}
+
+type gexfEdge struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Type   string      `xml:"type,attr,omitempty"`
+	Weight string      `xml:"weight,attr,omitempty"`
+	Values *gexfValues `xml:"attvalues"`
+}
+
+// gexfValues is a pointer in nodes & edges, empty attvalues elements are not written
+type gexfValues struct {
+	Values []gexfValue `xml:"attvalue"`
+}
+
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// WriteGEXF writes the graph as GEXF, e.g. for Gephi. vertex payloads must be Attributes or nil
+func (g *Graph) WriteGEXF(w io.Writer) error {
+	return (&AttributedGraph{Graph: g}).WriteGEXF(w)
+}
+
+// WriteGEXF writes the graph as GEXF 1.3, e.g. for Gephi. every vertex & edge attribute is declared as a typed
+// attribute, int values are declared as integer & int64 values as long. vertices are labeled with their names
+//
+//	<gexf xmlns="http://gexf.net/1.3" version="1.3">
+//	  <graph defaultedgetype="directed" mode="static">
+//	    <attributes class="node" mode="static">
+//	      <attribute id="0" title="owner" type="string"></attribute>
+//	    </attributes>
+//	    <nodes>
+//	      <node id="A" label="A">
+//	        <attvalues>
+//	          <attvalue for="0" value="infra"></attvalue>
+//	        </attvalues>
+//	      </node>
+//	      <node id="B" label="B"></node>
+//	    </nodes>
+//	    <edges>
+//	      <edge id="0" source="A" target="B"></edge>
+//	    </edges>
+//	  </graph>
+//	</gexf>
+func (a *AttributedGraph) WriteGEXF(w io.Writer) error {
+	doc, err := a.gexf()
+	if err != nil {
+		return errors.Wrap(err, "could not write gexf")
+	}
+	return writeXML(w, doc)
+}
+
+func (a *AttributedGraph) gexf() (*gexfDocument, error) {
+	vertices, edges, payloads, err := a.attributes()
+	if err != nil {
+		return nil, err
+	}
+	vertexKeys, edgeKeys, err := a.attributeKeys(vertices, edges, payloads)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := gexfGraph{DefaultEdgeType: "directed", Mode: "static"}
+	declared := 0
+	declare := func(keys []attributeKey, class string) []string {
+		if len(keys) == 0 {
+			return nil
+		}
+		ids := make([]string, len(keys))
+		definitions := gexfAttributes{Class: class, Mode: "static"}
+		for i, key := range keys {
+			ids[i] = strconv.Itoa(declared)
+			declared++
+			definitions.Attributes = append(definitions.Attributes, gexfAttribute{
+				ID:    ids[i],
+				Title: key.name,
+				Type:  gexfTypes[key.kind],
+			})
+		}
+		graph.Attributes = append(graph.Attributes, definitions)
+		return ids
+	}
+	vertexIDs := declare(vertexKeys, "node")
+	edgeIDs := declare(edgeKeys, "edge")
+	attvalues := func(keys []attributeKey, ids []string, attributes Attributes) *gexfValues {
+		var attvalues []gexfValue
+		for i, key := range keys {
+			if value, ok := attributes[key.name]; ok {
+				attvalues = append(attvalues, gexfValue{For: ids[i], Value: formatAttribute(value)})
+			}
+		}
+		if len(attvalues) == 0 {
+			return nil
+		}
+		return &gexfValues{Values: attvalues}
+	}
+
+	for _, v := range vertices {
+		graph.Nodes = append(graph.Nodes, gexfNode{ID: v, Label: v, Values: attvalues(vertexKeys, vertexIDs, payloads[v])})
+	}
+	for _, from := range vertices {
+		for _, to := range edges[from] {
+			graph.Edges = append(graph.Edges, gexfEdge{
+				ID:     strconv.Itoa(len(graph.Edges)),
+				Source: from,
+				Target: to,
+				Values: attvalues(edgeKeys, edgeIDs, a.Edges[Edge{From: from, To: to}]),
+			})
+		}
+	}
+	return &gexfDocument{Xmlns: gexfNamespace, Version: gexfVersion, Graph: graph}, nil
+}
+
+// LoadGEXFFile loads a graph from a GEXF file
+func LoadGEXFFile(path string) (*AttributedGraph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load gexf")
+	}
+	defer file.Close()
+	return LoadGEXF(file)
+}
+
+// LoadGEXF loads a graph from GEXF, e.g. the output of WriteGEXF or Gephi. attribute values become the attributes of
+// vertices & edges, attribute defaults are applied to vertices & edges without the value. integer attributes are
+// loaded as int, types other than the ones WriteGEXF writes, such as liststring or date, are loaded as strings.
+//
+// node labels that differ from their ids are loaded as label attributes & edge weights as float64 weight attributes,
+// unless attributes with these names exist. dynamic attributes & visualization data are ignored.
+//
+// vertices are added in node order & edges in edge order, so the output of WriteGEXF is loaded back exactly.
+// undirected & mutual edges are not supported
+func LoadGEXF(r io.Reader) (*AttributedGraph, error) {
+	var doc gexfDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "could not load gexf")
+	}
+	a, err := doc.graph()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load gexf")
+	}
+	return a, nil
+}
+
+func (doc *gexfDocument) graph() (*AttributedGraph, error) {
+	graph := doc.Graph
+	definitions := map[string]map[string]gexfAttribute{"node": {}, "edge": {}}
+	dynamic := map[string]map[string]bool{"node": {}, "edge": {}}
+	for _, attributes := range graph.Attributes {
+		if definitions[attributes.Class] == nil {
+			continue
+		}
+		for _, attribute := range attributes.Attributes {
+			if attributes.Mode == "dynamic" {
+				dynamic[attributes.Class][attribute.ID] = true
+				continue
+			}
+			kind := attributeString
+			for known, gexfType := range gexfTypes {
+				if attribute.Type == gexfType {
+					kind = known
+				}
+			}
+			attribute.Type = kind
+			definitions[attributes.Class][attribute.ID] = attribute
+		}
+	}
+	attributes := func(class string, attvalues *gexfValues) (Attributes, error) {
+		attributes := Attributes{}
+		for _, definition := range definitions[class] {
+			if definition.Default == nil {
+				continue
+			}
+			value, err := parseAttribute(definition.Type, *definition.Default)
+			if err != nil {
+				return nil, fmt.Errorf("default of attribute %s: %s", definition.ID, err)
+			}
+			attributes[definition.Title] = value
+		}
+		if attvalues == nil {
+			return attributes, nil
+		}
+		for _, attvalue := range attvalues.Values {
+			definition, ok := definitions[class][attvalue.For]
+			if !ok && dynamic[class][attvalue.For] {
+				continue
+			}
+			if !ok {
+				return nil, fmt.Errorf("attribute %s is not found", attvalue.For)
+			}
+			value, err := parseAttribute(definition.Type, attvalue.Value)
+			if err != nil {
+				return nil, fmt.Errorf("value of attribute %s: %s", definition.ID, err)
+			}
+			attributes[definition.Title] = value
+		}
+		return attributes, nil
+	}
+
+	a := &AttributedGraph{Edges: map[Edge]Attributes{}}
+	g, err := New()
+	if err != nil {
+		return nil, err
+	}
+	a.Graph = g
+	for _, node := range graph.Nodes {
+		if err := g.Add(node.ID); err != nil {
+			return nil, err
+		}
+		vertexAttributes, err := attributes("node", node.Values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "node %s", node.ID)
+		}
+		if _, ok := vertexAttributes["label"]; !ok && node.Label != "" && node.Label != node.ID {
+			vertexAttributes["label"] = node.Label
+		}
+		if len(vertexAttributes) > 0 {
+			if err := g.SetPayload(node.ID, vertexAttributes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, edge := range graph.Edges {
+		edgeType := edge.Type
+		if edgeType == "" {
+			edgeType = graph.DefaultEdgeType
+		}
+		if edgeType == "" {
+			// the default edge type of GEXF is undirected
+			edgeType = "undirected"
+		}
+		if edgeType != "directed" {
+			return nil, fmt.Errorf("undirected edges are not supported, edge %s -> %s is %s", edge.Source, edge.Target, edgeType)
+		}
+		if err := g.Connect(edge.Source, edge.Target); err != nil {
+			return nil, err
+		}
+		edgeAttributes, err := attributes("edge", edge.Values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "edge %s -> %s", edge.Source, edge.Target)
+		}
+		if _, ok := edgeAttributes["weight"]; !ok && edge.Weight != "" {
+			weight, err := strconv.ParseFloat(edge.Weight, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "weight of edge %s -> %s", edge.Source, edge.Target)
+			}
+			edgeAttributes["weight"] = weight
+		}
+		if len(edgeAttributes) > 0 {
+			a.Edges[Edge{From: edge.Source, To: edge.Target}] = edgeAttributes
+		}
+	}
+	return a, nil
+}
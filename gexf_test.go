@@ -0,0 +1,108 @@
+package dag_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGEXF(t *testing.T) {
+	t.Run("should write typed attributes", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B"}), dag.WithEdges(dag.Edges{"A": {"B"}}))
+		assert.Nil(t, err)
+		assert.Nil(t, g.SetPayload("A", dag.Attributes{"retries": 3}))
+		a := &dag.AttributedGraph{Graph: g, Edges: map[dag.Edge]dag.Attributes{{From: "A", To: "B"}: {"cached": true}}}
+
+		var out bytes.Buffer
+		assert.Nil(t, a.WriteGEXF(&out))
+		assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph defaultedgetype="directed" mode="static">
+    <attributes class="node" mode="static">
+      <attribute id="0" title="retries" type="integer"></attribute>
+    </attributes>
+    <attributes class="edge" mode="static">
+      <attribute id="1" title="cached" type="boolean"></attribute>
+    </attributes>
+    <nodes>
+      <node id="A" label="A">
+        <attvalues>
+          <attvalue for="0" value="3"></attvalue>
+        </attvalues>
+      </node>
+      <node id="B" label="B"></node>
+    </nodes>
+    <edges>
+      <edge id="0" source="A" target="B">
+        <attvalues>
+          <attvalue for="1" value="true"></attvalue>
+        </attvalues>
+      </edge>
+    </edges>
+  </graph>
+</gexf>
+`, out.String())
+	})
+
+	t.Run("should round trip vertices, edges & attributes", func(t *testing.T) {
+		a := createAttributedGraph(t)
+		var out bytes.Buffer
+		assert.Nil(t, a.WriteGEXF(&out))
+
+		loaded, err := dag.LoadGEXF(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, a.Graph.Vertices(), loaded.Graph.Vertices())
+		assert.Equal(t, a.Graph.Edges(), loaded.Graph.Edges())
+		for _, v := range a.Graph.Vertices() {
+			assert.Equal(t, a.VertexAttributes(v), loaded.VertexAttributes(v), v)
+		}
+		assert.Equal(t, a.Edges, loaded.Edges)
+	})
+
+	t.Run("should load Gephi files with labels, weights & defaults", func(t *testing.T) {
+		a, err := dag.LoadGEXF(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://www.gexf.net/1.2draft" xmlns:viz="http://www.gexf.net/1.2draft/viz" version="1.2">
+  <meta lastmodifieddate="2024-01-01"><creator>Gephi 0.10</creator></meta>
+  <graph defaultedgetype="directed" mode="static">
+    <attributes class="node" mode="static">
+      <attribute id="modularity_class" title="Modularity Class" type="integer"><default>0</default></attribute>
+      <attribute id="tags" title="tags" type="liststring"></attribute>
+    </attributes>
+    <attributes class="node" mode="dynamic">
+      <attribute id="score" title="score" type="double"></attribute>
+    </attributes>
+    <nodes>
+      <node id="1" label="build">
+        <attvalues><attvalue for="tags" value="[go, ci]"></attvalue><attvalue for="score" value="1" start="2"></attvalue></attvalues>
+        <viz:size value="10.0"></viz:size>
+      </node>
+      <node id="2" label="2"><attvalues><attvalue for="modularity_class" value="1"></attvalue></attvalues></node>
+    </nodes>
+    <edges>
+      <edge id="0" source="1" target="2" weight="2.5"></edge>
+    </edges>
+  </graph>
+</gexf>`))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"1", "2"}, a.Graph.Vertices())
+		assert.Equal(t, dag.Attributes{"Modularity Class": 0, "tags": "[go, ci]", "label": "build"}, a.VertexAttributes("1"))
+		assert.Equal(t, dag.Attributes{"Modularity Class": 1}, a.VertexAttributes("2"))
+		assert.Equal(t, dag.Attributes{"weight": 2.5}, a.EdgeAttributes("1", "2"))
+	})
+
+	t.Run("should return errors", func(t *testing.T) {
+		inputs := map[string]string{
+			`<gexf><graph><nodes><node id="a"/><node id="b"/></nodes><edges><edge id="0" source="a" target="b"/></edges></graph></gexf>`:                                          "could not load gexf: undirected edges are not supported, edge a -> b is undirected",
+			`<gexf><graph defaultedgetype="directed"><nodes><node id="a"/><node id="b"/></nodes><edges><edge id="0" source="a" target="b" type="mutual"/></edges></graph></gexf>`: "could not load gexf: undirected edges are not supported, edge a -> b is mutual",
+			`<gexf><graph defaultedgetype="directed"><nodes><node id="a"><attvalues><attvalue for="0" value="x"/></attvalues></node></nodes></graph></gexf>`:                      "could not load gexf: node a: attribute 0 is not found",
+			`<gexf><graph defaultedgetype="directed"><nodes><node id="a"/></nodes><edges><edge id="0" source="a" target="b"/></edges></graph></gexf>`:                             "could not load gexf: could not connect vertex a to vertex b: vertex b is not found in graph",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadGEXF(strings.NewReader(input))
+			assert.EqualError(t, err, message, input)
+		}
+	})
+}
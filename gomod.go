@@ -0,0 +1,170 @@
+package dag
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GoModule is a Go module, it is the payload of vertices that are loaded from go mod graph. the fields are named as in
+// the Module struct of go list -json, so modules of packages are decoded into it as well
+type GoModule struct {
+	Path string `json:"Path"`
+	// Version is empty for the main module
+	Version   string    `json:"Version,omitempty"`
+	Main      bool      `json:"Main,omitempty"`
+	Indirect  bool      `json:"Indirect,omitempty"`
+	Replace   *GoModule `json:"Replace,omitempty"`
+	Dir       string    `json:"Dir,omitempty"`
+	GoMod     string    `json:"GoMod,omitempty"`
+	GoVersion string    `json:"GoVersion,omitempty"`
+}
+
+// GoPackage is a Go package, it is the payload of vertices that are loaded from go list -deps -json
+type GoPackage struct {
+	ImportPath string `json:"ImportPath"`
+	Name       string `json:"Name,omitempty"`
+	Dir        string `json:"Dir,omitempty"`
+	// Standard is true for packages of the standard library
+	Standard bool `json:"Standard,omitempty"`
+	// DepOnly is true for packages that are only listed as dependencies of the listed packages
+	DepOnly bool            `json:"DepOnly,omitempty"`
+	Module  *GoModule       `json:"Module,omitempty"`
+	Imports []string        `json:"Imports,omitempty"`
+	Error   *GoPackageError `json:"Error,omitempty"`
+}
+
+// GoPackageError is the error of a package that go list -e could not load
+type GoPackageError struct {
+	ImportStack []string `json:"ImportStack,omitempty"`
+	Pos         string   `json:"Pos,omitempty"`
+	Err         string   `json:"Err"`
+}
+
+// LoadGoModGraphFile loads a module graph from a file with the output of go mod graph
+func LoadGoModGraphFile(path string) (*Graph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load go mod graph")
+	}
+	defer file.Close()
+	return LoadGoModGraph(file)
+}
+
+// LoadGoModGraph loads a module graph from the output of go mod graph. every line holds a module & one of its
+// requirements, vertices are named module@version as in the output & their payloads are *GoModule
+//
+//	example.com/app golang.org/x/text@v0.14.0
+//	golang.org/x/text@v0.14.0 golang.org/x/tools@v0.6.0
+//
+// requirements are connected to the modules that require them, so TopSort lists requirements first & Deps returns
+// the requirements of a module. the main module is the only module without a version
+func LoadGoModGraph(r io.Reader) (*Graph, error) {
+	g, err := New()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load go mod graph")
+	}
+
+	add := func(vertex Vertex) {
+		if g.Exists(vertex) {
+			return
+		}
+		module := &GoModule{Path: vertex}
+		if at := strings.LastIndex(vertex, "@"); at > 0 {
+			module.Path, module.Version = vertex[:at], vertex[at+1:]
+		} else {
+			module.Main = true
+		}
+		_ = g.Add(vertex)
+		_ = g.SetPayload(vertex, module)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("could not load go mod graph. line %d: expected a module & a requirement, got %d fields", line, len(fields))
+		}
+
+		module, requirement := fields[0], fields[1]
+		add(module)
+		add(requirement)
+		next, _ := g.Next(requirement)
+		if includes(next, module) {
+			continue
+		}
+		if err := connectDependency(g, requirement, module); err != nil {
+			return nil, fmt.Errorf("could not load go mod graph. line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not load go mod graph")
+	}
+	return g, nil
+}
+
+// LoadGoListFile loads a package graph from a file with the output of go list -deps -json
+func LoadGoListFile(path string) (*Graph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load go list")
+	}
+	defer file.Close()
+	return LoadGoList(file)
+}
+
+// LoadGoList loads a package graph from the json stream of go list -deps -json, e.g. go list -deps -json ./...
+// vertices are named by import paths in the order of the stream & their payloads are *GoPackage. imports are connected
+// to the packages that import them, so TopSort lists imports first & Deps returns the imports of a package.
+//
+// the C pseudo package of cgo is skipped, other imports must be listed in the stream, which -deps ensures
+func LoadGoList(r io.Reader) (*Graph, error) {
+	g, err := New()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load go list")
+	}
+
+	packages := []*GoPackage{}
+	decoder := json.NewDecoder(r)
+	for {
+		pkg := &GoPackage{}
+		if err := decoder.Decode(pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "could not load go list. package %d", len(packages)+1)
+		}
+		if pkg.ImportPath == "" {
+			return nil, fmt.Errorf("could not load go list. package %d has no import path", len(packages)+1)
+		}
+		if g.Exists(pkg.ImportPath) {
+			return nil, fmt.Errorf("could not load go list. package %s is listed twice", pkg.ImportPath)
+		}
+		_ = g.Add(pkg.ImportPath)
+		_ = g.SetPayload(pkg.ImportPath, pkg)
+		packages = append(packages, pkg)
+	}
+
+	for _, pkg := range packages {
+		for _, imported := range pkg.Imports {
+			if imported == "C" {
+				continue
+			}
+			if !g.Exists(imported) {
+				return nil, fmt.Errorf("could not load go list. import %s of package %s is not found, list packages with -deps", imported, pkg.ImportPath)
+			}
+			if err := connectDependency(g, imported, pkg.ImportPath); err != nil {
+				return nil, errors.Wrap(err, "could not load go list")
+			}
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,106 @@
+package dag_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadGoModGraph(t *testing.T) {
+	t.Run("should load modules with versions", func(t *testing.T) {
+		g, err := dag.LoadGoModGraph(strings.NewReader(`example.com/app github.com/pkg/errors@v0.9.1
+example.com/app github.com/stretchr/testify@v1.9.0
+example.com/app go@1.21
+
+github.com/stretchr/testify@v1.9.0 gopkg.in/yaml.v3@v3.0.1
+gopkg.in/yaml.v3@v3.0.1 gopkg.in/check.v1@v0.0.0-20161208181325-20d25e280405
+`))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{
+			"example.com/app", "github.com/pkg/errors@v0.9.1", "github.com/stretchr/testify@v1.9.0", "go@1.21",
+			"gopkg.in/yaml.v3@v3.0.1", "gopkg.in/check.v1@v0.0.0-20161208181325-20d25e280405",
+		}, g.Vertices())
+
+		deps, err := g.Deps("github.com/stretchr/testify@v1.9.0")
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"gopkg.in/check.v1@v0.0.0-20161208181325-20d25e280405", "gopkg.in/yaml.v3@v3.0.1"}, deps)
+
+		payload, _ := g.Payload("example.com/app")
+		assert.Equal(t, &dag.GoModule{Path: "example.com/app", Main: true}, payload)
+		payload, _ = g.Payload("gopkg.in/yaml.v3@v3.0.1")
+		assert.Equal(t, &dag.GoModule{Path: "gopkg.in/yaml.v3", Version: "v3.0.1"}, payload)
+	})
+
+	t.Run("should return errors with lines", func(t *testing.T) {
+		inputs := map[string]string{
+			"a b@v1\nb@v1":           "could not load go mod graph. line 2: expected a module & a requirement, got 1 fields",
+			"a b@v1\nb@v1 c@v1 d@v1": "could not load go mod graph. line 2: expected a module & a requirement, got 3 fields",
+			"a@v1 b@v1\nb@v1 a@v1":   "could not load go mod graph. line 2: edge a@v1 -> b@v1 creates a cycle a@v1 -> b@v1 -> a@v1",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadGoModGraph(strings.NewReader(input))
+			assert.EqualError(t, err, message, input)
+		}
+	})
+}
+
+func TestLoadGoList(t *testing.T) {
+	t.Run("should load packages with imports & modules", func(t *testing.T) {
+		g, err := dag.LoadGoList(strings.NewReader(`{
+	"ImportPath": "errors",
+	"Name": "errors",
+	"Standard": true,
+	"DepOnly": true
+}
+{
+	"ImportPath": "github.com/pkg/errors",
+	"Name": "errors",
+	"DepOnly": true,
+	"Module": {"Path": "github.com/pkg/errors", "Version": "v0.9.1", "GoVersion": "1.13"},
+	"Imports": ["errors", "C"]
+}
+{
+	"Dir": "/src/app",
+	"ImportPath": "example.com/app",
+	"Name": "main",
+	"Module": {"Path": "example.com/app", "Main": true},
+	"Imports": ["errors", "github.com/pkg/errors"],
+	"Error": {"Err": "no Go files"}
+}
+`))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"errors", "github.com/pkg/errors", "example.com/app"}, g.Vertices())
+		assert.Equal(t, dag.Edges{
+			"errors":                {"github.com/pkg/errors", "example.com/app"},
+			"github.com/pkg/errors": {"example.com/app"},
+			"example.com/app":       {},
+		}, g.Edges())
+
+		payload, _ := g.Payload("github.com/pkg/errors")
+		assert.Equal(t, &dag.GoPackage{
+			ImportPath: "github.com/pkg/errors",
+			Name:       "errors",
+			DepOnly:    true,
+			Module:     &dag.GoModule{Path: "github.com/pkg/errors", Version: "v0.9.1", GoVersion: "1.13"},
+			Imports:    []string{"errors", "C"},
+		}, payload)
+		payload, _ = g.Payload("example.com/app")
+		assert.Equal(t, "no Go files", payload.(*dag.GoPackage).Error.Err)
+	})
+
+	t.Run("should return errors", func(t *testing.T) {
+		inputs := map[string]string{
+			`{"ImportPath": "a", "Imports": ["b"]}`:   "could not load go list. import b of package a is not found, list packages with -deps",
+			`{"ImportPath": "a"} {"ImportPath": "a"}`: "could not load go list. package a is listed twice",
+			`{"Name": "a"}`:                         "could not load go list. package 1 has no import path",
+			`{"ImportPath": "a"} {"ImportPath": `:   "could not load go list. package 2: unexpected EOF",
+			`{"ImportPath": "a", "Imports": ["a"]}`: "could not load go list: edge a -> a creates a cycle a -> a",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadGoList(strings.NewReader(input))
+			assert.EqualError(t, err, message, input)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package dag
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/aacanakin/dag/queue"
@@ -33,6 +34,18 @@ func WithEdges(edges Edges) GraphOptions {
 	}
 }
 
+// WithPayloads sets the payloads of vertices, the vertices must be added before
+func WithPayloads(payloads map[Vertex]any) GraphOptions {
+	return func(g *Graph) error {
+		for vertex, payload := range payloads {
+			if err := g.SetPayload(vertex, payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 // Vertex represents a vertex or node in the graph
 type Vertex = string
 
@@ -40,11 +53,42 @@ type Vertex = string
 // Edges represents the edges of the graph
 type Edges map[Vertex][]Vertex
 
+// Edge is an edge from a vertex to one of its next vertices
+type Edge struct {
+	From Vertex `json:"from"`
+	To   Vertex `json:"to"`
+}
+
+// CycleError is returned when an edge from a vertex to another vertex would create a cycle
+type CycleError struct {
+	From Vertex
+	To   Vertex
+	// Cycle holds the vertices of the cycle, starting & ending with the same vertex. it is nil when the cycle is not known
+	Cycle []Vertex
+}
+
+func (e *CycleError) Error() string {
+	if len(e.Cycle) == 0 {
+		return fmt.Sprintf("cyclic edges are not allowed from %s to %s", e.From, e.To)
+	}
+	return fmt.Sprintf("edge %s -> %s creates a cycle %s", e.From, e.To, strings.Join(e.Cycle, " -> "))
+}
+
+// VertexNotFoundError is returned when a vertex is not found in the graph
+type VertexNotFoundError struct {
+	Vertex Vertex
+}
+
+func (e *VertexNotFoundError) Error() string {
+	return fmt.Sprintf("vertex %s is not found in graph", e.Vertex)
+}
+
 // New creates an empty graph with no vertices & edges and returns it
 func New(opts ...GraphOptions) (*Graph, error) {
 	g := &Graph{
 		vertices: []Vertex{},
 		edges:    map[Vertex][]Vertex{},
+		payloads: map[Vertex]any{},
 	}
 
 	for _, opt := range opts {
@@ -60,6 +104,14 @@ type Graph struct {
 	mu       sync.RWMutex
 	vertices []Vertex
 	edges    Edges
+	payloads map[Vertex]any
+	// readOnly is set for views of other graphs, e.g. revisions of a VersionedGraph, & never changes
+	readOnly bool
+}
+
+// errReadOnly is returned by the mutations of read-only graphs
+func errReadOnly() error {
+	return fmt.Errorf("graph is read-only")
 }
 
 // Edges returns the edges of the graph
@@ -84,6 +136,29 @@ func (g *Graph) Exists(vertex Vertex) bool {
 	return exists
 }
 
+// SetPayload attaches arbitrary data to a vertex, replacing the existing payload
+func (g *Graph) SetPayload(vertex Vertex, payload any) error {
+	if g.readOnly {
+		return errReadOnly()
+	}
+	if existing := g.Exists(vertex); !existing {
+		return &VertexNotFoundError{Vertex: vertex}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.payloads[vertex] = payload
+	return nil
+}
+
+// Payload returns the payload of a vertex, ok is false when the vertex has no payload
+func (g *Graph) Payload(vertex Vertex) (payload any, ok bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	payload, ok = g.payloads[vertex]
+	return payload, ok
+}
+
 // Prev returns the previous vertices of a given vertex
 func (g *Graph) Prev(vertex Vertex) (prev []Vertex, err error) {
 	if existing := g.Exists(vertex); !existing {
@@ -150,7 +225,14 @@ func (g *Graph) Reverse() (*Graph, error) {
 		return nil, errors.Wrap(err, "could not reverse graph")
 	}
 
-	return &Graph{vertices: g.Vertices(), edges: revEdges}, nil
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	payloads := make(map[Vertex]any, len(g.payloads))
+	for vertex, payload := range g.payloads {
+		payloads[vertex] = payload
+	}
+
+	return &Graph{vertices: g.vertices, edges: revEdges, payloads: payloads}, nil
 }
 
 // DFS performs depth first search on the graph starting from the given vertex
@@ -260,6 +342,49 @@ func (g *Graph) ReverseDeps(vertex Vertex) (result []Vertex, err error) {
 	return sorted, err
 }
 
+// Path returns the shortest path from a vertex to another vertex, including both vertices
+// returns error if any of the vertices is not found in the graph or the vertices are not connected
+func (g *Graph) Path(from Vertex, to Vertex) ([]Vertex, error) {
+	if !g.Exists(to) {
+		return []Vertex{}, &VertexNotFoundError{Vertex: to}
+	}
+
+	queue := queue.New()
+	queue.Enqueue(from)
+	parents := map[Vertex]Vertex{}
+	visited := set.New()
+	visited.Add(from)
+
+	for queue.Size() > 0 {
+		current, err := queue.Pop()
+		if err != nil {
+			return []Vertex{}, errors.Wrap(err, "could not find path")
+		}
+
+		if current == to {
+			path := []Vertex{to}
+			for current != from {
+				current = parents[current]
+				path = append([]Vertex{current}, path...)
+			}
+			return path, nil
+		}
+
+		next, err := g.Next(current)
+		if err != nil {
+			return []Vertex{}, errors.Wrap(err, "could not find path")
+		}
+		for _, nextVertex := range next {
+			if visited.Add(nextVertex) {
+				parents[nextVertex] = current
+				queue.Enqueue(nextVertex)
+			}
+		}
+	}
+
+	return []Vertex{}, fmt.Errorf("there is no path from vertex %s to vertex %s", from, to)
+}
+
 // Leaves returns the leaf vertices of the graph
 func (g *Graph) Leaves() (leaves []Vertex, err error) {
 	for _, vertex := range g.Vertices() {
@@ -287,6 +412,9 @@ func (g *Graph) Roots() ([]Vertex, error) {
 // Append adds a new vertex to graph given vertex and previous vertices,
 // returns error if any of the previous vertices is not present in graph
 func (g *Graph) Append(v Vertex, prevVertices []Vertex) error {
+	if g.readOnly {
+		return errors.Wrap(errReadOnly(), "could not append node to graph")
+	}
 	if existing := g.Exists(v); existing {
 		return errors.Wrap(fmt.Errorf("duplicate node id=%s are not allowed", v), "could not append node to graph")
 	}
@@ -312,6 +440,9 @@ func (g *Graph) Append(v Vertex, prevVertices []Vertex) error {
 
 // Add appends an unconnected node to the graph
 func (g *Graph) Add(vertices ...Vertex) error {
+	if g.readOnly {
+		return errReadOnly()
+	}
 	if len(vertices) == 0 {
 		return fmt.Errorf("no vertices to add to graph")
 	}
@@ -365,7 +496,13 @@ func (g *Graph) hasDep(from Vertex, to Vertex) bool {
 //
 // it can be used to lazily initialize vertice connections
 func (g *Graph) Connect(from Vertex, to Vertex) error {
+	if g.readOnly {
+		return errors.Wrap(errReadOnly(), fmt.Sprintf("could not connect vertex %s to vertex %s", from, to))
+	}
 
+	if existing := g.Exists(from); !existing {
+		return errors.Wrap(&VertexNotFoundError{Vertex: from}, fmt.Sprintf("could not connect vertex %s to vertex %s", from, to))
+	}
 	hasEdge, err := g.hasNext(from, to)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("could not connect vertex %s to vertex %s", from, to))
@@ -374,8 +511,12 @@ func (g *Graph) Connect(from Vertex, to Vertex) error {
 		return fmt.Errorf("could not connect vertex %s to vertex %s. edge already exists", from, to)
 	}
 
+	if existing := g.Exists(to); !existing {
+		return errors.Wrap(&VertexNotFoundError{Vertex: to}, fmt.Sprintf("could not connect vertex %s to vertex %s", from, to))
+	}
+
 	if hasCycle := g.hasDep(to, from); hasCycle {
-		return fmt.Errorf("could not connect nodes. reason: cyclic edges are not allowed from %s to %s", from, to)
+		return errors.Wrap(&CycleError{From: from, To: to}, "could not connect nodes. reason")
 	}
 
 	g.mu.Lock()
@@ -388,6 +529,9 @@ func (g *Graph) Connect(from Vertex, to Vertex) error {
 // DisconnectEdge disconnects two vertices in the graph
 // returns error if the edge does not exist
 func (g *Graph) DisconnectEdge(from Vertex, to Vertex) error {
+	if g.readOnly {
+		return errors.Wrap(errReadOnly(), fmt.Sprintf("could not disconnect graph node prev=%s next=%s", from, to))
+	}
 	g.mu.RLock()
 	edgeIndex := index(g.edges[from], func(v Vertex) bool {
 		return v == to
@@ -436,6 +580,9 @@ func (g *Graph) Disconnect(v Vertex) error {
 
 // Remove removes node, all next nodes that are connected to that node & clears all edges that are related to node & deps
 func (g *Graph) Remove(v Vertex) (removed []Vertex, err error) {
+	if g.readOnly {
+		return removed, errors.Wrap(errReadOnly(), "could not remove node")
+	}
 	toRemove, err := g.DFS(v)
 	if err != nil {
 		return removed, errors.Wrap(err, "could not remove node")
@@ -452,7 +599,10 @@ func (g *Graph) Remove(v Vertex) (removed []Vertex, err error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	g.vertices = exclude(g.vertices, toRemove...)
-	delete(g.edges, v)
+	for _, removedVertex := range toRemove {
+		delete(g.edges, removedVertex)
+		delete(g.payloads, removedVertex)
+	}
 
 	return removed, nil
 }
@@ -502,7 +652,35 @@ func (g *Graph) TopSort() (result []Vertex, err error) {
 	return result, nil
 }
 
-// DeepCopy creates a deep copy of the graph
+// CriticalPathLengths returns the number of vertices on the longest path from each vertex to a leaf, including the vertex itself
+// example: {a: [b, c], b: [c], c: []} -> {a: 3, b: 2, c: 1}
+func (g *Graph) CriticalPathLengths() (map[Vertex]int, error) {
+	sorted, err := g.TopSort()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not calculate critical path lengths")
+	}
+
+	lengths := make(map[Vertex]int, len(sorted))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		vertex := sorted[i]
+		next, err := g.Next(vertex)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not calculate critical path lengths")
+		}
+
+		length := 0
+		for _, nextVertex := range next {
+			if lengths[nextVertex] > length {
+				length = lengths[nextVertex]
+			}
+		}
+		lengths[vertex] = length + 1
+	}
+
+	return lengths, nil
+}
+
+// DeepCopy creates a deep copy of the graph, payloads are shared with the copy
 func (g *Graph) DeepCopy() (*Graph, error) {
 	graph, err := New()
 	if err != nil {
@@ -525,6 +703,12 @@ func (g *Graph) DeepCopy() (*Graph, error) {
 		}
 	}
 
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for vertex, payload := range g.payloads {
+		graph.payloads[vertex] = payload
+	}
+
 	return graph, nil
 }
 
@@ -119,6 +119,43 @@ func TestGraph(t *testing.T) {
 		})
 	})
 
+	t.Run("Payload", func(t *testing.T) {
+		t.Run("should return payload of vertex", func(t *testing.T) {
+			g, err := dag.New(
+				dag.WithVertices([]dag.Vertex{"A", "B"}),
+				dag.WithPayloads(map[dag.Vertex]any{"A": 1}),
+			)
+			assert.Nil(t, err)
+
+			payload, ok := g.Payload("A")
+			assert.True(t, ok)
+			assert.Equal(t, 1, payload)
+
+			_, ok = g.Payload("B")
+			assert.False(t, ok)
+		})
+
+		t.Run("should return error for non existing vertex", func(t *testing.T) {
+			_, err := dag.New(dag.WithPayloads(map[dag.Vertex]any{"X": 1}))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should keep payloads in copies", func(t *testing.T) {
+			g := createGraph()
+			assert.Nil(t, g.SetPayload("E", "e"))
+
+			copy, err := g.DeepCopy()
+			assert.Nil(t, err)
+			payload, _ := copy.Payload("E")
+			assert.Equal(t, "e", payload)
+
+			reverse, err := g.Reverse()
+			assert.Nil(t, err)
+			payload, _ = reverse.Payload("E")
+			assert.Equal(t, "e", payload)
+		})
+	})
+
 	t.Run("Prev", func(t *testing.T) {
 
 		type prevResult struct {
@@ -691,6 +728,28 @@ func TestGraph(t *testing.T) {
 			err := g.Connect("X", "A")
 
 			assert.NotNil(t, err)
+
+			err = g.Connect("A", "X")
+
+			assert.NotNil(t, err)
+			assert.False(t, g.Exists("X"))
+		})
+		t.Run("should return typed errors for cycles & missing vertices", func(t *testing.T) {
+			g := createGraph()
+
+			var cycleErr *dag.CycleError
+			err := g.Connect("F", "A")
+			assert.True(t, errors.As(err, &cycleErr))
+			assert.Equal(t, &dag.CycleError{From: "F", To: "A"}, cycleErr)
+			assert.EqualError(t, err, "could not connect nodes. reason: cyclic edges are not allowed from F to A")
+
+			var notFoundErr *dag.VertexNotFoundError
+			assert.True(t, errors.As(g.Connect("X", "A"), &notFoundErr))
+			assert.Equal(t, dag.Vertex("X"), notFoundErr.Vertex)
+			err = g.Connect("A", "X")
+			assert.True(t, errors.As(err, &notFoundErr))
+			assert.EqualError(t, err, "could not connect vertex A to vertex X: vertex X is not found in graph")
+			assert.True(t, errors.As(g.SetPayload("X", 1), &notFoundErr))
 		})
 	})
 
@@ -802,6 +861,21 @@ func TestGraph(t *testing.T) {
 			assert.Equal(t, []dag.Vertex{"F"}, removed, "Checking removed vertices")
 		})
 
+		t.Run("should drop edges & payloads of removed descendants", func(t *testing.T) {
+			g := createGraph()
+			assert.Nil(t, g.SetPayload("E", "e"))
+
+			_, err := g.Remove("D")
+
+			assert.Nil(t, err)
+			assert.False(t, g.Exists("E"))
+			assert.False(t, g.Exists("F"))
+			assert.Equal(t, dag.Edges{"A": {"B"}, "B": {"C"}, "C": {}}, g.Edges())
+			_, ok := g.Payload("E")
+			assert.False(t, ok)
+			assert.Nil(t, g.Add("E"))
+		})
+
 		t.Run("should return error for non existing vertex", func(t *testing.T) {
 			g := createGraph()
 
@@ -858,6 +932,51 @@ func TestGraph(t *testing.T) {
 		})
 	})
 
+	t.Run("Path", func(t *testing.T) {
+		t.Run("should return shortest path between vertices", func(t *testing.T) {
+			path, err := createGraph().Path("A", "F")
+
+			assert.Nil(t, err)
+			assert.Equal(t, []dag.Vertex{"A", "B", "E", "F"}, path)
+		})
+
+		t.Run("should return single vertex path for same vertex", func(t *testing.T) {
+			path, err := createGraph().Path("C", "C")
+
+			assert.Nil(t, err)
+			assert.Equal(t, []dag.Vertex{"C"}, path)
+		})
+
+		t.Run("should return error when vertices are not connected", func(t *testing.T) {
+			_, err := createGraph().Path("C", "A")
+			assert.EqualError(t, err, "there is no path from vertex C to vertex A")
+
+			_, err = createGraph().Path("A", "X")
+			assert.EqualError(t, err, "vertex X is not found in graph")
+
+			_, err = createGraph().Path("X", "A")
+			assert.NotNil(t, err)
+		})
+	})
+
+	t.Run("CriticalPathLengths", func(t *testing.T) {
+		t.Run("should return longest path lengths to leaves", func(t *testing.T) {
+			lengths, err := createGraph().CriticalPathLengths()
+
+			assert.Nil(t, err)
+			assert.Equal(t, map[dag.Vertex]int{"A": 4, "B": 3, "C": 1, "D": 3, "E": 2, "F": 1}, lengths)
+		})
+
+		t.Run("should return empty lengths for empty graph", func(t *testing.T) {
+			g, err := dag.New()
+			assert.Nil(t, err)
+
+			lengths, err := g.CriticalPathLengths()
+			assert.Nil(t, err)
+			assert.Empty(t, lengths)
+		})
+	})
+
 	t.Run("DeepCopy", func(t *testing.T) {
 		t.Run("should return a deep copy of a graph", func(t *testing.T) {
 			g := createGraph()
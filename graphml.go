@@ -0,0 +1,266 @@
+package dag
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+type graphMLDocument struct {
+	XMLName xml.Name       `xml:"graphml"`
+	Xmlns   string         `xml:"xmlns,attr,omitempty"`
+	Keys    []graphMLKey   `xml:"key"`
+	Graphs  []graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID      string  `xml:"id,attr"`
+	For     string  `xml:"for,attr"`
+	Name    string  `xml:"attr.name,attr,omitempty"`
+	Type    string  `xml:"attr.type,attr,omitempty"`
+	Default *string `xml:"default"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr,omitempty"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID       string        `xml:"id,attr,omitempty"`
+	Directed string        `xml:"directed,attr,omitempty"`
+	Source   string        `xml:"source,attr"`
+	Target   string        `xml:"target,attr"`
+	Data     []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as GraphML, e.g. for yEd. vertex payloads must be Attributes or nil
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	return (&AttributedGraph{Graph: g}).WriteGraphML(w)
+}
+
+// WriteGraphML writes the graph as GraphML, e.g. for yEd. every vertex & edge attribute is declared as a typed key,
+// keys of vertices are named d0, d1 & so on, followed by the keys of edges
+//
+//	<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+//	  <key id="d0" for="node" attr.name="owner" attr.type="string"></key>
+//	  <key id="d1" for="edge" attr.name="weight" attr.type="double"></key>
+//	  <graph edgedefault="directed">
+//	    <node id="A">
+//	      <data key="d0">infra</data>
+//	    </node>
+//	    <node id="B"></node>
+//	    <edge id="e0" source="A" target="B">
+//	      <data key="d1">1.5</data>
+//	    </edge>
+//	  </graph>
+//	</graphml>
+func (a *AttributedGraph) WriteGraphML(w io.Writer) error {
+	doc, err := a.graphML()
+	if err != nil {
+		return errors.Wrap(err, "could not write graphml")
+	}
+	return writeXML(w, doc)
+}
+
+func (a *AttributedGraph) graphML() (*graphMLDocument, error) {
+	vertices, edges, payloads, err := a.attributes()
+	if err != nil {
+		return nil, err
+	}
+	vertexKeys, edgeKeys, err := a.attributeKeys(vertices, edges, payloads)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &graphMLDocument{Xmlns: graphMLNamespace}
+	declare := func(keys []attributeKey, domain string) []string {
+		ids := make([]string, len(keys))
+		for i, key := range keys {
+			ids[i] = fmt.Sprintf("d%d", len(doc.Keys))
+			doc.Keys = append(doc.Keys, graphMLKey{ID: ids[i], For: domain, Name: key.name, Type: key.kind})
+		}
+		return ids
+	}
+	vertexIDs := declare(vertexKeys, "node")
+	edgeIDs := declare(edgeKeys, "edge")
+	data := func(keys []attributeKey, ids []string, attributes Attributes) []graphMLData {
+		var data []graphMLData
+		for i, key := range keys {
+			if value, ok := attributes[key.name]; ok {
+				data = append(data, graphMLData{Key: ids[i], Value: formatAttribute(value)})
+			}
+		}
+		return data
+	}
+
+	graph := graphMLGraph{EdgeDefault: "directed"}
+	for _, v := range vertices {
+		graph.Nodes = append(graph.Nodes, graphMLNode{ID: v, Data: data(vertexKeys, vertexIDs, payloads[v])})
+	}
+	for _, from := range vertices {
+		for _, to := range edges[from] {
+			graph.Edges = append(graph.Edges, graphMLEdge{
+				ID:     fmt.Sprintf("e%d", len(graph.Edges)),
+				Source: from,
+				Target: to,
+				Data:   data(edgeKeys, edgeIDs, a.Edges[Edge{From: from, To: to}]),
+			})
+		}
+	}
+	doc.Graphs = []graphMLGraph{graph}
+	return doc, nil
+}
+
+// LoadGraphMLFile loads a graph from a GraphML file
+func LoadGraphMLFile(path string) (*AttributedGraph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load graphml")
+	}
+	defer file.Close()
+	return LoadGraphML(file)
+}
+
+// LoadGraphML loads a graph from GraphML, e.g. the output of WriteGraphML or yEd. data of keys with a name & a type
+// become the attributes of vertices & edges, key defaults are applied to vertices & edges without the data. keys
+// without a name, such as yEd graphics, graph data, ports, hyperedges & nested graphs are ignored.
+//
+// vertices are added in node order & edges in edge order, so the output of WriteGraphML is loaded back exactly.
+// undirected graphs & edges are not supported
+func LoadGraphML(r io.Reader) (*AttributedGraph, error) {
+	var doc graphMLDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "could not load graphml")
+	}
+	a, err := doc.graph()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load graphml")
+	}
+	return a, nil
+}
+
+func (doc *graphMLDocument) graph() (*AttributedGraph, error) {
+	if len(doc.Graphs) != 1 {
+		return nil, fmt.Errorf("expected 1 graph, got %d", len(doc.Graphs))
+	}
+	graph := doc.Graphs[0]
+	if graph.EdgeDefault != "directed" {
+		return nil, fmt.Errorf("undirected graphs are not supported, expected edgedefault=\"directed\"")
+	}
+
+	// keys holds the attribute keys of nodes & edges by id, ignored keys are nil
+	keys := map[string]*graphMLKey{}
+	for i, key := range doc.Keys {
+		if key.Name == "" || key.For != "node" && key.For != "edge" && key.For != "all" {
+			keys[key.ID] = nil
+			continue
+		}
+		if doc.Keys[i].Type == "" {
+			doc.Keys[i].Type = attributeString
+		}
+		keys[key.ID] = &doc.Keys[i]
+	}
+	attributes := func(domain string, data []graphMLData) (Attributes, error) {
+		attributes := Attributes{}
+		for _, key := range doc.Keys {
+			if keys[key.ID] == nil || key.Default == nil || key.For != domain && key.For != "all" {
+				continue
+			}
+			value, err := parseAttribute(key.Type, *key.Default)
+			if err != nil {
+				return nil, fmt.Errorf("default of key %s: %s", key.ID, err)
+			}
+			attributes[key.Name] = value
+		}
+		for _, d := range data {
+			key, ok := keys[d.Key]
+			if !ok {
+				return nil, fmt.Errorf("key %s is not found", d.Key)
+			}
+			if key == nil {
+				continue
+			}
+			if key.For != domain && key.For != "all" {
+				return nil, fmt.Errorf("key %s is not for %s", key.ID, domain)
+			}
+			value, err := parseAttribute(key.Type, d.Value)
+			if err != nil {
+				return nil, fmt.Errorf("data of key %s: %s", key.ID, err)
+			}
+			attributes[key.Name] = value
+		}
+		if len(attributes) == 0 {
+			return nil, nil
+		}
+		return attributes, nil
+	}
+
+	a := &AttributedGraph{Edges: map[Edge]Attributes{}}
+	g, err := New()
+	if err != nil {
+		return nil, err
+	}
+	a.Graph = g
+	for _, node := range graph.Nodes {
+		if err := g.Add(node.ID); err != nil {
+			return nil, err
+		}
+		vertexAttributes, err := attributes("node", node.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "node %s", node.ID)
+		}
+		if vertexAttributes != nil {
+			if err := g.SetPayload(node.ID, vertexAttributes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, edge := range graph.Edges {
+		if edge.Directed == "false" {
+			return nil, fmt.Errorf("undirected edges are not supported, edge %s -> %s", edge.Source, edge.Target)
+		}
+		if err := g.Connect(edge.Source, edge.Target); err != nil {
+			return nil, err
+		}
+		edgeAttributes, err := attributes("edge", edge.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "edge %s -> %s", edge.Source, edge.Target)
+		}
+		if edgeAttributes != nil {
+			a.Edges[Edge{From: edge.Source, To: edge.Target}] = edgeAttributes
+		}
+	}
+	return a, nil
+}
+
+// writeXML writes a document with an xml declaration, indented by 2 spaces
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
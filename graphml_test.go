@@ -0,0 +1,138 @@
+package dag_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+// createAttributedGraph returns the test graph with attributes of every type & strings that need escaping
+func createAttributedGraph(t *testing.T) *dag.AttributedGraph {
+	g := createGraph()
+	assert.Nil(t, g.SetPayload("A", dag.Attributes{
+		"owner":   "infra <ops> & \"dev\"\n\tteam ",
+		"retries": 3,
+		"size":    int64(1) << 40,
+		"ratio":   float32(0.1),
+		"cost":    2.5,
+		"cached":  true,
+	}))
+	assert.Nil(t, g.SetPayload("E", dag.Attributes{"owner": "", "cost": math.Inf(-1)}))
+	return &dag.AttributedGraph{Graph: g, Edges: map[dag.Edge]dag.Attributes{
+		{From: "A", To: "B"}: {"weight": 1.5, "label": "needs"},
+		{From: "E", To: "F"}: {"weight": 0.0},
+	}}
+}
+
+func TestGraphML(t *testing.T) {
+	t.Run("should write typed keys", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B"}), dag.WithEdges(dag.Edges{"A": {"B"}}))
+		assert.Nil(t, err)
+		assert.Nil(t, g.SetPayload("A", dag.Attributes{"owner": "infra", "retries": 3}))
+		a := &dag.AttributedGraph{Graph: g, Edges: map[dag.Edge]dag.Attributes{{From: "A", To: "B"}: {"weight": 1.5}}}
+
+		var out bytes.Buffer
+		assert.Nil(t, a.WriteGraphML(&out))
+		assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="d0" for="node" attr.name="owner" attr.type="string"></key>
+  <key id="d1" for="node" attr.name="retries" attr.type="int"></key>
+  <key id="d2" for="edge" attr.name="weight" attr.type="double"></key>
+  <graph edgedefault="directed">
+    <node id="A">
+      <data key="d0">infra</data>
+      <data key="d1">3</data>
+    </node>
+    <node id="B"></node>
+    <edge id="e0" source="A" target="B">
+      <data key="d2">1.5</data>
+    </edge>
+  </graph>
+</graphml>
+`, out.String())
+	})
+
+	t.Run("should round trip int & int64 attributes with their types", func(t *testing.T) {
+		big := math.MaxInt32
+		big++
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"A"}))
+		assert.Nil(t, err)
+		assert.Nil(t, g.SetPayload("A", dag.Attributes{"n": big, "size": int64(big)}))
+
+		var out bytes.Buffer
+		assert.Nil(t, (&dag.AttributedGraph{Graph: g}).WriteGraphML(&out))
+		assert.Contains(t, out.String(), `attr.name="n" attr.type="int"`)
+		assert.Contains(t, out.String(), `attr.name="size" attr.type="long"`)
+		loaded, err := dag.LoadGraphML(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, dag.Attributes{"n": big, "size": int64(big)}, loaded.VertexAttributes("A"))
+	})
+
+	t.Run("should round trip vertices, edges & attributes", func(t *testing.T) {
+		a := createAttributedGraph(t)
+		var out bytes.Buffer
+		assert.Nil(t, a.WriteGraphML(&out))
+
+		loaded, err := dag.LoadGraphML(&out)
+		assert.Nil(t, err)
+		assert.Equal(t, a.Graph.Vertices(), loaded.Graph.Vertices())
+		assert.Equal(t, a.Graph.Edges(), loaded.Graph.Edges())
+		for _, v := range a.Graph.Vertices() {
+			assert.Equal(t, a.VertexAttributes(v), loaded.VertexAttributes(v), v)
+		}
+		assert.Equal(t, a.Edges, loaded.Edges)
+	})
+
+	t.Run("should load yEd files with defaults & ignore graphics", func(t *testing.T) {
+		a, err := dag.LoadGraphML(strings.NewReader(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:y="http://www.yworks.com/xml/graphml">
+  <key for="node" id="d0" yfiles.type="nodegraphics"/>
+  <key attr.name="owner" attr.type="string" for="node" id="d1"><default>nobody</default></key>
+  <key attr.name="weight" attr.type="long" for="all" id="d2"/>
+  <graph edgedefault="directed" id="G">
+    <node id="n0"><data key="d0"><y:ShapeNode><y:NodeLabel>build</y:NodeLabel></y:ShapeNode></data></node>
+    <edge id="e0" source="n0" target="n1"><data key="d2">7</data></edge>
+    <node id="n1"><data key="d1">infra</data></node>
+  </graph>
+</graphml>`))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"n0", "n1"}, a.Graph.Vertices())
+		assert.Equal(t, dag.Attributes{"owner": "nobody"}, a.VertexAttributes("n0"))
+		assert.Equal(t, dag.Attributes{"owner": "infra"}, a.VertexAttributes("n1"))
+		assert.Equal(t, dag.Attributes{"weight": int64(7)}, a.EdgeAttributes("n0", "n1"))
+	})
+
+	t.Run("should return errors", func(t *testing.T) {
+		inputs := map[string]string{
+			`<graphml><graph edgedefault="undirected"></graph></graphml>`: "could not load graphml: undirected graphs are not supported, expected edgedefault=\"directed\"",
+			`<graphml><key id="k" for="node" attr.name="n" attr.type="int"/><graph edgedefault="directed"><node id="a"><data key="k">x</data></node></graph></graphml>`: "could not load graphml: node a: data of key k: strconv.ParseInt: parsing \"x\": invalid syntax",
+			`<graphml><graph edgedefault="directed"><node id="a"><data key="k">x</data></node></graph></graphml>`:                                                       "could not load graphml: node a: key k is not found",
+			`<graphml><graph edgedefault="directed"><node id="a"/><edge source="a" target="a"/></graph></graphml>`:                                                      "could not load graphml: could not connect nodes. reason: cyclic edges are not allowed from a to a",
+			`<graphml></graphml>`: "could not load graphml: expected 1 graph, got 0",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadGraphML(strings.NewReader(input))
+			assert.EqualError(t, err, message, input)
+		}
+	})
+
+	t.Run("should return error for payloads that are not attributes", func(t *testing.T) {
+		g := createGraph()
+		assert.Nil(t, g.SetPayload("A", 42))
+		assert.EqualError(t, g.WriteGraphML(&bytes.Buffer{}), "could not write graphml: payload of vertex A is int, expected Attributes")
+
+		assert.Nil(t, g.SetPayload("A", dag.Attributes{"x": 1}))
+		assert.Nil(t, g.SetPayload("B", dag.Attributes{"x": "1"}))
+		assert.EqualError(t, g.WriteGraphML(&bytes.Buffer{}), "could not write graphml: vertices: attribute x has values of types int and string")
+
+		assert.Nil(t, g.SetPayload("B", dag.Attributes{"x": []int{1}}))
+		assert.EqualError(t, g.WriteGraphML(&bytes.Buffer{}), "could not write graphml: vertices: attribute x: unsupported type []int, expected string, bool, int, int64, float32 or float64")
+
+		assert.Nil(t, g.SetPayload("B", dag.Attributes{"x": "\x00"}))
+		assert.EqualError(t, g.WriteGraphML(&bytes.Buffer{}), "could not write graphml: value of attribute x has character U+0000 which xml cannot hold")
+	})
+}
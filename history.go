@@ -0,0 +1,135 @@
+package dag
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type HistoryOptions func(*History) error
+
+// WithHistoryLimit sets the number of transactions that can be undone, the oldest transactions are forgotten first.
+// the history is unlimited by default
+func WithHistoryLimit(limit int) HistoryOptions {
+	return func(h *History) error {
+		if limit <= 0 {
+			return fmt.Errorf("history limit %d must be positive", limit)
+		}
+		h.limit = limit
+		return nil
+	}
+}
+
+// History records the transactions of a graph, so that they can be undone & redone, e.g. for the edit history of an
+// editor. undo applies the inverse operations of a transaction & redo applies its operations again.
+//
+// while a history is used, the graph must only be mutated through the history
+type History struct {
+	mu     sync.Mutex
+	graph  *Graph
+	done   []*Tx
+	undone []*Tx
+	limit  int
+}
+
+// NewHistory creates an empty history of a graph
+func NewHistory(g *Graph, opts ...HistoryOptions) (*History, error) {
+	if g == nil {
+		return nil, fmt.Errorf("could not create history. graph is nil")
+	}
+
+	h := &History{graph: g}
+	for _, opt := range opts {
+		if err := opt(h); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Tx applies a transaction to the graph like Graph.Tx & records it, transactions that are undone can not be redone
+// after a new transaction. transactions without mutations are not recorded
+func (h *History) Tx(fn func(tx *Tx) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	tx, err := h.graph.tx(fn)
+	if err != nil {
+		return err
+	}
+	if len(tx.ops) > 0 {
+		h.push(tx)
+		h.undone = nil
+	}
+	return nil
+}
+
+// push records a transaction that can be undone, forgetting the oldest one beyond the limit
+func (h *History) push(tx *Tx) {
+	h.done = append(h.done, tx)
+	if h.limit > 0 && len(h.done) > h.limit {
+		h.done = append([]*Tx{}, h.done[len(h.done)-h.limit:]...)
+	}
+}
+
+// Undo reverts the last transaction, returns error if there is no transaction to undo
+func (h *History) Undo() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.done) == 0 {
+		return fmt.Errorf("could not undo. there is no transaction to undo")
+	}
+	last := h.done[len(h.done)-1]
+	if err := h.graph.Tx(func(tx *Tx) error {
+		last.revert(tx.graph)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	h.done = h.done[:len(h.done)-1]
+	h.undone = append(h.undone, last)
+	return nil
+}
+
+// Redo applies the last undone transaction again, returns error if there is no transaction to redo
+func (h *History) Redo() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.undone) == 0 {
+		return fmt.Errorf("could not redo. there is no transaction to redo")
+	}
+	last := h.undone[len(h.undone)-1]
+	redone, err := h.graph.tx(func(tx *Tx) error {
+		for _, op := range last.ops {
+			if err := op(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not redo")
+	}
+
+	h.undone = h.undone[:len(h.undone)-1]
+	h.push(redone)
+	return nil
+}
+
+// CanUndo returns whether there is a transaction to undo
+func (h *History) CanUndo() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.done) > 0
+}
+
+// CanRedo returns whether there is a transaction to redo
+func (h *History) CanRedo() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.undone) > 0
+}
@@ -0,0 +1,93 @@
+package dag_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("should undo & redo transactions", func(t *testing.T) {
+		g := createGraph()
+		h, err := dag.NewHistory(g)
+		assert.Nil(t, err)
+		original := dag.Edges{"A": {"B", "D"}, "B": {"C", "E"}, "C": {}, "D": {"E"}, "E": {"F"}, "F": {}}
+
+		assert.Nil(t, h.Tx(func(tx *dag.Tx) error {
+			if err := tx.Append("G", []dag.Vertex{"C"}); err != nil {
+				return err
+			}
+			return tx.SetPayload("G", "g")
+		}))
+		assert.Nil(t, h.Tx(func(tx *dag.Tx) error {
+			_, err := tx.Remove("B")
+			return err
+		}))
+		assert.Nil(t, h.Tx(func(tx *dag.Tx) error {
+			return tx.DisconnectEdge("A", "D")
+		}))
+		assert.Equal(t, []dag.Vertex{"A", "D"}, g.Vertices())
+		next, _ := g.Next("A")
+		assert.Empty(t, next)
+
+		assert.Nil(t, h.Undo())
+		assert.Nil(t, h.Undo())
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F", "G"}, g.Vertices())
+		payload, _ := g.Payload("G")
+		assert.Equal(t, "g", payload)
+		assert.Nil(t, h.Undo())
+		assert.Equal(t, original, g.Edges())
+		assert.False(t, h.CanUndo())
+		assert.EqualError(t, h.Undo(), "could not undo. there is no transaction to undo")
+
+		assert.Nil(t, h.Redo())
+		assert.Nil(t, h.Redo())
+		assert.Equal(t, []dag.Vertex{"A", "D"}, g.Vertices())
+		assert.True(t, h.CanRedo())
+
+		// a new transaction forgets the undone transactions
+		assert.Nil(t, h.Tx(func(tx *dag.Tx) error {
+			return tx.Add("H")
+		}))
+		assert.False(t, h.CanRedo())
+		assert.EqualError(t, h.Redo(), "could not redo. there is no transaction to redo")
+	})
+
+	t.Run("should not record failed & empty transactions", func(t *testing.T) {
+		g := createGraph()
+		h, err := dag.NewHistory(g)
+		assert.Nil(t, err)
+
+		assert.EqualError(t, h.Tx(func(tx *dag.Tx) error {
+			_ = tx.Add("G")
+			return errors.New("failed")
+		}), "failed")
+		assert.Nil(t, h.Tx(func(tx *dag.Tx) error {
+			return nil
+		}))
+		assert.False(t, h.CanUndo())
+		assert.False(t, g.Exists("G"))
+	})
+
+	t.Run("should forget transactions beyond the limit", func(t *testing.T) {
+		g := createGraph()
+		h, err := dag.NewHistory(g, dag.WithHistoryLimit(2))
+		assert.Nil(t, err)
+		for _, v := range []dag.Vertex{"G", "H", "I"} {
+			v := v
+			assert.Nil(t, h.Tx(func(tx *dag.Tx) error {
+				return tx.Add(v)
+			}))
+		}
+
+		assert.Nil(t, h.Undo())
+		assert.Nil(t, h.Undo())
+		assert.NotNil(t, h.Undo())
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F", "G"}, g.Vertices())
+
+		_, err = dag.NewHistory(g, dag.WithHistoryLimit(0))
+		assert.EqualError(t, err, "history limit 0 must be positive")
+	})
+}
@@ -0,0 +1,96 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// JSONVersion is the version of the json encoding of graphs
+const JSONVersion = 1
+
+// graphJSON is the json document of a graph
+type graphJSON struct {
+	Version  int                        `json:"version"`
+	Vertices []Vertex                   `json:"vertices"`
+	Edges    map[Vertex][]Vertex        `json:"edges,omitempty"`
+	Payloads map[Vertex]json.RawMessage `json:"payloads,omitempty"`
+}
+
+// MarshalJSON encodes the graph as a versioned json document
+//
+//	{"version": 1, "vertices": ["A", "B", "C"], "edges": {"A": ["B", "C"]}, "payloads": {"A": {"cmd": "make"}}}
+//
+// vertices are in insertion order, edges of a vertex are in connection order. vertices without next vertices are
+// omitted from edges & vertices without payloads are omitted from payloads
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	doc := graphJSON{Version: JSONVersion, Vertices: g.vertices, Edges: map[Vertex][]Vertex{}, Payloads: map[Vertex]json.RawMessage{}}
+	for vertex, next := range g.edges {
+		if len(next) > 0 {
+			doc.Edges[vertex] = next
+		}
+	}
+	for vertex, payload := range g.payloads {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("could not marshal payload of vertex %s", vertex))
+		}
+		doc.Payloads[vertex] = encoded
+	}
+
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes a json document that is encoded by MarshalJSON, replacing the vertices, edges & payloads of the graph.
+// the graph is rebuilt with Add & Connect, so duplicate vertices, dangling edges & cycles are rejected.
+// payloads are decoded as json.RawMessage, they can be decoded to their own types afterwards
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	if g.readOnly {
+		return errors.Wrap(errReadOnly(), "could not unmarshal graph")
+	}
+	doc := graphJSON{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return errors.Wrap(err, "could not unmarshal graph")
+	}
+	if doc.Version != JSONVersion {
+		return fmt.Errorf("could not unmarshal graph. unsupported version %d, expected %d", doc.Version, JSONVersion)
+	}
+
+	built, err := New()
+	if err != nil {
+		return errors.Wrap(err, "could not unmarshal graph")
+	}
+	if len(doc.Vertices) > 0 {
+		if err := built.Add(doc.Vertices...); err != nil {
+			return errors.Wrap(err, "could not unmarshal graph")
+		}
+	}
+
+	for vertex := range doc.Edges {
+		if !built.Exists(vertex) {
+			return fmt.Errorf("could not unmarshal graph. edges of vertex %s: %w", vertex, &VertexNotFoundError{Vertex: vertex})
+		}
+	}
+	for _, vertex := range doc.Vertices {
+		for _, next := range doc.Edges[vertex] {
+			if err := built.Connect(vertex, next); err != nil {
+				return errors.Wrap(err, "could not unmarshal graph")
+			}
+		}
+	}
+
+	for vertex, payload := range doc.Payloads {
+		if err := built.SetPayload(vertex, payload); err != nil {
+			return errors.Wrap(err, "could not unmarshal graph")
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.vertices, g.edges, g.payloads = built.vertices, built.edges, built.payloads
+	return nil
+}
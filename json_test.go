@@ -0,0 +1,79 @@
+package dag_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	t.Run("MarshalJSON", func(t *testing.T) {
+		t.Run("should encode vertices in insertion order", func(t *testing.T) {
+			g := createGraph()
+			assert.Nil(t, g.SetPayload("A", map[string]string{"cmd": "make"}))
+
+			data, err := json.Marshal(g)
+			assert.Nil(t, err)
+			assert.Equal(t, `{"version":1,"vertices":["A","B","C","D","E","F"],"edges":{"A":["B","D"],"B":["C","E"],"D":["E"],"E":["F"]},"payloads":{"A":{"cmd":"make"}}}`, string(data))
+		})
+
+		t.Run("should encode empty graph", func(t *testing.T) {
+			g, err := dag.New()
+			assert.Nil(t, err)
+
+			data, err := json.Marshal(g)
+			assert.Nil(t, err)
+			assert.Equal(t, `{"version":1,"vertices":[]}`, string(data))
+		})
+
+		t.Run("should return error for payloads that can not be encoded", func(t *testing.T) {
+			g := createGraph()
+			assert.Nil(t, g.SetPayload("A", make(chan int)))
+
+			_, err := json.Marshal(g)
+			assert.NotNil(t, err)
+		})
+	})
+
+	t.Run("UnmarshalJSON", func(t *testing.T) {
+		t.Run("should decode encoded graph", func(t *testing.T) {
+			g := createGraph()
+			assert.Nil(t, g.SetPayload("F", 42))
+			data, err := json.Marshal(g)
+			assert.Nil(t, err)
+
+			decoded := &dag.Graph{}
+			assert.Nil(t, json.Unmarshal(data, decoded))
+			assert.Equal(t, g.Vertices(), decoded.Vertices())
+			assert.Equal(t, dag.Edges{"A": {"B", "D"}, "B": {"C", "E"}, "C": {}, "D": {"E"}, "E": {"F"}, "F": {}}, decoded.Edges())
+
+			payload, ok := decoded.Payload("F")
+			assert.True(t, ok)
+			assert.Equal(t, json.RawMessage("42"), payload)
+
+			sorted, err := decoded.TopSort()
+			assert.Nil(t, err)
+			assert.Equal(t, []dag.Vertex{"A", "B", "D", "C", "E", "F"}, sorted)
+		})
+
+		t.Run("should reject invalid documents", func(t *testing.T) {
+			expected := map[string]string{
+				`{"version":2,"vertices":[]}`:                                      "could not unmarshal graph. unsupported version 2, expected 1",
+				`{"version":1,"vertices":["A","A"]}`:                               "could not unmarshal graph: vertex A already added. vertices must be unique",
+				`{"version":1,"vertices":["A"],"edges":{"A":["X"]}}`:               "could not unmarshal graph: could not connect vertex A to vertex X: vertex X is not found in graph",
+				`{"version":1,"vertices":["A"],"edges":{"X":["A"]}}`:               "could not unmarshal graph. edges of vertex X: vertex X is not found in graph",
+				`{"version":1,"vertices":["A","B"],"edges":{"A":["B"],"B":["A"]}}`: "could not unmarshal graph: could not connect nodes. reason: cyclic edges are not allowed from B to A",
+				`{"version":1,"vertices":["A"],"payloads":{"X":1}}`:                "could not unmarshal graph: vertex X is not found in graph",
+			}
+			for data, message := range expected {
+				err := json.Unmarshal([]byte(data), &dag.Graph{})
+				assert.EqualError(t, err, message, data)
+			}
+
+			err := json.Unmarshal([]byte(`[]`), &dag.Graph{})
+			assert.NotNil(t, err)
+		})
+	})
+}
@@ -0,0 +1,555 @@
+// Package layout computes layered drawings of graphs, e.g. for dashboards, with the Sugiyama method. vertices are
+// assigned to layers, the vertices of each layer are ordered to reduce edge crossings & then coordinates are assigned.
+// the resulting positions & edge polylines can be drawn by any frontend or written as SVG without Graphviz
+package layout
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/aacanakin/dag"
+	"github.com/pkg/errors"
+)
+
+// Heuristic is the crossing minimisation heuristic that orders the vertices of a layer by their neighbors
+type Heuristic int
+
+const (
+	// Barycenter orders vertices by the average position of their neighbors
+	Barycenter Heuristic = iota
+	// Median orders vertices by the weighted median position of their neighbors
+	Median
+)
+
+const (
+	// DefaultIterations is the number of crossing minimisation sweeps
+	DefaultIterations = 24
+	// DefaultNodeSpacing is the gap between the vertices of a layer
+	DefaultNodeSpacing = 20
+	// DefaultLayerSpacing is the gap between layers
+	DefaultLayerSpacing = 40
+)
+
+// SizeFunc returns the width & height of a vertex
+type SizeFunc func(vertex dag.Vertex) (width float64, height float64)
+
+// DefaultSize sizes vertices to fit their name in a 14px sans-serif font
+func DefaultSize(vertex dag.Vertex) (width float64, height float64) {
+	return math.Max(40, float64(8*utf8.RuneCountInString(vertex)+20)), 30
+}
+
+type Options func(*layouter) error
+
+// WithDirection sets the direction of the edges, one of TB, LR, BT or RL
+func WithDirection(dir string) Options {
+	return func(l *layouter) error {
+		switch dir {
+		case "TB", "LR", "BT", "RL":
+			l.direction = dir
+			return nil
+		default:
+			return fmt.Errorf("unknown direction %s, expected TB, LR, BT or RL", dir)
+		}
+	}
+}
+
+// WithSizes sets the sizes of vertices, DefaultSize is used when it is not set
+func WithSizes(size SizeFunc) Options {
+	return func(l *layouter) error {
+		if size == nil {
+			return fmt.Errorf("size func is nil")
+		}
+		l.size = size
+		return nil
+	}
+}
+
+// WithSpacing sets the gap between the vertices of a layer & the gap between layers
+func WithSpacing(node float64, layer float64) Options {
+	return func(l *layouter) error {
+		if node < 0 || layer < 0 {
+			return fmt.Errorf("spacing must not be negative, got %g & %g", node, layer)
+		}
+		l.nodeSpacing, l.layerSpacing = node, layer
+		return nil
+	}
+}
+
+// WithHeuristic sets the crossing minimisation heuristic, Barycenter is used when it is not set
+func WithHeuristic(heuristic Heuristic) Options {
+	return func(l *layouter) error {
+		if heuristic != Barycenter && heuristic != Median {
+			return fmt.Errorf("unknown heuristic %d", heuristic)
+		}
+		l.heuristic = heuristic
+		return nil
+	}
+}
+
+// WithIterations sets the number of crossing minimisation sweeps, 0 keeps the vertices in insertion order
+func WithIterations(iterations int) Options {
+	return func(l *layouter) error {
+		if iterations < 0 {
+			return fmt.Errorf("iterations must not be negative, got %d", iterations)
+		}
+		l.iterations = iterations
+		return nil
+	}
+}
+
+// Point is a position in the drawing, y grows downwards
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Node is the position of a vertex
+type Node struct {
+	Vertex dag.Vertex `json:"vertex"`
+	// Layer is the length of the longest path from a root to the vertex
+	Layer int `json:"layer"`
+	// Order is the index of the vertex in its layer
+	Order int `json:"order"`
+	// X & Y are the center of the vertex
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Edge is the polyline of an edge, from the border of its from vertex to the border of its to vertex.
+// edges that span several layers bend once in each layer they cross
+type Edge struct {
+	From   dag.Vertex `json:"from"`
+	To     dag.Vertex `json:"to"`
+	Points []Point    `json:"points"`
+}
+
+// Layout is a layered drawing of a graph, its top left corner is at 0,0
+type Layout struct {
+	// Nodes are in the insertion order of the vertices
+	Nodes []Node `json:"nodes"`
+	// Edges are in the insertion order of their from vertices & the order of the next vertices
+	Edges     []Edge  `json:"edges"`
+	Width     float64 `json:"width"`
+	Height    float64 `json:"height"`
+	Direction string  `json:"direction"`
+	// Crossings is the number of edge crossings
+	Crossings int `json:"crossings"`
+
+	index map[dag.Vertex]int
+}
+
+// Node returns the position of a vertex
+func (l *Layout) Node(vertex dag.Vertex) (Node, bool) {
+	i, ok := l.index[vertex]
+	if !ok {
+		return Node{}, false
+	}
+	return l.Nodes[i], true
+}
+
+type layouter struct {
+	direction    string
+	size         SizeFunc
+	nodeSpacing  float64
+	layerSpacing float64
+	heuristic    Heuristic
+	iterations   int
+}
+
+// node is a vertex or a dummy vertex of an edge that spans several layers. across is the size of the node across its
+// layer & along is its size along the edges
+type node struct {
+	vertex dag.Vertex
+	dummy  bool
+	layer  int
+	order  int
+	across float64
+	along  float64
+	x      float64
+	y      float64
+	up     []*node
+	down   []*node
+}
+
+// chain is an edge with the dummy nodes in between its vertices
+type chain struct {
+	from  dag.Vertex
+	to    dag.Vertex
+	nodes []*node
+}
+
+// New lays out a graph
+func New(g *dag.Graph, opts ...Options) (*Layout, error) {
+	l := &layouter{
+		direction:    "TB",
+		size:         DefaultSize,
+		nodeSpacing:  DefaultNodeSpacing,
+		layerSpacing: DefaultLayerSpacing,
+		iterations:   DefaultIterations,
+	}
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			return nil, errors.Wrap(err, "could not lay out graph")
+		}
+	}
+
+	vertices := g.Vertices()
+	edges := g.Edges()
+	layers, nodes, chains := l.layer(vertices, edges)
+	crossings := l.order(layers)
+	l.position(layers)
+
+	layout := &Layout{Direction: l.direction, Crossings: crossings, index: map[dag.Vertex]int{}}
+	across, along := l.extent(layers)
+	for _, v := range vertices {
+		n := nodes[v]
+		x, y := l.point(n.x, n.y, across, along)
+		width, height := n.across, n.along
+		if l.direction == "LR" || l.direction == "RL" {
+			width, height = height, width
+		}
+		layout.index[v] = len(layout.Nodes)
+		layout.Nodes = append(layout.Nodes, Node{Vertex: v, Layer: n.layer, Order: n.order, X: x, Y: y, Width: width, Height: height})
+	}
+	for _, c := range chains {
+		edge := Edge{From: c.from, To: c.to}
+		for i, n := range c.nodes {
+			y := n.y
+			switch i {
+			case 0:
+				y += n.along / 2
+			case len(c.nodes) - 1:
+				y -= n.along / 2
+			}
+			x, y := l.point(n.x, y, across, along)
+			edge.Points = append(edge.Points, Point{X: x, Y: y})
+		}
+		layout.Edges = append(layout.Edges, edge)
+	}
+
+	layout.Width, layout.Height = across, along
+	if l.direction == "LR" || l.direction == "RL" {
+		layout.Width, layout.Height = along, across
+	}
+	return layout, nil
+}
+
+// layer assigns vertices to layers by the longest path from a root & splits edges that span several layers with dummy
+// nodes. vertices are initially ordered by insertion order
+func (l *layouter) layer(vertices []dag.Vertex, edges dag.Edges) (layers [][]*node, nodes map[dag.Vertex]*node, chains []chain) {
+	inDegree := map[dag.Vertex]int{}
+	for _, v := range vertices {
+		for _, next := range edges[v] {
+			inDegree[next]++
+		}
+	}
+	rank := map[dag.Vertex]int{}
+	ready := []dag.Vertex{}
+	for _, v := range vertices {
+		if inDegree[v] == 0 {
+			ready = append(ready, v)
+		}
+	}
+	for len(ready) > 0 {
+		v := ready[0]
+		ready = ready[1:]
+		for _, next := range edges[v] {
+			if rank[v]+1 > rank[next] {
+				rank[next] = rank[v] + 1
+			}
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	add := func(n *node) {
+		for len(layers) <= n.layer {
+			layers = append(layers, []*node{})
+		}
+		n.order = len(layers[n.layer])
+		layers[n.layer] = append(layers[n.layer], n)
+	}
+
+	nodes = make(map[dag.Vertex]*node, len(vertices))
+	for _, v := range vertices {
+		width, height := l.size(v)
+		n := &node{vertex: v, layer: rank[v], across: width, along: height}
+		if l.direction == "LR" || l.direction == "RL" {
+			n.across, n.along = height, width
+		}
+		nodes[v] = n
+		add(n)
+	}
+
+	for _, v := range vertices {
+		for _, next := range edges[v] {
+			c := chain{from: v, to: next, nodes: []*node{nodes[v]}}
+			for layer := rank[v] + 1; layer < rank[next]; layer++ {
+				dummy := &node{vertex: v, dummy: true, layer: layer}
+				add(dummy)
+				c.nodes = append(c.nodes, dummy)
+			}
+			c.nodes = append(c.nodes, nodes[next])
+
+			for i := 1; i < len(c.nodes); i++ {
+				c.nodes[i-1].down = append(c.nodes[i-1].down, c.nodes[i])
+				c.nodes[i].up = append(c.nodes[i].up, c.nodes[i-1])
+			}
+			chains = append(chains, c)
+		}
+	}
+	return layers, nodes, chains
+}
+
+// order sweeps the layers down & up, sorting each layer by the positions of its neighbors in the previous layer of the
+// sweep. the order with the fewest crossings is kept & its crossings are returned
+func (l *layouter) order(layers [][]*node) int {
+	best := snapshot(layers)
+	fewest := crossings(layers)
+
+	for i := 0; i < l.iterations && fewest > 0; i++ {
+		if i%2 == 0 {
+			for r := 1; r < len(layers); r++ {
+				l.sort(layers[r], func(n *node) []*node { return n.up })
+			}
+		} else {
+			for r := len(layers) - 2; r >= 0; r-- {
+				l.sort(layers[r], func(n *node) []*node { return n.down })
+			}
+		}
+
+		if c := crossings(layers); c < fewest {
+			best, fewest = snapshot(layers), c
+		}
+	}
+
+	for r := range layers {
+		layers[r] = best[r]
+		for i, n := range layers[r] {
+			n.order = i
+		}
+	}
+	return fewest
+}
+
+// sort orders a layer by the barycenters or medians of the neighbors, nodes without neighbors keep their position
+func (l *layouter) sort(layer []*node, neighbors func(*node) []*node) {
+	keys := make(map[*node]float64, len(layer))
+	for _, n := range layer {
+		positions := []float64{}
+		for _, neighbor := range neighbors(n) {
+			positions = append(positions, float64(neighbor.order))
+		}
+		switch {
+		case len(positions) == 0:
+			keys[n] = float64(n.order)
+		case l.heuristic == Median:
+			keys[n] = median(positions)
+		default:
+			sum := 0.0
+			for _, p := range positions {
+				sum += p
+			}
+			keys[n] = sum / float64(len(positions))
+		}
+	}
+
+	sort.SliceStable(layer, func(i, j int) bool {
+		return keys[layer[i]] < keys[layer[j]]
+	})
+	for i, n := range layer {
+		n.order = i
+	}
+}
+
+// median returns the weighted median of positions, which leans towards the side where the positions are denser
+func median(positions []float64) float64 {
+	sort.Float64s(positions)
+	m := len(positions) / 2
+	switch {
+	case len(positions)%2 == 1:
+		return positions[m]
+	case len(positions) == 2:
+		return (positions[0] + positions[1]) / 2
+	}
+
+	left := positions[m-1] - positions[0]
+	right := positions[len(positions)-1] - positions[m]
+	if left+right == 0 {
+		return (positions[m-1] + positions[m]) / 2
+	}
+	return (positions[m-1]*right + positions[m]*left) / (left + right)
+}
+
+func snapshot(layers [][]*node) [][]*node {
+	copied := make([][]*node, len(layers))
+	for r, layer := range layers {
+		copied[r] = append([]*node{}, layer...)
+	}
+	return copied
+}
+
+// crossings counts the crossings of the edges between consecutive layers
+func crossings(layers [][]*node) int {
+	count := 0
+	for _, layer := range layers {
+		type segment struct{ from, to int }
+		segments := []segment{}
+		for _, n := range layer {
+			for _, down := range n.down {
+				segments = append(segments, segment{n.order, down.order})
+			}
+		}
+		for i := range segments {
+			for j := i + 1; j < len(segments); j++ {
+				if (segments[i].from-segments[j].from)*(segments[i].to-segments[j].to) < 0 {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// position assigns coordinates. nodes are moved towards their neighbors without changing their order or overlapping,
+// dummy nodes weigh more so long edges stay straight
+func (l *layouter) position(layers [][]*node) {
+	for _, layer := range layers {
+		x := 0.0
+		for i, n := range layer {
+			if i > 0 {
+				x += l.gap(layer[i-1], n)
+			}
+			n.x = x
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		for r := 1; r < len(layers); r++ {
+			l.align(layers[r], func(n *node) []*node { return n.up })
+		}
+		for r := len(layers) - 2; r >= 0; r-- {
+			l.align(layers[r], func(n *node) []*node { return n.down })
+		}
+	}
+	for _, layer := range layers {
+		l.align(layer, func(n *node) []*node { return append(append([]*node{}, n.up...), n.down...) })
+	}
+
+	left := math.Inf(1)
+	for _, layer := range layers {
+		if len(layer) > 0 {
+			left = math.Min(left, layer[0].x-layer[0].across/2)
+		}
+	}
+	y := 0.0
+	for _, layer := range layers {
+		thickness := 0.0
+		for _, n := range layer {
+			n.x -= left
+			thickness = math.Max(thickness, n.along)
+		}
+		for _, n := range layer {
+			n.y = y + thickness/2
+		}
+		y += thickness + l.layerSpacing
+	}
+}
+
+// gap is the minimum distance between the centers of adjacent nodes of a layer
+func (l *layouter) gap(left *node, right *node) float64 {
+	return (left.across+right.across)/2 + l.nodeSpacing
+}
+
+// align moves the nodes of a layer as close as possible to the average position of their neighbors, keeping their
+// order & gaps. it solves the weighted isotonic regression of the targets minus the cumulative gaps
+func (l *layouter) align(layer []*node, neighbors func(*node) []*node) {
+	targets := make([]float64, len(layer))
+	weights := make([]float64, len(layer))
+	offset := 0.0
+	for i, n := range layer {
+		if i > 0 {
+			offset += l.gap(layer[i-1], n)
+		}
+
+		targets[i], weights[i] = n.x, 1
+		if ns := neighbors(n); len(ns) > 0 {
+			sum := 0.0
+			for _, neighbor := range ns {
+				sum += neighbor.x
+			}
+			targets[i] = sum / float64(len(ns))
+		}
+		if n.dummy {
+			weights[i] = 8
+		}
+		targets[i] -= offset
+	}
+
+	offset = 0.0
+	for i, x := range isotonic(targets, weights) {
+		if i > 0 {
+			offset += l.gap(layer[i-1], layer[i])
+		}
+		layer[i].x = x + offset
+	}
+}
+
+// isotonic returns the non decreasing sequence that is closest to values by weighted least squares, by pooling
+// adjacent violators
+func isotonic(values []float64, weights []float64) []float64 {
+	type block struct {
+		value  float64
+		weight float64
+		count  int
+	}
+	blocks := []block{}
+	for i := range values {
+		blocks = append(blocks, block{value: values[i], weight: weights[i], count: 1})
+		for len(blocks) > 1 && blocks[len(blocks)-2].value > blocks[len(blocks)-1].value {
+			a, b := blocks[len(blocks)-2], blocks[len(blocks)-1]
+			weight := a.weight + b.weight
+			blocks = blocks[:len(blocks)-2]
+			blocks = append(blocks, block{value: (a.value*a.weight + b.value*b.weight) / weight, weight: weight, count: a.count + b.count})
+		}
+	}
+
+	result := make([]float64, 0, len(values))
+	for _, b := range blocks {
+		for i := 0; i < b.count; i++ {
+			result = append(result, b.value)
+		}
+	}
+	return result
+}
+
+// extent returns the size of the drawing across & along the layers
+func (l *layouter) extent(layers [][]*node) (across float64, along float64) {
+	for _, layer := range layers {
+		for _, n := range layer {
+			across = math.Max(across, n.x+n.across/2)
+			along = math.Max(along, n.y+n.along/2)
+		}
+	}
+	return across, along
+}
+
+// point maps a position across & along the layers to the direction of the layout
+func (l *layouter) point(x float64, y float64, across float64, along float64) (float64, float64) {
+	switch l.direction {
+	case "BT":
+		return x, along - y
+	case "LR":
+		return y, x
+	case "RL":
+		return along - y, x
+	default:
+		return x, y
+	}
+}
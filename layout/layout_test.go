@@ -0,0 +1,117 @@
+package layout_test
+
+import (
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/aacanakin/dag/layout"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGraph(t *testing.T, vertices []dag.Vertex, edges dag.Edges) *dag.Graph {
+	g, err := dag.New(dag.WithVertices(vertices), dag.WithEdges(edges))
+	assert.Nil(t, err)
+	return g
+}
+
+func TestLayout(t *testing.T) {
+	// A -> B -> C, A -> D -> E -> F, B -> E
+	sample := func(t *testing.T) *dag.Graph {
+		return newGraph(t, []dag.Vertex{"A", "B", "C", "D", "E", "F"}, dag.Edges{"A": {"B", "D"}, "B": {"C", "E"}, "D": {"E"}, "E": {"F"}})
+	}
+
+	t.Run("should assign layers & keep vertices of a layer apart", func(t *testing.T) {
+		l, err := layout.New(sample(t))
+		assert.Nil(t, err)
+
+		layers := map[dag.Vertex]int{}
+		for _, n := range l.Nodes {
+			layers[n.Vertex] = n.Layer
+		}
+		assert.Equal(t, map[dag.Vertex]int{"A": 0, "B": 1, "C": 2, "D": 1, "E": 2, "F": 3}, layers)
+
+		b, _ := l.Node("B")
+		d, _ := l.Node("D")
+		assert.Equal(t, b.Y, d.Y)
+		assert.GreaterOrEqual(t, d.X-b.X, (b.Width+d.Width)/2+layout.DefaultNodeSpacing-1e-9)
+
+		a, _ := l.Node("A")
+		assert.Equal(t, layout.DefaultLayerSpacing+a.Height, b.Y-a.Y)
+		assert.Equal(t, 0, l.Crossings)
+	})
+
+	t.Run("should minimise crossings", func(t *testing.T) {
+		// insertion order draws A -> D & B -> C crossed
+		g := newGraph(t, []dag.Vertex{"A", "B", "C", "D"}, dag.Edges{"A": {"D"}, "B": {"C"}})
+
+		l, err := layout.New(g, layout.WithIterations(0))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, l.Crossings)
+
+		for _, heuristic := range []layout.Heuristic{layout.Barycenter, layout.Median} {
+			l, err = layout.New(g, layout.WithHeuristic(heuristic))
+			assert.Nil(t, err)
+			assert.Equal(t, 0, l.Crossings)
+
+			c, _ := l.Node("C")
+			d, _ := l.Node("D")
+			assert.Equal(t, []int{1, 0}, []int{c.Order, d.Order})
+			assert.Less(t, d.X, c.X)
+		}
+	})
+
+	t.Run("should bend long edges once per layer", func(t *testing.T) {
+		l, err := layout.New(newGraph(t, []dag.Vertex{"A", "B", "C"}, dag.Edges{"A": {"B", "C"}, "B": {"C"}}))
+		assert.Nil(t, err)
+
+		assert.Equal(t, "A", l.Edges[1].From)
+		assert.Equal(t, "C", l.Edges[1].To)
+		assert.Len(t, l.Edges[1].Points, 3)
+
+		a, _ := l.Node("A")
+		c, _ := l.Node("C")
+		assert.Equal(t, layout.Point{X: a.X, Y: a.Y + a.Height/2}, l.Edges[1].Points[0])
+		assert.Equal(t, layout.Point{X: c.X, Y: c.Y - c.Height/2}, l.Edges[1].Points[2])
+	})
+
+	t.Run("should lay out in all directions", func(t *testing.T) {
+		g := newGraph(t, []dag.Vertex{"A", "B"}, dag.Edges{"A": {"B"}})
+		size := layout.WithSizes(func(dag.Vertex) (float64, float64) { return 60, 20 })
+
+		l, err := layout.New(g, layout.WithDirection("LR"), size)
+		assert.Nil(t, err)
+		a, _ := l.Node("A")
+		b, _ := l.Node("B")
+		assert.Equal(t, layout.Node{Vertex: "A", X: 30, Y: 10, Width: 60, Height: 20}, a)
+		assert.Equal(t, layout.Node{Vertex: "B", Layer: 1, X: 130, Y: 10, Width: 60, Height: 20}, b)
+		assert.Equal(t, []float64{160, 20}, []float64{l.Width, l.Height})
+
+		l, err = layout.New(g, layout.WithDirection("BT"), size)
+		assert.Nil(t, err)
+		a, _ = l.Node("A")
+		b, _ = l.Node("B")
+		assert.Equal(t, []float64{70, 10}, []float64{a.Y, b.Y})
+
+		l, err = layout.New(g, layout.WithDirection("RL"), size)
+		assert.Nil(t, err)
+		a, _ = l.Node("A")
+		assert.Equal(t, 130.0, a.X)
+	})
+
+	t.Run("should lay out empty graphs", func(t *testing.T) {
+		g, err := dag.New()
+		assert.Nil(t, err)
+		l, err := layout.New(g)
+		assert.Nil(t, err)
+		assert.Empty(t, l.Nodes)
+		assert.Equal(t, 0.0, l.Width)
+	})
+
+	t.Run("should return error for invalid options", func(t *testing.T) {
+		_, err := layout.New(sample(t), layout.WithDirection("UP"))
+		assert.EqualError(t, err, "could not lay out graph: unknown direction UP, expected TB, LR, BT or RL")
+
+		_, err = layout.New(sample(t), layout.WithSpacing(-1, 0))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,128 @@
+package layout
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aacanakin/dag"
+	"github.com/pkg/errors"
+)
+
+// DefaultPadding is the margin around SVG drawings
+const DefaultPadding = 10
+
+// svgStyle is embedded into SVG drawings, classes of vertices can override it
+const svgStyle = `.node rect { fill: #fff; stroke: #333; stroke-width: 1.5; }
+.node text { font: 14px sans-serif; fill: #333; }
+.edge polyline { fill: none; stroke: #666; stroke-width: 1.5; }
+#arrow path { fill: #666; }`
+
+type SVGOptions func(*svgWriter) error
+
+// WithLabels sets the labels of vertices, the vertex names are used when it is not set
+func WithLabels(label func(vertex dag.Vertex) string) SVGOptions {
+	return func(s *svgWriter) error {
+		if label == nil {
+			return fmt.Errorf("label func is nil")
+		}
+		s.label = label
+		return nil
+	}
+}
+
+// WithClasses adds css classes to the group elements of vertices, e.g. the state of a vertex
+func WithClasses(class func(vertex dag.Vertex) string) SVGOptions {
+	return func(s *svgWriter) error {
+		if class == nil {
+			return fmt.Errorf("class func is nil")
+		}
+		s.class = class
+		return nil
+	}
+}
+
+// WithStyle replaces the embedded css of the drawing
+func WithStyle(css string) SVGOptions {
+	return func(s *svgWriter) error {
+		s.style = css
+		return nil
+	}
+}
+
+// WithPadding sets the margin around the drawing, DefaultPadding is used when it is not set
+func WithPadding(padding float64) SVGOptions {
+	return func(s *svgWriter) error {
+		if padding < 0 {
+			return fmt.Errorf("padding must not be negative, got %g", padding)
+		}
+		s.padding = padding
+		return nil
+	}
+}
+
+type svgWriter struct {
+	label   func(vertex dag.Vertex) string
+	class   func(vertex dag.Vertex) string
+	style   string
+	padding float64
+}
+
+// WriteSVG writes the layout as a standalone SVG document. edges are drawn as polylines with arrow heads, vertices as
+// rounded rectangles with centered labels. vertex & edge groups carry data-vertex, data-from & data-to attributes, so
+// scripts can find them
+func (l *Layout) WriteSVG(w io.Writer, opts ...SVGOptions) error {
+	s := &svgWriter{
+		label:   func(vertex dag.Vertex) string { return vertex },
+		class:   func(vertex dag.Vertex) string { return "" },
+		style:   svgStyle,
+		padding: DefaultPadding,
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return errors.Wrap(err, "could not write svg")
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	p := s.padding
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"%s %s %s %s\">\n",
+		num(l.Width+2*p), num(l.Height+2*p), num(-p), num(-p), num(l.Width+2*p), num(l.Height+2*p))
+	fmt.Fprintf(out, "  <style>\n%s\n  </style>\n", html.EscapeString(s.style))
+	fmt.Fprintln(out, `  <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M 0 0 L 10 5 L 0 10 z"/></marker></defs>`)
+
+	for _, e := range l.Edges {
+		points := make([]string, 0, len(e.Points))
+		for _, point := range e.Points {
+			points = append(points, num(point.X)+","+num(point.Y))
+		}
+		fmt.Fprintf(out, "  <g class=\"edge\" data-from=\"%s\" data-to=\"%s\"><polyline points=\"%s\" marker-end=\"url(#arrow)\"/></g>\n",
+			html.EscapeString(e.From), html.EscapeString(e.To), strings.Join(points, " "))
+	}
+
+	for _, n := range l.Nodes {
+		class := strings.TrimSpace("node " + s.class(n.Vertex))
+		fmt.Fprintf(out, "  <g class=\"%s\" data-vertex=\"%s\">", html.EscapeString(class), html.EscapeString(n.Vertex))
+		fmt.Fprintf(out, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" rx=\"4\"/>",
+			num(n.X-n.Width/2), num(n.Y-n.Height/2), num(n.Width), num(n.Height))
+		fmt.Fprintf(out, "<text x=\"%s\" y=\"%s\" text-anchor=\"middle\" dominant-baseline=\"central\">%s</text></g>\n",
+			num(n.X), num(n.Y), html.EscapeString(s.label(n.Vertex)))
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
+
+// num formats a coordinate with at most 2 decimals
+func num(f float64) string {
+	rounded := math.Round(f*100) / 100
+	if rounded == 0 {
+		// drops the sign of negative zero
+		rounded = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
@@ -0,0 +1,58 @@
+package layout_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/aacanakin/dag/layout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSVG(t *testing.T) {
+	g := newGraph(t, []dag.Vertex{`<a & "b">`, "C"}, dag.Edges{`<a & "b">`: {"C"}})
+	l, err := layout.New(g, layout.WithSizes(func(dag.Vertex) (float64, float64) { return 60, 20 }))
+	assert.Nil(t, err)
+
+	t.Run("should write a well formed svg document", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.Nil(t, l.WriteSVG(&out, layout.WithClasses(func(v dag.Vertex) string {
+			if v == "C" {
+				return "failed"
+			}
+			return ""
+		})))
+
+		decoder := xml.NewDecoder(&out)
+		elements := []string{}
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(t, err)
+			if start, ok := token.(xml.StartElement); ok {
+				elements = append(elements, start.Name.Local)
+			}
+		}
+		assert.Equal(t, []string{"svg", "style", "defs", "marker", "path", "g", "polyline", "g", "rect", "text", "g", "rect", "text"}, elements)
+	})
+
+	t.Run("should escape labels & write coordinates", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.Nil(t, l.WriteSVG(&out, layout.WithPadding(0), layout.WithLabels(strings.ToUpper)))
+
+		svg := out.String()
+		assert.Contains(t, svg, `<svg xmlns="http://www.w3.org/2000/svg" width="60" height="80" viewBox="0 0 60 80">`)
+		assert.Contains(t, svg, `<g class="edge" data-from="&lt;a &amp; &#34;b&#34;&gt;" data-to="C"><polyline points="30,20 30,60" marker-end="url(#arrow)"/></g>`)
+		assert.Contains(t, svg, `<text x="30" y="10" text-anchor="middle" dominant-baseline="central">&lt;A &amp; &#34;B&#34;&gt;</text>`)
+	})
+
+	t.Run("should return error for invalid options", func(t *testing.T) {
+		err := l.WriteSVG(io.Discard, layout.WithPadding(-1))
+		assert.EqualError(t, err, "could not write svg: padding must not be negative, got -1")
+	})
+}
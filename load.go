@@ -0,0 +1,46 @@
+package dag
+
+import (
+	"fmt"
+)
+
+// LoadError is an error at a position of a build definition, e.g. a Taskfile or a Makefile
+type LoadError struct {
+	Line int
+	// Column is 0 for formats that are read by lines
+	Column int
+	// Cycle holds the vertices of a cycle for cycle errors, starting & ending with the offending dependency
+	Cycle   []Vertex
+	Message string
+	// Err is the cause of the error when it is known, e.g. *VertexNotFoundError for unknown dependencies
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// dependencyCycle returns the cycle that an edge from a dependency to its dependent would create, or nil
+func dependencyCycle(g *Graph, dependency Vertex, dependent Vertex) []Vertex {
+	path, err := g.Path(dependent, dependency)
+	if err != nil {
+		return nil
+	}
+	return append([]Vertex{dependency}, path...)
+}
+
+// connectDependency connects a dependency to its dependent, so topological orders list dependencies first. edges that
+// would create a cycle are returned as *CycleError with the cycle, starting & ending with the dependency
+func connectDependency(g *Graph, dependency Vertex, dependent Vertex) error {
+	if cycle := dependencyCycle(g, dependency, dependent); cycle != nil {
+		return &CycleError{From: dependency, To: dependent, Cycle: cycle}
+	}
+	return g.Connect(dependency, dependent)
+}
@@ -0,0 +1,451 @@
+package dag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MakeTarget is a target of a Makefile, it is the payload of vertices that are loaded from Makefiles
+type MakeTarget struct {
+	Name Vertex
+	// Prerequisites are the normal prerequisites of all rules of the target
+	Prerequisites []Vertex
+	// OrderOnly are the order-only prerequisites, the ones after |
+	OrderOnly []Vertex
+	Recipe    []string
+	Phony     bool
+	// Line is the line of the first rule of the target, it is 0 for prerequisites without rules, e.g. source files
+	Line int
+}
+
+// makeAssignment matches variable assignments, e.g. CC := gcc
+var makeAssignment = regexp.MustCompile(`^([^\s:#=]+)\s*(=|:=|::=|:::=|\?=|\+=|!=)\s*(.*)$`)
+
+// makeDirectives are the directives that are skipped, conditionals are not evaluated & both branches are read
+var makeDirectives = map[string]bool{
+	"ifdef": true, "ifndef": true, "ifeq": true, "ifneq": true, "else": true, "endif": true,
+	"include": true, "-include": true, "sinclude": true, "vpath": true, "unexport": true, "undefine": true,
+}
+
+// LoadMakefileFile loads a graph from a Makefile
+func LoadMakefileFile(path string) (*Graph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load makefile")
+	}
+	defer file.Close()
+	return LoadMakefile(file)
+}
+
+// LoadMakefile loads a graph from the rules of a Makefile. vertices are the targets & prerequisites in the order they
+// are mentioned & their payloads are *MakeTarget. prerequisites, including order-only ones, are connected to their
+// targets, so TopSort lists them first & Deps returns them
+//
+//	OBJS = main.o util.o
+//	.PHONY: all
+//	all: app
+//	app: $(OBJS) | bin        # main.o, util.o & bin -> app
+//		$(CC) -o bin/app $(OBJS)
+//	$(OBJS): %.o: %.c         # static pattern rule, main.c -> main.o
+//
+// rules of a target are merged. variables that the Makefile assigns are expanded, including substitution references
+// such as $(SRCS:.c=.o), functions such as $(wildcard ...) expand to nothing. pattern rules, special targets other than
+// .PHONY, included Makefiles & conditionals are not evaluated.
+//
+// cycles & static pattern rules with targets that do not match their pattern are returned as *LoadError
+func LoadMakefile(r io.Reader) (*Graph, error) {
+	m := &makefile{
+		variables:     map[string]makeVariable{},
+		targets:       map[Vertex]*MakeTarget{},
+		prerequisites: map[Vertex][]makePrerequisite{},
+	}
+	if err := m.parse(r); err != nil {
+		return nil, err
+	}
+
+	// phony targets without rules are added after the other targets
+	for _, name := range m.phony {
+		m.target(name, 0).Phony = true
+	}
+
+	g, err := New()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load makefile")
+	}
+	for _, name := range m.order {
+		_ = g.Add(name)
+		_ = g.SetPayload(name, m.targets[name])
+	}
+	for _, name := range m.order {
+		for _, prerequisite := range m.prerequisites[name] {
+			if cycle := dependencyCycle(g, prerequisite.name, name); cycle != nil {
+				return nil, &LoadError{
+					Line:    prerequisite.line,
+					Cycle:   cycle,
+					Message: fmt.Sprintf("target %s: prerequisite %s creates a cycle %s", name, prerequisite.name, strings.Join(cycle, " -> ")),
+				}
+			}
+			if err := g.Connect(prerequisite.name, name); err != nil {
+				return nil, errors.Wrap(err, "could not load makefile")
+			}
+		}
+	}
+	return g, nil
+}
+
+type makeVariable struct {
+	value string
+	// simple variables are expanded when they are assigned, recursive ones when they are used
+	simple bool
+}
+
+// makePrerequisite is a prerequisite with the line of its rule
+type makePrerequisite struct {
+	name Vertex
+	line int
+}
+
+type makefile struct {
+	variables     map[string]makeVariable
+	targets       map[Vertex]*MakeTarget
+	order         []Vertex
+	prerequisites map[Vertex][]makePrerequisite
+	// phony holds the prerequisites of .PHONY in order
+	phony []Vertex
+}
+
+// target returns the target of a name, adding it when it is mentioned for the first time
+func (m *makefile) target(name Vertex, line int) *MakeTarget {
+	target, ok := m.targets[name]
+	if !ok {
+		target = &MakeTarget{Name: name}
+		m.targets[name] = target
+		m.order = append(m.order, name)
+	}
+	if target.Line == 0 {
+		target.Line = line
+	}
+	return target
+}
+
+func (m *makefile) prerequisite(target *MakeTarget, name Vertex, orderOnly bool, line int) {
+	if includes(target.Prerequisites, name) || includes(target.OrderOnly, name) {
+		return
+	}
+	if orderOnly {
+		target.OrderOnly = append(target.OrderOnly, name)
+	} else {
+		target.Prerequisites = append(target.Prerequisites, name)
+	}
+	m.target(name, 0)
+	m.prerequisites[target.Name] = append(m.prerequisites[target.Name], makePrerequisite{name: name, line: line})
+}
+
+// parse reads the logical lines of the Makefile, lines that end with a backslash are continued on the next line
+func (m *makefile) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	// rule holds the targets of the last rule, which the following recipe lines belong to
+	var rule []*MakeTarget
+	inDefine := false
+	logical, start := "", 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if logical == "" {
+			start = line
+		}
+		if continued := strings.TrimSuffix(text, `\`); continued != text && !strings.HasSuffix(continued, `\`) {
+			logical += continued + " "
+			continue
+		}
+		text, logical = logical+text, ""
+
+		if inDefine {
+			if strings.HasPrefix(strings.TrimSpace(text), "endef") {
+				inDefine = false
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			for _, target := range rule {
+				target.Recipe = append(target.Recipe, strings.TrimPrefix(text, "\t"))
+			}
+			continue
+		}
+
+		text = strings.TrimSpace(stripMakeComment(text))
+		if text == "" {
+			continue
+		}
+		rule = nil
+
+		fields := strings.Fields(text)
+		for len(fields) > 1 && (fields[0] == "export" || fields[0] == "override" || fields[0] == "private") {
+			text = strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+			fields = fields[1:]
+		}
+		switch {
+		case fields[0] == "define":
+			inDefine = true
+			continue
+		case makeDirectives[fields[0]] || fields[0] == "export" || fields[0] == "override":
+			continue
+		}
+
+		if match := makeAssignment.FindStringSubmatch(text); match != nil {
+			m.assign(match[1], match[2], match[3])
+			continue
+		}
+
+		targets, err := m.rule(text, start)
+		if err != nil {
+			return err
+		}
+		rule = targets
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "could not load makefile")
+	}
+	return nil
+}
+
+// stripMakeComment removes a comment that starts with an unescaped #
+func stripMakeComment(text string) string {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '#' && (i == 0 || text[i-1] != '\\') {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func (m *makefile) assign(name string, op string, value string) {
+	switch op {
+	case "=":
+		m.variables[name] = makeVariable{value: value}
+	case ":=", "::=", ":::=":
+		m.variables[name] = makeVariable{value: m.expand(value), simple: true}
+	case "?=":
+		if _, ok := m.variables[name]; !ok {
+			m.variables[name] = makeVariable{value: value}
+		}
+	case "+=":
+		variable, ok := m.variables[name]
+		if variable.simple {
+			value = m.expand(value)
+		}
+		if ok && variable.value != "" {
+			value = variable.value + " " + value
+		}
+		m.variables[name] = makeVariable{value: value, simple: variable.simple}
+	case "!=":
+		// shell assignments are not evaluated
+		m.variables[name] = makeVariable{simple: true}
+	}
+}
+
+// rule parses a rule, returning the targets that the following recipe lines belong to
+func (m *makefile) rule(text string, line int) ([]*MakeTarget, error) {
+	colon := makeIndex(text, ':')
+	if colon < 0 {
+		// a line that is neither a rule nor an assignment, e.g. a function call
+		return nil, nil
+	}
+	names := strings.Fields(m.expand(text[:colon]))
+	rest := strings.TrimLeft(text[colon+1:], ":")
+
+	var recipe []string
+	if semicolon := makeIndex(rest, ';'); semicolon >= 0 {
+		recipe = []string{strings.TrimSpace(rest[semicolon+1:])}
+		rest = rest[:semicolon]
+	}
+	// target specific variables, e.g. test: GOFLAGS += -race
+	if makeAssignment.MatchString(strings.TrimSpace(rest)) {
+		return nil, nil
+	}
+
+	targetPattern := ""
+	if colon := makeIndex(rest, ':'); colon >= 0 {
+		targetPattern = strings.TrimSpace(m.expand(rest[:colon]))
+		rest = rest[colon+1:]
+	}
+	normal, orderOnly := rest, ""
+	if bar := makeIndex(rest, '|'); bar >= 0 {
+		normal, orderOnly = rest[:bar], rest[bar+1:]
+	}
+	prerequisites := strings.Fields(m.expand(normal))
+	orderOnlyPrerequisites := strings.Fields(m.expand(orderOnly))
+
+	if len(names) == 1 && names[0] == ".PHONY" {
+		m.phony = append(m.phony, prerequisites...)
+		return nil, nil
+	}
+
+	targets := []*MakeTarget{}
+	for _, name := range names {
+		if isSpecialMakeTarget(name) || targetPattern == "" && strings.Contains(name, "%") {
+			continue
+		}
+		stem := ""
+		if targetPattern != "" {
+			var ok bool
+			if stem, ok = matchMakePattern(targetPattern, name); !ok {
+				return nil, &LoadError{Line: line, Message: fmt.Sprintf("target %s does not match the target pattern %s", name, targetPattern)}
+			}
+		}
+
+		target := m.target(name, line)
+		for _, prerequisite := range prerequisites {
+			if targetPattern != "" {
+				prerequisite = strings.Replace(prerequisite, "%", stem, 1)
+			}
+			m.prerequisite(target, prerequisite, false, line)
+		}
+		for _, prerequisite := range orderOnlyPrerequisites {
+			if targetPattern != "" {
+				prerequisite = strings.Replace(prerequisite, "%", stem, 1)
+			}
+			m.prerequisite(target, prerequisite, true, line)
+		}
+		target.Recipe = append(target.Recipe, recipe...)
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// isSpecialMakeTarget returns true for built-in targets such as .SUFFIXES or .DEFAULT
+func isSpecialMakeTarget(name string) bool {
+	if len(name) < 2 || name[0] != '.' {
+		return false
+	}
+	for _, c := range name[1:] {
+		if (c < 'A' || c > 'Z') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// makeIndex returns the index of a character outside of variable references, or -1
+func makeIndex(text string, c byte) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '$' && i+1 < len(text) && (text[i+1] == '(' || text[i+1] == '{'):
+			depth++
+			i++
+		case depth > 0 && (text[i] == ')' || text[i] == '}'):
+			depth--
+		case depth == 0 && text[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// matchMakePattern matches a name against a pattern with a %, returning the stem that % matches
+func matchMakePattern(pattern string, name string) (string, bool) {
+	percent := strings.Index(pattern, "%")
+	if percent < 0 {
+		return "", pattern == name
+	}
+	prefix, suffix := pattern[:percent], pattern[percent+1:]
+	if len(name) < len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+	return name[len(prefix) : len(name)-len(suffix)], true
+}
+
+// expand expands variable references, recursive variables are expanded up to a depth to stop self references
+func (m *makefile) expand(text string) string {
+	return m.expandDepth(text, 0)
+}
+
+func (m *makefile) expandDepth(text string, depth int) string {
+	if depth > 32 || !strings.Contains(text, "$") {
+		return text
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '$' || i+1 == len(text) {
+			out.WriteByte(text[i])
+			continue
+		}
+
+		i++
+		reference := ""
+		switch text[i] {
+		case '$':
+			out.WriteByte('$')
+			continue
+		case '(', '{':
+			closing := byte(')')
+			if text[i] == '{' {
+				closing = '}'
+			}
+			end, nested := i+1, 0
+			for ; end < len(text); end++ {
+				if text[end] == text[i] {
+					nested++
+				} else if text[end] == closing {
+					if nested == 0 {
+						break
+					}
+					nested--
+				}
+			}
+			reference = text[i+1 : end]
+			i = end
+		default:
+			reference = text[i : i+1]
+		}
+		out.WriteString(m.reference(reference, depth))
+	}
+	return out.String()
+}
+
+// reference expands the reference of a variable or a substitution reference, functions expand to nothing
+func (m *makefile) reference(reference string, depth int) string {
+	reference = m.expandDepth(reference, depth+1)
+	if strings.ContainsAny(reference, " \t,") {
+		return ""
+	}
+
+	name, substitution := reference, ""
+	if colon := strings.Index(reference, ":"); colon >= 0 && strings.Contains(reference[colon:], "=") {
+		name, substitution = reference[:colon], reference[colon+1:]
+	}
+	variable, ok := m.variables[name]
+	if !ok {
+		return ""
+	}
+	value := variable.value
+	if !variable.simple {
+		value = m.expandDepth(value, depth+1)
+	}
+	if substitution == "" {
+		return value
+	}
+
+	// $(VAR:a=b) replaces suffixes, $(VAR:%.c=%.o) patterns
+	equals := strings.Index(substitution, "=")
+	from, to := substitution[:equals], substitution[equals+1:]
+	if !strings.Contains(from, "%") {
+		from, to = "%"+from, "%"+to
+	}
+	words := strings.Fields(value)
+	for i, word := range words {
+		if stem, ok := matchMakePattern(from, word); ok {
+			words[i] = strings.Replace(to, "%", stem, 1)
+		}
+	}
+	return strings.Join(words, " ")
+}
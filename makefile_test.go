@@ -0,0 +1,90 @@
+package dag_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMakefile(t *testing.T) {
+	t.Run("should load rules, variables & phony targets", func(t *testing.T) {
+		g, err := dag.LoadMakefile(strings.NewReader(`# build the app
+SRCS := main.c \
+        util.c
+OBJS = $(SRCS:.c=.o)
+BIN ?= bin
+CFLAGS += -O2
+
+.PHONY: all clean
+
+all: app
+
+app: $(OBJS) | $(BIN) # link
+	$(CC) -o $(BIN)/app $(OBJS)
+
+$(OBJS): %.o: %.c config.h
+	$(CC) $(CFLAGS) -c $<
+
+%.d: %.c
+	$(CC) -M $< > $@
+
+$(BIN): ; mkdir -p $@
+
+app: VERSION = 1.0
+app: $(wildcard *.a)
+
+ifeq ($(DEBUG),1)
+all: debug.log
+endif
+
+define HELP
+clean: everything
+endef
+`))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"all", "app", "main.o", "util.o", "bin", "main.c", "config.h", "util.c", "debug.log", "clean"}, g.Vertices())
+		assert.Equal(t, dag.Edges{
+			"all":       {},
+			"app":       {"all"},
+			"main.o":    {"app"},
+			"util.o":    {"app"},
+			"bin":       {"app"},
+			"main.c":    {"main.o"},
+			"config.h":  {"main.o", "util.o"},
+			"util.c":    {"util.o"},
+			"debug.log": {"all"},
+			"clean":     {},
+		}, g.Edges())
+
+		payload, _ := g.Payload("app")
+		assert.Equal(t, &dag.MakeTarget{
+			Name:          "app",
+			Prerequisites: []dag.Vertex{"main.o", "util.o"},
+			OrderOnly:     []dag.Vertex{"bin"},
+			Recipe:        []string{"$(CC) -o $(BIN)/app $(OBJS)"},
+			Line:          12,
+		}, payload)
+		payload, _ = g.Payload("bin")
+		assert.Equal(t, []string{"mkdir -p $@"}, payload.(*dag.MakeTarget).Recipe)
+		payload, _ = g.Payload("clean")
+		assert.Equal(t, &dag.MakeTarget{Name: "clean", Phony: true}, payload)
+		payload, _ = g.Payload("all")
+		assert.True(t, payload.(*dag.MakeTarget).Phony)
+		payload, _ = g.Payload("main.c")
+		assert.Equal(t, &dag.MakeTarget{Name: "main.c"}, payload)
+	})
+
+	t.Run("should return errors with lines", func(t *testing.T) {
+		_, err := dag.LoadMakefile(strings.NewReader("a: b\n\nb: c\nc: a\n"))
+		var loadErr *dag.LoadError
+		assert.True(t, errors.As(err, &loadErr))
+		assert.Equal(t, []dag.Vertex{"a", "c", "b", "a"}, loadErr.Cycle)
+		assert.EqualError(t, err, "line 4: target c: prerequisite a creates a cycle a -> c -> b -> a")
+
+		_, err = dag.LoadMakefile(strings.NewReader("x.o y.c: %.o: %.c\n"))
+		assert.EqualError(t, err, "line 1: target y.c does not match the target pattern %.o")
+	})
+}
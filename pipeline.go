@@ -0,0 +1,428 @@
+package dag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// PipelineVersion is the pipeline file version supported by LoadPipeline
+const PipelineVersion = 1
+
+// Task is the definition of a task in a pipeline file, it is the payload of the vertices of a loaded pipeline
+type Task struct {
+	Name        Vertex
+	Deps        []Vertex
+	Command     Command
+	Retries     int
+	RetryDelay  time.Duration
+	TriggerRule TriggerRule
+	Resources   Resources
+	// Line & Column are the position of the task in the pipeline file
+	Line   int
+	Column int
+}
+
+// Pipeline is a graph loaded from a pipeline file
+type Pipeline struct {
+	// Pools holds the capacities of resource pools
+	Pools Resources
+	// Graph holds a vertex per task, the payloads of vertices are *Task
+	Graph *Graph
+}
+
+// PipelineErrorKind represents the kind of a pipeline error
+type PipelineErrorKind string
+
+const (
+	// PipelineSyntaxError means the file is not valid YAML or JSON
+	PipelineSyntaxError PipelineErrorKind = "syntax"
+	// PipelineInvalidError means a field has an invalid value
+	PipelineInvalidError PipelineErrorKind = "invalid"
+	// PipelineUnknownDepError means a task depends on a task that is not declared
+	PipelineUnknownDepError PipelineErrorKind = "unknown_dependency"
+	// PipelineCycleError means a dependency creates a cycle
+	PipelineCycleError PipelineErrorKind = "cycle"
+)
+
+// PipelineError is an error at a position of a pipeline file
+type PipelineError struct {
+	Kind   PipelineErrorKind
+	Line   int
+	Column int
+	// Task is the offending task, it is empty for errors outside of tasks
+	Task Vertex
+	// Dep is the offending dependency for unknown dependency & cycle errors
+	Dep Vertex
+	// Cycle holds the vertices of a cycle for cycle errors, starting & ending with Dep
+	Cycle   []Vertex
+	Message string
+}
+
+func (e *PipelineError) Error() string {
+	position := fmt.Sprintf("line %d", e.Line)
+	if e.Column > 0 {
+		position += fmt.Sprintf(", column %d", e.Column)
+	}
+	if e.Task != "" {
+		return fmt.Sprintf("%s: task %s: %s", position, e.Task, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", position, e.Message)
+}
+
+// PipelineErrors holds all errors of a pipeline file, ordered by position
+type PipelineErrors []*PipelineError
+
+func (e PipelineErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// LoadPipelineFile loads a pipeline from a YAML or JSON file
+func LoadPipelineFile(path string) (*Pipeline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load pipeline")
+	}
+	defer file.Close()
+	return LoadPipeline(file)
+}
+
+// LoadPipeline loads a pipeline from YAML or JSON. tasks become vertices in the order they are declared
+//
+//	version: 1
+//	resources:        # capacities of resource pools
+//	  db: 1
+//	  cpu: 16
+//	tasks:
+//	  generate:
+//	    command: go generate ./...   # a string command runs with "sh -c"
+//	  build:
+//	    deps: [generate]
+//	    command: [go, build, ./...]
+//	    env: {CGO_ENABLED: "0"}
+//	    dir: .
+//	    retries: 2
+//	    retry_delay: 5s
+//	    trigger_rule: all_success
+//	    resources: {cpu: 4}
+//
+// returns PipelineErrors when the document is not a valid pipeline. all errors of the document are reported
+func LoadPipeline(r io.Reader) (*Pipeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load pipeline")
+	}
+
+	root := yaml.Node{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, PipelineErrors{syntaxError(err)}
+	}
+
+	l := &pipelineLoader{}
+	pipeline, tasks := l.document(&root)
+	l.build(pipeline, tasks)
+
+	if len(l.errs) > 0 {
+		sort.SliceStable(l.errs, func(i, j int) bool {
+			if l.errs[i].Line != l.errs[j].Line {
+				return l.errs[i].Line < l.errs[j].Line
+			}
+			return l.errs[i].Column < l.errs[j].Column
+		})
+		return nil, l.errs
+	}
+	return pipeline, nil
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func syntaxError(err error) *PipelineError {
+	line := 0
+	if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		line, _ = strconv.Atoi(match[1])
+	}
+	return &PipelineError{Kind: PipelineSyntaxError, Line: line, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+}
+
+// taskNodes keeps the nodes of dependencies to report their positions
+type taskNodes struct {
+	task *Task
+	deps []*yaml.Node
+}
+
+type pipelineLoader struct {
+	errs PipelineErrors
+}
+
+func (l *pipelineLoader) fail(kind PipelineErrorKind, node *yaml.Node, task Vertex, format string, args ...any) *PipelineError {
+	err := &PipelineError{Kind: kind, Line: node.Line, Column: node.Column, Task: task, Message: fmt.Sprintf(format, args...)}
+	l.errs = append(l.errs, err)
+	return err
+}
+
+// mapping calls fn for each key & value of a mapping node, reporting duplicate keys
+func (l *pipelineLoader) mapping(node *yaml.Node, task Vertex, fn func(key *yaml.Node, value *yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		l.fail(PipelineInvalidError, node, task, "expected a mapping")
+		return
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if seen[key.Value] {
+			l.fail(PipelineInvalidError, key, task, "duplicate key %s", key.Value)
+			continue
+		}
+		seen[key.Value] = true
+		fn(key, value)
+	}
+}
+
+// decode decodes a scalar node, reporting invalid values
+func (l *pipelineLoader) decode(node *yaml.Node, task Vertex, what string, target any) bool {
+	if node.Kind != yaml.ScalarNode {
+		l.fail(PipelineInvalidError, node, task, "%s must be a scalar", what)
+		return false
+	}
+	if err := node.Decode(target); err != nil {
+		l.fail(PipelineInvalidError, node, task, "invalid %s %q", what, node.Value)
+		return false
+	}
+	return true
+}
+
+func (l *pipelineLoader) document(root *yaml.Node) (*Pipeline, []*taskNodes) {
+	pipeline := &Pipeline{Pools: Resources{}}
+	tasks := []*taskNodes{}
+
+	if len(root.Content) == 0 {
+		l.fail(PipelineInvalidError, root, "", "document is empty")
+		return pipeline, tasks
+	}
+
+	hasTasks := false
+	l.mapping(root.Content[0], "", func(key *yaml.Node, value *yaml.Node) {
+		switch key.Value {
+		case "version":
+			version := 0
+			if l.decode(value, "", "version", &version) && version != PipelineVersion {
+				l.fail(PipelineInvalidError, value, "", "unsupported version %d, expected %d", version, PipelineVersion)
+			}
+		case "resources":
+			pipeline.Pools = l.resources(value, "", 1)
+		case "tasks":
+			hasTasks = true
+			l.mapping(value, "", func(key *yaml.Node, value *yaml.Node) {
+				tasks = append(tasks, l.task(key, value))
+			})
+		default:
+			l.fail(PipelineInvalidError, key, "", "unknown field %s", key.Value)
+		}
+	})
+
+	if !hasTasks && len(l.errs) == 0 {
+		l.fail(PipelineInvalidError, root.Content[0], "", "tasks are missing")
+	}
+	return pipeline, tasks
+}
+
+// resources decodes a mapping of pool names to amounts, amounts lower than least are reported
+func (l *pipelineLoader) resources(node *yaml.Node, task Vertex, least int) Resources {
+	resources := Resources{}
+	l.mapping(node, task, func(key *yaml.Node, value *yaml.Node) {
+		amount := 0
+		if !l.decode(value, task, "amount of resource "+key.Value, &amount) {
+			return
+		}
+		if amount < least {
+			l.fail(PipelineInvalidError, value, task, "amount of resource %s must be at least %d", key.Value, least)
+			return
+		}
+		resources[key.Value] = amount
+	})
+	return resources
+}
+
+func (l *pipelineLoader) task(key *yaml.Node, node *yaml.Node) *taskNodes {
+	name := key.Value
+	t := &taskNodes{task: &Task{Name: name, Deps: []Vertex{}, TriggerRule: TriggerAllSuccess, Resources: Resources{}, Line: key.Line, Column: key.Column}}
+
+	// a task without fields, e.g. "lint:" or "lint: {}"
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return t
+	}
+
+	l.mapping(node, name, func(key *yaml.Node, value *yaml.Node) {
+		switch key.Value {
+		case "deps":
+			if value.Kind != yaml.SequenceNode {
+				l.fail(PipelineInvalidError, value, name, "deps must be a list")
+				return
+			}
+			for _, dep := range value.Content {
+				var depName string
+				if l.decode(dep, name, "dependency", &depName) {
+					t.task.Deps = append(t.task.Deps, depName)
+					t.deps = append(t.deps, dep)
+				}
+			}
+		case "command":
+			switch value.Kind {
+			case yaml.ScalarNode:
+				t.task.Command.Args = []string{"sh", "-c", value.Value}
+			case yaml.SequenceNode:
+				for _, arg := range value.Content {
+					var argValue string
+					if l.decode(arg, name, "command argument", &argValue) {
+						t.task.Command.Args = append(t.task.Command.Args, argValue)
+					}
+				}
+			default:
+				l.fail(PipelineInvalidError, value, name, "command must be a string or a list")
+			}
+		case "env":
+			l.mapping(value, name, func(key *yaml.Node, value *yaml.Node) {
+				var envValue string
+				if l.decode(value, name, "value of env "+key.Value, &envValue) {
+					t.task.Command.Env = append(t.task.Command.Env, key.Value+"="+envValue)
+				}
+			})
+		case "dir":
+			l.decode(value, name, "dir", &t.task.Command.Dir)
+		case "retries":
+			if l.decode(value, name, "retries", &t.task.Retries) && t.task.Retries < 0 {
+				l.fail(PipelineInvalidError, value, name, "retries must not be negative")
+			}
+		case "retry_delay":
+			var delay string
+			if !l.decode(value, name, "retry delay", &delay) {
+				return
+			}
+			duration, err := time.ParseDuration(delay)
+			if err != nil || duration < 0 {
+				l.fail(PipelineInvalidError, value, name, "invalid retry delay %q", delay)
+				return
+			}
+			t.task.RetryDelay = duration
+		case "trigger_rule":
+			var rule string
+			if l.decode(value, name, "trigger rule", &rule) {
+				t.task.TriggerRule = TriggerRule(rule)
+				if !t.task.TriggerRule.Valid() {
+					l.fail(PipelineInvalidError, value, name, "unknown trigger rule %s", rule)
+				}
+			}
+		case "resources":
+			t.task.Resources = l.resources(value, name, 0)
+		default:
+			l.fail(PipelineInvalidError, key, name, "unknown field %s", key.Value)
+		}
+	})
+	return t
+}
+
+// build adds the tasks to a new graph, reporting unknown dependencies, cycles & unknown resource pools
+func (l *pipelineLoader) build(pipeline *Pipeline, tasks []*taskNodes) {
+	g, _ := New()
+	for _, t := range tasks {
+		_ = g.Add(t.task.Name)
+		_ = g.SetPayload(t.task.Name, t.task)
+
+		pools := keys(t.task.Resources)
+		sort.Strings(pools)
+		for _, pool := range pools {
+			amount := t.task.Resources[pool]
+			capacity, exists := pipeline.Pools[pool]
+			if !exists {
+				l.errs = append(l.errs, &PipelineError{Kind: PipelineInvalidError, Line: t.task.Line, Column: t.task.Column, Task: t.task.Name, Message: fmt.Sprintf("unknown resource pool %s", pool)})
+			} else if amount > capacity {
+				l.errs = append(l.errs, &PipelineError{Kind: PipelineInvalidError, Line: t.task.Line, Column: t.task.Column, Task: t.task.Name, Message: fmt.Sprintf("demands %d of resource pool %s with capacity %d", amount, pool, capacity)})
+			}
+		}
+	}
+
+	for _, t := range tasks {
+		for i, dep := range t.task.Deps {
+			node := t.deps[i]
+			if !g.Exists(dep) {
+				err := l.fail(PipelineUnknownDepError, node, t.task.Name, "unknown dependency %s", dep)
+				err.Dep = dep
+				continue
+			}
+
+			if path, err := g.Path(t.task.Name, dep); err == nil {
+				err := l.fail(PipelineCycleError, node, t.task.Name, "dependency %s creates a cycle %s", dep, strings.Join(append([]Vertex{dep}, path...), " -> "))
+				err.Dep = dep
+				err.Cycle = append([]Vertex{dep}, path...)
+				continue
+			}
+
+			if err := g.Connect(dep, t.task.Name); err != nil {
+				err := l.fail(PipelineInvalidError, node, t.task.Name, "duplicate dependency %s", dep)
+				err.Dep = dep
+			}
+		}
+	}
+
+	pipeline.Graph = g
+}
+
+// Task returns the task of a vertex
+func (p *Pipeline) Task(vertex Vertex) (*Task, bool) {
+	payload, ok := p.Graph.Payload(vertex)
+	if !ok {
+		return nil, false
+	}
+	task, ok := payload.(*Task)
+	return task, ok
+}
+
+// Command returns the command of a vertex, it is a CommandFunc that can be passed to NewCommandRunner
+func (p *Pipeline) Command(vertex Vertex) (Command, error) {
+	task, ok := p.Task(vertex)
+	if !ok {
+		return Command{}, fmt.Errorf("task %s is not found in pipeline", vertex)
+	}
+	return task.Command, nil
+}
+
+// ExecutorOptions returns the executor options of the retries, trigger rules & resources declared in the pipeline
+func (p *Pipeline) ExecutorOptions() []ExecutorOptions {
+	opts := []ExecutorOptions{
+		WithRetryPolicy(func(vertex Vertex) (int, time.Duration) {
+			if task, ok := p.Task(vertex); ok {
+				return task.Retries, task.RetryDelay
+			}
+			return 0, 0
+		}),
+		WithTriggerRules(func(vertex Vertex) TriggerRule {
+			if task, ok := p.Task(vertex); ok {
+				return task.TriggerRule
+			}
+			return TriggerAllSuccess
+		}),
+	}
+
+	if len(p.Pools) > 0 {
+		opts = append(opts, WithResources(p.Pools, func(vertex Vertex) Resources {
+			if task, ok := p.Task(vertex); ok {
+				return task.Resources
+			}
+			return nil
+		}))
+	}
+	return opts
+}
@@ -0,0 +1,194 @@
+package dag_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+const samplePipeline = `version: 1
+resources:
+  db: 1
+  cpu: 16
+tasks:
+  generate:
+    command: echo generate
+  build:
+    deps: [generate]
+    command: [go, build, ./...]
+    env:
+      CGO_ENABLED: "0"
+    dir: src
+    retries: 2
+    retry_delay: 5s
+    resources: {cpu: 4}
+  migrate:
+    resources: {db: 1}
+  test:
+    deps: [build, migrate]
+    trigger_rule: all_done
+`
+
+func TestPipeline(t *testing.T) {
+	t.Run("LoadPipeline", func(t *testing.T) {
+		t.Run("should load tasks as vertices with payloads", func(t *testing.T) {
+			pipeline, err := dag.LoadPipeline(strings.NewReader(samplePipeline))
+			assert.Nil(t, err)
+
+			assert.Equal(t, []dag.Vertex{"generate", "build", "migrate", "test"}, pipeline.Graph.Vertices())
+			assert.Equal(t, dag.Resources{"db": 1, "cpu": 16}, pipeline.Pools)
+
+			sorted, err := pipeline.Graph.TopSort()
+			assert.Nil(t, err)
+			assert.Equal(t, []dag.Vertex{"generate", "migrate", "build", "test"}, sorted)
+
+			build, ok := pipeline.Task("build")
+			assert.True(t, ok)
+			assert.Equal(t, &dag.Task{
+				Name:        "build",
+				Deps:        []dag.Vertex{"generate"},
+				Command:     dag.Command{Args: []string{"go", "build", "./..."}, Env: []string{"CGO_ENABLED=0"}, Dir: "src"},
+				Retries:     2,
+				RetryDelay:  5 * time.Second,
+				TriggerRule: dag.TriggerAllSuccess,
+				Resources:   dag.Resources{"cpu": 4},
+				Line:        8,
+				Column:      3,
+			}, build)
+
+			command, err := pipeline.Command("generate")
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"sh", "-c", "echo generate"}, command.Args)
+
+			_, err = pipeline.Command("missing")
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should load json documents", func(t *testing.T) {
+			pipeline, err := dag.LoadPipeline(strings.NewReader(`{"version": 1, "tasks": {"a": {}, "b": {"deps": ["a"]}}}`))
+			assert.Nil(t, err)
+			assert.Equal(t, dag.Edges{"a": {"b"}, "b": {}}, pipeline.Graph.Edges())
+		})
+
+		t.Run("should report unknown dependencies & cycles with positions", func(t *testing.T) {
+			_, err := dag.LoadPipeline(strings.NewReader(`tasks:
+  a:
+    deps: [c]
+  b:
+    deps: [a, missing]
+  c:
+    deps: [b]
+`))
+			errs, ok := err.(dag.PipelineErrors)
+			assert.True(t, ok)
+			assert.Equal(t, 2, len(errs))
+
+			assert.Equal(t, dag.PipelineUnknownDepError, errs[0].Kind)
+			assert.Equal(t, dag.Vertex("b"), errs[0].Task)
+			assert.Equal(t, dag.Vertex("missing"), errs[0].Dep)
+			assert.Equal(t, "line 5, column 15: task b: unknown dependency missing", errs[0].Error())
+
+			assert.Equal(t, dag.PipelineCycleError, errs[1].Kind)
+			assert.Equal(t, dag.Vertex("c"), errs[1].Task)
+			assert.Equal(t, []dag.Vertex{"b", "c", "a", "b"}, errs[1].Cycle)
+			assert.Equal(t, 7, errs[1].Line)
+		})
+
+		t.Run("should report invalid fields", func(t *testing.T) {
+			_, err := dag.LoadPipeline(strings.NewReader(`version: 2
+owner: me
+resources:
+  db: 0
+tasks:
+  a:
+    retries: many
+    retry_delay: soon
+    trigger_rule: sometimes
+    command: {run: x}
+    resources: {gpu: 1}
+    deps: a
+    unknown: true
+`))
+			assert.Equal(t, strings.Join([]string{
+				"line 1, column 10: unsupported version 2, expected 1",
+				"line 2, column 1: unknown field owner",
+				"line 4, column 7: amount of resource db must be at least 1",
+				"line 6, column 3: task a: unknown resource pool gpu",
+				"line 7, column 14: task a: invalid retries \"many\"",
+				"line 8, column 18: task a: invalid retry delay \"soon\"",
+				"line 9, column 19: task a: unknown trigger rule sometimes",
+				"line 10, column 14: task a: command must be a string or a list",
+				"line 12, column 11: task a: deps must be a list",
+				"line 13, column 5: task a: unknown field unknown",
+			}, "\n"), err.Error())
+		})
+
+		t.Run("should report syntax errors", func(t *testing.T) {
+			_, err := dag.LoadPipeline(strings.NewReader("tasks:\n  a: [\n"))
+			errs, ok := err.(dag.PipelineErrors)
+			assert.True(t, ok)
+			assert.Equal(t, dag.PipelineSyntaxError, errs[0].Kind)
+			assert.Equal(t, 2, errs[0].Line)
+		})
+
+		t.Run("should report missing tasks", func(t *testing.T) {
+			_, err := dag.LoadPipeline(strings.NewReader("version: 1\n"))
+			assert.EqualError(t, err, "line 1, column 1: tasks are missing")
+		})
+	})
+
+	t.Run("LoadPipelineFile", func(t *testing.T) {
+		t.Run("should load pipeline from file", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pipeline.yml")
+			assert.Nil(t, os.WriteFile(path, []byte(samplePipeline), 0o644))
+
+			pipeline, err := dag.LoadPipelineFile(path)
+			assert.Nil(t, err)
+			assert.Equal(t, 4, len(pipeline.Graph.Vertices()))
+
+			_, err = dag.LoadPipelineFile(filepath.Join(t.TempDir(), "missing.yml"))
+			assert.NotNil(t, err)
+		})
+	})
+
+	t.Run("ExecutorOptions", func(t *testing.T) {
+		t.Run("should run pipeline with its retries, trigger rules & resources", func(t *testing.T) {
+			pipeline, err := dag.LoadPipeline(strings.NewReader(`resources: {db: 1}
+tasks:
+  flaky:
+    retries: 1
+    resources: {db: 1}
+  broken: {}
+  report:
+    deps: [flaky, broken]
+    trigger_rule: all_done
+`))
+			assert.Nil(t, err)
+
+			attempts := map[dag.Vertex]int{}
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				attempts[vertex]++
+				if vertex == "broken" || (vertex == "flaky" && attempts[vertex] == 1) {
+					return nil, assert.AnError
+				}
+				return nil, nil
+			}
+
+			executor, err := dag.NewExecutor(pipeline.Graph, task, append(pipeline.ExecutorOptions(), dag.WithWorkers(1))...)
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.NotNil(t, err)
+			assert.Equal(t, 2, attempts["flaky"])
+			assert.Equal(t, dag.StateSucceeded, results["flaky"].State)
+			assert.Equal(t, dag.StateFailed, results["broken"].State)
+			assert.Equal(t, dag.StateSucceeded, results["report"].State)
+		})
+	})
+}
@@ -0,0 +1,226 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EstimateFunc returns the estimated duration of the task of a vertex
+type EstimateFunc func(vertex Vertex) time.Duration
+
+// SkipFunc returns true for vertices that would not run, e.g. vertices that are up to date
+type SkipFunc func(vertex Vertex) bool
+
+// DefaultEstimate is the duration planned for vertices when no estimate is given
+const DefaultEstimate = time.Second
+
+type PlanOptions func(*planner) error
+
+// WithEstimates sets the duration estimates of vertices. vertices with a non positive estimate are planned with DefaultEstimate
+func WithEstimates(estimate EstimateFunc) PlanOptions {
+	return func(p *planner) error {
+		if estimate == nil {
+			return fmt.Errorf("estimate func is nil")
+		}
+		p.estimate = estimate
+		return nil
+	}
+}
+
+// WithSkip sets the predicate of vertices that would not run. skipped vertices take no time & no worker
+func WithSkip(skip SkipFunc) PlanOptions {
+	return func(p *planner) error {
+		if skip == nil {
+			return fmt.Errorf("skip func is nil")
+		}
+		p.skip = skip
+		return nil
+	}
+}
+
+type planner struct {
+	estimate EstimateFunc
+	skip     SkipFunc
+}
+
+// PlannedVertex is the simulated schedule of a vertex
+type PlannedVertex struct {
+	Vertex Vertex `json:"vertex"`
+	// Wave is the length of the longest path from a root to the vertex
+	Wave int `json:"wave"`
+	// Worker is the index of the worker that runs the vertex, -1 when the vertex is skipped
+	Worker  int           `json:"worker"`
+	Start   time.Duration `json:"start"`
+	End     time.Duration `json:"end"`
+	Skipped bool          `json:"skipped,omitempty"`
+}
+
+// Plan is the simulated schedule of a run
+type Plan struct {
+	Workers int `json:"workers"`
+	// Vertices are ordered by start time & vertex order of the graph
+	Vertices []PlannedVertex `json:"vertices"`
+	Makespan time.Duration   `json:"makespan"`
+}
+
+// NewPlan simulates running the graph on the given number of workers without running anything.
+// vertices are started first in, first out in the order they become ready. priorities, resource pools & trigger rules
+// of the Executor are not planned, so plans of runs with these options are estimates of runs without them
+func NewPlan(g *Graph, workers int, opts ...PlanOptions) (*Plan, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("could not plan graph. workers must be positive, got %d", workers)
+	}
+
+	p := &planner{
+		estimate: func(Vertex) time.Duration { return DefaultEstimate },
+		skip:     func(Vertex) bool { return false },
+	}
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, errors.Wrap(err, "could not plan graph")
+		}
+	}
+
+	sorted, err := g.TopSort()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not plan graph")
+	}
+	prev, err := g.ReverseEdges()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not plan graph")
+	}
+
+	planned := make(map[Vertex]*PlannedVertex, len(sorted))
+	inDegree := make(map[Vertex]int, len(sorted))
+	for _, vertex := range sorted {
+		wave := 0
+		for _, prevVertex := range prev[vertex] {
+			if planned[prevVertex].Wave+1 > wave {
+				wave = planned[prevVertex].Wave + 1
+			}
+		}
+		planned[vertex] = &PlannedVertex{Vertex: vertex, Wave: wave, Worker: -1}
+		inDegree[vertex] = len(prev[vertex])
+	}
+
+	ready := []Vertex{}
+	for _, vertex := range sorted {
+		if inDegree[vertex] == 0 {
+			ready = append(ready, vertex)
+		}
+	}
+
+	// busy maps workers to the vertices they run
+	busy := make([]*PlannedVertex, workers)
+	now := time.Duration(0)
+	finished := 0
+
+	release := func(vertex Vertex) {
+		next, _ := g.Next(vertex)
+		for _, nextVertex := range next {
+			inDegree[nextVertex]--
+			if inDegree[nextVertex] == 0 {
+				ready = append(ready, nextVertex)
+			}
+		}
+	}
+
+	for finished < len(sorted) {
+		for len(ready) > 0 {
+			vertex := ready[0]
+			current := planned[vertex]
+
+			if p.skip(vertex) {
+				ready = ready[1:]
+				current.Skipped = true
+				current.Start, current.End = now, now
+				finished++
+				release(vertex)
+				continue
+			}
+
+			worker := index(busy, func(v *PlannedVertex) bool { return v == nil })
+			if worker < 0 {
+				break
+			}
+			ready = ready[1:]
+
+			duration := p.estimate(vertex)
+			if duration <= 0 {
+				duration = DefaultEstimate
+			}
+			current.Worker = worker
+			current.Start = now
+			current.End = now + duration
+			busy[worker] = current
+		}
+
+		// advance to the earliest end of running vertices
+		next := time.Duration(-1)
+		for _, running := range busy {
+			if running != nil && (next < 0 || running.End < next) {
+				next = running.End
+			}
+		}
+		if next < 0 {
+			break
+		}
+		now = next
+
+		for worker, running := range busy {
+			if running != nil && running.End == now {
+				busy[worker] = nil
+				finished++
+				release(running.Vertex)
+			}
+		}
+	}
+
+	plan := &Plan{Workers: workers, Vertices: make([]PlannedVertex, 0, len(sorted))}
+	for _, vertex := range sorted {
+		plan.Vertices = append(plan.Vertices, *planned[vertex])
+		if planned[vertex].End > plan.Makespan {
+			plan.Makespan = planned[vertex].End
+		}
+	}
+	sort.SliceStable(plan.Vertices, func(i, j int) bool {
+		return plan.Vertices[i].Start < plan.Vertices[j].Start
+	})
+
+	return plan, nil
+}
+
+// WriteText writes the plan as a table followed by the makespan
+func (p *Plan) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WAVE\tVERTEX\tWORKER\tSTART\tEND")
+	for _, v := range p.Vertices {
+		worker := fmt.Sprint(v.Worker)
+		if v.Skipped {
+			worker = "skip"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", v.Wave, v.Vertex, worker, v.Start, v.End)
+	}
+	if err := tw.Flush(); err != nil {
+		return errors.Wrap(err, "could not write plan")
+	}
+
+	_, err := fmt.Fprintf(w, "makespan: %s with %d workers\n", p.Makespan, p.Workers)
+	return err
+}
+
+// WriteJSON writes the plan as indented json, durations are encoded in nanoseconds
+func (p *Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(p); err != nil {
+		return errors.Wrap(err, "could not write plan")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package dag_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	t.Run("NewPlan", func(t *testing.T) {
+		t.Run("should return error when workers is not positive", func(t *testing.T) {
+			_, err := dag.NewPlan(createGraph(), 0)
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should plan vertices with default estimates", func(t *testing.T) {
+			plan, err := dag.NewPlan(createGraph(), 2)
+			assert.Nil(t, err)
+			assert.Equal(t, 4*time.Second, plan.Makespan)
+
+			expected := []dag.PlannedVertex{
+				{Vertex: "A", Wave: 0, Worker: 0, Start: 0, End: time.Second},
+				{Vertex: "B", Wave: 1, Worker: 0, Start: time.Second, End: 2 * time.Second},
+				{Vertex: "D", Wave: 1, Worker: 1, Start: time.Second, End: 2 * time.Second},
+				{Vertex: "C", Wave: 2, Worker: 0, Start: 2 * time.Second, End: 3 * time.Second},
+				{Vertex: "E", Wave: 2, Worker: 1, Start: 2 * time.Second, End: 3 * time.Second},
+				{Vertex: "F", Wave: 3, Worker: 0, Start: 3 * time.Second, End: 4 * time.Second},
+			}
+			assert.Equal(t, expected, plan.Vertices)
+		})
+
+		t.Run("should queue ready vertices when workers are busy", func(t *testing.T) {
+			estimates := map[dag.Vertex]time.Duration{"B": 5 * time.Second}
+			plan, err := dag.NewPlan(createGraph(), 1, dag.WithEstimates(func(v dag.Vertex) time.Duration {
+				return estimates[v]
+			}))
+			assert.Nil(t, err)
+			assert.Equal(t, 10*time.Second, plan.Makespan)
+
+			for _, v := range plan.Vertices {
+				assert.Equal(t, 0, v.Worker)
+			}
+		})
+
+		t.Run("should not spend time on skipped vertices", func(t *testing.T) {
+			plan, err := dag.NewPlan(createGraph(), 2, dag.WithSkip(func(v dag.Vertex) bool {
+				return v == "A" || v == "B"
+			}))
+			assert.Nil(t, err)
+			assert.Equal(t, 3*time.Second, plan.Makespan)
+			assert.Equal(t, dag.PlannedVertex{Vertex: "A", Wave: 0, Worker: -1, Skipped: true}, plan.Vertices[0])
+		})
+
+		t.Run("should return error when options are nil", func(t *testing.T) {
+			_, err := dag.NewPlan(createGraph(), 1, dag.WithEstimates(nil))
+			assert.NotNil(t, err)
+			_, err = dag.NewPlan(createGraph(), 1, dag.WithSkip(nil))
+			assert.NotNil(t, err)
+		})
+	})
+
+	t.Run("WriteText", func(t *testing.T) {
+		t.Run("should render plan as table", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B"}), dag.WithEdges(dag.Edges{"A": {"B"}}))
+			assert.Nil(t, err)
+			plan, err := dag.NewPlan(g, 1)
+			assert.Nil(t, err)
+
+			var buf bytes.Buffer
+			assert.Nil(t, plan.WriteText(&buf))
+			expected := "" +
+				"WAVE  VERTEX  WORKER  START  END\n" +
+				"0     A       0       0s     1s\n" +
+				"1     B       0       1s     2s\n" +
+				"makespan: 2s with 1 workers\n"
+			assert.Equal(t, expected, buf.String())
+		})
+	})
+
+	t.Run("WriteJSON", func(t *testing.T) {
+		t.Run("should render plan as json", func(t *testing.T) {
+			plan, err := dag.NewPlan(createGraph(), 2)
+			assert.Nil(t, err)
+
+			var buf bytes.Buffer
+			assert.Nil(t, plan.WriteJSON(&buf))
+
+			decoded := dag.Plan{}
+			assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+			assert.Equal(t, *plan, decoded)
+		})
+	})
+}
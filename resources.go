@@ -0,0 +1,130 @@
+package dag
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Resources maps resource pool names to amounts, e.g. {"db": 1, "cpu": 4}
+type Resources map[string]int
+
+// fits checks if the demand can be taken from the available resources
+func (r Resources) fits(demand Resources) bool {
+	for pool, amount := range demand {
+		if r[pool] < amount {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Resources) acquire(demand Resources) {
+	for pool, amount := range demand {
+		r[pool] -= amount
+	}
+}
+
+func (r Resources) release(demand Resources) {
+	for pool, amount := range demand {
+		r[pool] += amount
+	}
+}
+
+// DemandFunc returns the resources a vertex holds while its task runs
+type DemandFunc func(vertex Vertex) Resources
+
+// PriorityFunc returns the priority of a vertex, ready vertices with higher priority are started first
+type PriorityFunc func(vertex Vertex) int
+
+// WithResources sets the capacities of named resource pools & the demands of vertices.
+// a ready vertex is started only when all of its demands are available.
+// unless WithPriority is set, ready vertices are prioritized by their critical path lengths
+func WithResources(pools Resources, demand DemandFunc) ExecutorOptions {
+	return func(e *Executor) error {
+		if demand == nil {
+			return fmt.Errorf("demand func is nil")
+		}
+		for pool, capacity := range pools {
+			if capacity < 1 {
+				return fmt.Errorf("capacity of resource pool %s must be positive, got %d", pool, capacity)
+			}
+		}
+		e.pools = pools
+		e.demand = demand
+		return nil
+	}
+}
+
+// WithPriority sets the priority of vertices that are ready to start at the same time
+func WithPriority(priority PriorityFunc) ExecutorOptions {
+	return func(e *Executor) error {
+		if priority == nil {
+			return fmt.Errorf("priority func is nil")
+		}
+		e.priority = priority
+		return nil
+	}
+}
+
+// demandOf returns the resource demand of a vertex, returns error if the demand can never be satisfied
+func (e *Executor) demandOf(vertex Vertex) (Resources, error) {
+	if e.demand == nil {
+		return nil, nil
+	}
+
+	demand := e.demand(vertex)
+	for pool, amount := range demand {
+		capacity, exists := e.pools[pool]
+		if !exists {
+			return nil, fmt.Errorf("vertex %s demands unknown resource pool %s", vertex, pool)
+		}
+		if amount < 0 || amount > capacity {
+			return nil, fmt.Errorf("vertex %s demands %d of resource pool %s with capacity %d", vertex, amount, pool, capacity)
+		}
+	}
+	return demand, nil
+}
+
+// prioritize returns the priority func of a run, nil keeps ready vertices in the order they became ready
+func (e *Executor) prioritize() (PriorityFunc, error) {
+	if e.priority != nil || e.demand == nil {
+		return e.priority, nil
+	}
+
+	lengths, err := e.graph.CriticalPathLengths()
+	if err != nil {
+		return nil, err
+	}
+	return func(vertex Vertex) int {
+		return lengths[vertex]
+	}, nil
+}
+
+// pick returns the index of the ready vertex to start next, -1 when none of them can start.
+// ready vertices are visited in priority order. when a vertex does not fit, the short pools are reserved for it,
+// so that vertices with lower priority can not take them & starve it
+func pick(ready []Vertex, priority PriorityFunc, available Resources, demands map[Vertex]Resources, blocked func(Vertex) bool) int {
+	if priority != nil {
+		sort.SliceStable(ready, func(i, j int) bool {
+			return priority(ready[i]) > priority(ready[j])
+		})
+	}
+
+	reserved := map[string]bool{}
+	for i, vertex := range ready {
+		if blocked(vertex) {
+			return i
+		}
+
+		demand := demands[vertex]
+		if available.fits(demand) && !some(keys(demand), func(pool string) bool { return reserved[pool] }) {
+			return i
+		}
+		for pool, amount := range demand {
+			if available[pool] < amount {
+				reserved[pool] = true
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,144 @@
+package dag_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResources(t *testing.T) {
+	t.Run("WithResources", func(t *testing.T) {
+		t.Run("should return error when demand func is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithResources(dag.Resources{"db": 1}, nil))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should return error when capacity is not positive", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithResources(dag.Resources{"db": 0}, func(dag.Vertex) dag.Resources {
+				return nil
+			}))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should return error when a demand can never be satisfied", func(t *testing.T) {
+			for _, demand := range []dag.Resources{{"db": 2}, {"gpu": 1}} {
+				demand := demand
+				executor, err := dag.NewExecutor(createGraph(), concatTask, dag.WithResources(dag.Resources{"db": 1}, func(dag.Vertex) dag.Resources {
+					return demand
+				}))
+				assert.Nil(t, err)
+
+				_, err = executor.Run(context.Background())
+				assert.NotNil(t, err)
+			}
+		})
+
+		t.Run("should not exceed pool capacities", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"db1", "db2", "db3", "cpu1", "cpu2", "cpu3", "cpu4", "cpu5"}))
+			assert.Nil(t, err)
+
+			demands := map[dag.Vertex]dag.Resources{
+				"db1":  {"db": 1},
+				"db2":  {"db": 1},
+				"db3":  {"db": 1, "cpu": 4},
+				"cpu1": {"cpu": 4},
+				"cpu2": {"cpu": 8},
+				"cpu3": {"cpu": 4},
+				"cpu4": {"cpu": 8},
+				"cpu5": {"cpu": 16},
+			}
+			pools := dag.Resources{"db": 1, "cpu": 16}
+
+			var mu sync.Mutex
+			used := dag.Resources{}
+			exceeded := false
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				mu.Lock()
+				for pool, amount := range demands[vertex] {
+					used[pool] += amount
+					if used[pool] > pools[pool] {
+						exceeded = true
+					}
+				}
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				for pool, amount := range demands[vertex] {
+					used[pool] -= amount
+				}
+				mu.Unlock()
+				return nil, nil
+			}
+
+			executor, err := dag.NewExecutor(g, task, dag.WithWorkers(8), dag.WithResources(pools, func(v dag.Vertex) dag.Resources {
+				return demands[v]
+			}))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.False(t, exceeded)
+			for _, result := range results {
+				assert.Equal(t, dag.StateSucceeded, result.State)
+			}
+		})
+
+		t.Run("should start vertices on the critical path first", func(t *testing.T) {
+			// X is ready at the same time as A, but A has the longest path to a leaf
+			g := createGraph()
+			assert.Nil(t, g.Add("X"))
+
+			var mu sync.Mutex
+			order := []dag.Vertex{}
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				mu.Lock()
+				order = append(order, vertex)
+				mu.Unlock()
+				return nil, nil
+			}
+
+			executor, err := dag.NewExecutor(g, task, dag.WithWorkers(1), dag.WithResources(dag.Resources{}, func(dag.Vertex) dag.Resources {
+				return nil
+			}))
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, []dag.Vertex{"A", "B", "D", "E", "X", "C", "F"}, order)
+		})
+	})
+
+	t.Run("WithPriority", func(t *testing.T) {
+		t.Run("should return error when priority func is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithPriority(nil))
+			assert.NotNil(t, err)
+		})
+
+		t.Run("should start ready vertices by priority", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"low", "mid", "high"}))
+			assert.Nil(t, err)
+
+			order := []dag.Vertex{}
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				order = append(order, vertex)
+				return nil, nil
+			}
+			priorities := map[dag.Vertex]int{"low": 1, "mid": 2, "high": 3}
+
+			executor, err := dag.NewExecutor(g, task, dag.WithWorkers(1), dag.WithPriority(func(v dag.Vertex) int {
+				return priorities[v]
+			}))
+			assert.Nil(t, err)
+
+			_, err = executor.Run(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, []dag.Vertex{"high", "mid", "low"}, order)
+		})
+	})
+}
@@ -36,3 +36,12 @@ func some[T any](slice []T, test func(T) bool) bool {
 	}
 	return false
 }
+
+// keys returns the keys of a map
+func keys[K comparable, V any](m map[K]V) []K {
+	ret := make([]K, 0, len(m))
+	for key := range m {
+		ret = append(ret, key)
+	}
+	return ret
+}
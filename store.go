@@ -0,0 +1,392 @@
+package dag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	storeSnapshotFile  = "snapshot.dag"
+	storeLogFile       = "wal.log"
+	storeSnapshotMagic = "DAGS"
+	storeLogMagic      = "DAGW"
+	// storeHeaderSize is the size of the magic & generation that start snapshots & logs
+	storeHeaderSize = 4 + 8
+	// storeRecordHeaderSize is the size of the length & CRC-32C checksum that start log records
+	storeRecordHeaderSize = 4 + 4
+)
+
+// storeRecord is a mutation in the log of a store
+type storeRecord struct {
+	Op       string          `json:"op"`
+	Vertex   Vertex          `json:"vertex,omitempty"`
+	Vertices []Vertex        `json:"vertices,omitempty"`
+	From     Vertex          `json:"from,omitempty"`
+	To       Vertex          `json:"to,omitempty"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+}
+
+// apply applies the record to a graph
+func (r storeRecord) apply(g *Graph) error {
+	switch r.Op {
+	case "add":
+		return g.Add(r.Vertices...)
+	case "append":
+		return g.Append(r.Vertex, r.Vertices)
+	case "connect":
+		return g.Connect(r.From, r.To)
+	case "disconnect":
+		return g.DisconnectEdge(r.From, r.To)
+	case "remove":
+		_, err := g.Remove(r.Vertex)
+		return err
+	case "payload":
+		return g.SetPayload(r.Vertex, r.Payload)
+	default:
+		return fmt.Errorf("unknown operation %s", r.Op)
+	}
+}
+
+type StoreOptions func(*Store) error
+
+// WithSnapshotInterval sets the number of log records after which the store takes a snapshot, 0 disables automatic
+// snapshots. the default is 10000 records
+func WithSnapshotInterval(records int) StoreOptions {
+	return func(s *Store) error {
+		if records < 0 {
+			return fmt.Errorf("snapshot interval %d is negative", records)
+		}
+		s.interval = records
+		return nil
+	}
+}
+
+// Store is a graph that is persisted in a directory, it survives restarts without importing the graph again.
+//
+// every mutation is appended to a write-ahead log & synced to disk before it returns. the log is compacted into a binary
+// snapshot every WithSnapshotInterval records & on Snapshot. OpenStore loads the snapshot & replays the log, a torn
+// final record of a crash during a write is dropped
+type Store struct {
+	mu    sync.Mutex
+	dir   string
+	graph *Graph
+	log   *os.File
+	// generation is increased by each snapshot, a log belongs to the snapshot of the same generation
+	generation uint64
+	records    int
+	interval   int
+	err        error
+}
+
+// OpenStore opens the store in the given directory, creating the directory if it does not exist
+func OpenStore(dir string, opts ...StoreOptions) (*Store, error) {
+	s := &Store{dir: dir, interval: 10000}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, errors.Wrap(err, "could not open store")
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "could not open store")
+	}
+
+	if err := s.loadSnapshot(); err != nil {
+		return nil, errors.Wrap(err, "could not open store")
+	}
+	if err := s.replay(); err != nil {
+		return nil, errors.Wrap(err, "could not open store")
+	}
+	return s, nil
+}
+
+// loadSnapshot loads the graph & generation of the snapshot, an empty graph of generation 0 when there is no snapshot
+func (s *Store) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, storeSnapshotFile))
+	if os.IsNotExist(err) {
+		s.graph, err = New()
+		return err
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not read snapshot")
+	}
+
+	if len(data) < storeHeaderSize || string(data[:4]) != storeSnapshotMagic {
+		return fmt.Errorf("could not read snapshot. invalid header")
+	}
+	s.generation = binary.LittleEndian.Uint64(data[4:])
+	s.graph, err = LoadBinary(bytes.NewReader(data[storeHeaderSize:]))
+	return errors.Wrap(err, "could not read snapshot")
+}
+
+// replay applies the records of the log to the graph & opens the log for appending. logs of older generations are
+// already in the snapshot & are replaced with an empty log
+func (s *Store) replay() error {
+	path := filepath.Join(s.dir, storeLogFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "could not read log")
+	}
+	if os.IsNotExist(err) {
+		return s.resetLog()
+	}
+	if len(data) < storeHeaderSize || string(data[:4]) != storeLogMagic {
+		return fmt.Errorf("could not read log. invalid header")
+	}
+	if generation := binary.LittleEndian.Uint64(data[4:]); generation < s.generation {
+		return s.resetLog()
+	} else if generation > s.generation {
+		return fmt.Errorf("could not read log. generation %d is newer than the snapshot generation %d", generation, s.generation)
+	}
+
+	offset := storeHeaderSize
+	for offset < len(data) {
+		record, size, err := decodeStoreRecord(data[offset:])
+		if err == errTornRecord {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("could not read log record at offset %d", offset))
+		}
+		if err := record.apply(s.graph); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("could not replay log record at offset %d", offset))
+		}
+		offset += size
+		s.records++
+	}
+
+	// a torn final record is cut off, so that the next record is appended after the last complete one
+	if offset < len(data) {
+		if err := os.Truncate(path, int64(offset)); err != nil {
+			return errors.Wrap(err, "could not truncate torn log record")
+		}
+	}
+	s.log, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	return errors.Wrap(err, "could not open log")
+}
+
+var errTornRecord = errors.New("torn record")
+
+// decodeStoreRecord decodes the record at the start of data & returns its size. records that are cut off by the end of
+// data & final records with a checksum mismatch are torn, checksum mismatches before the final record are corruption.
+// a length that points past the end of data is only torn when no complete record follows it, otherwise the length is
+// corrupted & dropping the rest of the log would drop committed records
+func decodeStoreRecord(data []byte) (storeRecord, int, error) {
+	if len(data) < storeRecordHeaderSize {
+		return storeRecord{}, 0, errTornRecord
+	}
+	size := storeRecordHeaderSize + int(binary.LittleEndian.Uint32(data))
+	if size < storeRecordHeaderSize || size > len(data) {
+		if containsStoreRecord(data[storeRecordHeaderSize:]) {
+			return storeRecord{}, 0, fmt.Errorf("length %d points past the end of the log", size-storeRecordHeaderSize)
+		}
+		return storeRecord{}, 0, errTornRecord
+	}
+	body := data[storeRecordHeaderSize:size]
+	if crc32.Checksum(body, binaryChecksum) != binary.LittleEndian.Uint32(data[4:]) {
+		if size == len(data) {
+			return storeRecord{}, 0, errTornRecord
+		}
+		return storeRecord{}, 0, fmt.Errorf("checksum mismatch")
+	}
+
+	record := storeRecord{}
+	if err := json.Unmarshal(body, &record); err != nil {
+		return storeRecord{}, 0, errors.Wrap(err, "could not decode record")
+	}
+	return record, size, nil
+}
+
+// containsStoreRecord returns whether a complete record with a matching checksum starts anywhere in data
+func containsStoreRecord(data []byte) bool {
+	for i := 0; i+storeRecordHeaderSize < len(data); i++ {
+		size := storeRecordHeaderSize + int(binary.LittleEndian.Uint32(data[i:]))
+		// bodies are json objects, so that runs of zeros are not taken for empty records
+		if size <= storeRecordHeaderSize || size > len(data)-i || data[i+storeRecordHeaderSize] != '{' {
+			continue
+		}
+		if crc32.Checksum(data[i+storeRecordHeaderSize:i+size], binaryChecksum) == binary.LittleEndian.Uint32(data[i+4:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// resetLog replaces the log with an empty log of the current generation
+func (s *Store) resetLog() error {
+	path := filepath.Join(s.dir, storeLogFile)
+	header := make([]byte, storeHeaderSize)
+	copy(header, storeLogMagic)
+	binary.LittleEndian.PutUint64(header[4:], s.generation)
+	if err := writeFileAtomic(path, header); err != nil {
+		return errors.Wrap(err, "could not create log")
+	}
+
+	log, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "could not open log")
+	}
+	if s.log != nil {
+		s.log.Close()
+	}
+	s.log, s.records = log, 0
+	return nil
+}
+
+// Graph returns a read-only copy of the graph of the store at the time of the call, so that it can be read while the
+// store is written. payloads are shared with the copy
+func (s *Store) Graph() *Graph {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vertices, edges := s.graph.snapshot()
+	return &Graph{vertices: vertices, edges: edges, payloads: s.graph.payloadSnapshot(), readOnly: true}
+}
+
+// Add adds unconnected vertices to the graph & persists them
+func (s *Store) Add(vertices ...Vertex) error {
+	return s.write(storeRecord{Op: "add", Vertices: vertices}, func(tx *Tx) error {
+		return tx.Add(vertices...)
+	})
+}
+
+// Append adds a vertex that is connected to the given previous vertices & persists it
+func (s *Store) Append(v Vertex, prevVertices []Vertex) error {
+	return s.write(storeRecord{Op: "append", Vertex: v, Vertices: prevVertices}, func(tx *Tx) error {
+		return tx.Append(v, prevVertices)
+	})
+}
+
+// Connect connects two vertices & persists the edge
+func (s *Store) Connect(from Vertex, to Vertex) error {
+	return s.write(storeRecord{Op: "connect", From: from, To: to}, func(tx *Tx) error {
+		return tx.Connect(from, to)
+	})
+}
+
+// DisconnectEdge disconnects two vertices & persists the removal of the edge
+func (s *Store) DisconnectEdge(from Vertex, to Vertex) error {
+	return s.write(storeRecord{Op: "disconnect", From: from, To: to}, func(tx *Tx) error {
+		return tx.DisconnectEdge(from, to)
+	})
+}
+
+// Remove removes a vertex & its next vertices like Graph.Remove & persists the removal
+func (s *Store) Remove(v Vertex) (removed []Vertex, err error) {
+	err = s.write(storeRecord{Op: "remove", Vertex: v}, func(tx *Tx) error {
+		removed, err = tx.Remove(v)
+		return err
+	})
+	return removed, err
+}
+
+// SetPayload sets the payload of a vertex & persists it as json, payloads are loaded back as json.RawMessage
+func (s *Store) SetPayload(vertex Vertex, payload any) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("could not marshal payload of vertex %s", vertex))
+	}
+	return s.write(storeRecord{Op: "payload", Vertex: vertex, Payload: encoded}, func(tx *Tx) error {
+		return tx.SetPayload(vertex, payload)
+	})
+}
+
+// write applies a mutation to the graph in a transaction that is committed after its record is appended to the log
+// & synced, so that the graph never gets ahead of the log. a failed append leaves an unknown part of the record in the
+// log, so the store refuses further writes until it is opened again
+func (s *Store) write(record storeRecord, apply func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "could not encode log record")
+	}
+	data := make([]byte, storeRecordHeaderSize, storeRecordHeaderSize+len(body))
+	binary.LittleEndian.PutUint32(data, uint32(len(body)))
+	binary.LittleEndian.PutUint32(data[4:], crc32.Checksum(body, binaryChecksum))
+	data = append(data, body...)
+
+	err = s.graph.Tx(func(tx *Tx) error {
+		if err := apply(tx); err != nil {
+			return err
+		}
+		if _, err := s.log.Write(data); err != nil {
+			s.err = errors.Wrap(err, "could not write log record. store must be opened again")
+			return s.err
+		}
+		if err := s.log.Sync(); err != nil {
+			s.err = errors.Wrap(err, "could not sync log record. store must be opened again")
+			return s.err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.records++
+	if s.interval > 0 && s.records >= s.interval {
+		// the record is persisted even when the snapshot fails, a failed snapshot is taken again on the next write
+		_ = s.snapshot()
+	}
+	return nil
+}
+
+// Snapshot compacts the log into a snapshot of the graph & starts an empty log
+func (s *Store) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	return s.snapshot()
+}
+
+// snapshot writes the snapshot of the next generation before the log of the next generation, a crash in between leaves
+// a log of an older generation that is replaced on open
+func (s *Store) snapshot() error {
+	var data bytes.Buffer
+	header := make([]byte, storeHeaderSize)
+	copy(header, storeSnapshotMagic)
+	binary.LittleEndian.PutUint64(header[4:], s.generation+1)
+	data.Write(header)
+	if err := s.graph.WriteBinary(&data); err != nil {
+		return errors.Wrap(err, "could not take snapshot")
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, storeSnapshotFile), data.Bytes()); err != nil {
+		return errors.Wrap(err, "could not take snapshot")
+	}
+
+	s.generation++
+	if err := s.resetLog(); err != nil {
+		s.err = errors.Wrap(err, "could not take snapshot. store must be opened again")
+		return s.err
+	}
+	return nil
+}
+
+// Close closes the log of the store, the store must not be used after it is closed. closing a closed store does nothing
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return nil
+	}
+	if s.err == nil {
+		s.err = fmt.Errorf("store is closed")
+	}
+	err := s.log.Close()
+	s.log = nil
+	return errors.Wrap(err, "could not close store")
+}
@@ -0,0 +1,174 @@
+package dag_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+// fillStore builds the sample graph A -> B -> C, A -> D -> E -> F, B -> E through the store
+func fillStore(t *testing.T, s *dag.Store) {
+	assert.Nil(t, s.Add("A", "B", "C", "D"))
+	assert.Nil(t, s.Connect("A", "B"))
+	assert.Nil(t, s.Connect("B", "C"))
+	assert.Nil(t, s.Connect("A", "D"))
+	assert.Nil(t, s.Append("E", []dag.Vertex{"B", "D"}))
+	assert.Nil(t, s.Append("F", []dag.Vertex{"E"}))
+}
+
+func TestStore(t *testing.T) {
+	t.Run("should replay the log on open", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		fillStore(t, s)
+		assert.Nil(t, s.Add("G"))
+		assert.Nil(t, s.Connect("F", "G"))
+		assert.Nil(t, s.DisconnectEdge("F", "G"))
+		assert.Nil(t, s.SetPayload("A", map[string]string{"cmd": "make"}))
+		assert.NotNil(t, s.Connect("C", "A"))
+		assert.Nil(t, s.Close())
+
+		reopened, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		defer reopened.Close()
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F", "G"}, reopened.Graph().Vertices())
+		sorted, err := reopened.Graph().TopSort()
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "G", "B", "D", "C", "E", "F"}, sorted)
+		payload, _ := reopened.Graph().Payload("A")
+		assert.Equal(t, json.RawMessage(`{"cmd":"make"}`), payload)
+
+		removed, err := reopened.Remove("E")
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"E", "F"}, removed)
+	})
+
+	t.Run("should compact the log into snapshots", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := dag.OpenStore(dir, dag.WithSnapshotInterval(4))
+		assert.Nil(t, err)
+		fillStore(t, s)
+		_, err = os.Stat(filepath.Join(dir, "snapshot.dag"))
+		assert.Nil(t, err)
+
+		_, err = s.Remove("D")
+		assert.Nil(t, err)
+		assert.Nil(t, s.Snapshot())
+		assert.Nil(t, s.Close())
+
+		reopened, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		defer reopened.Close()
+		assert.Equal(t, dag.Edges{"A": {"B"}, "B": {"C"}, "C": {}}, reopened.Graph().Edges())
+	})
+
+	t.Run("should ignore logs that are already in the snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		fillStore(t, s)
+		stale, err := os.ReadFile(filepath.Join(dir, "wal.log"))
+		assert.Nil(t, err)
+		assert.Nil(t, s.Snapshot())
+		assert.Nil(t, s.Close())
+
+		// a crash after the snapshot is written leaves the log of the previous generation
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "wal.log"), stale, 0o644))
+		reopened, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		defer reopened.Close()
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F"}, reopened.Graph().Vertices())
+	})
+
+	t.Run("should drop a torn final record", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		fillStore(t, s)
+		assert.Nil(t, s.Add("G"))
+		assert.Nil(t, s.Close())
+
+		path := filepath.Join(dir, "wal.log")
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Nil(t, os.WriteFile(path, data[:len(data)-3], 0o644))
+
+		reopened, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F"}, reopened.Graph().Vertices())
+		assert.Nil(t, reopened.Add("H"))
+		assert.Nil(t, reopened.Close())
+
+		reopened, err = dag.OpenStore(dir)
+		assert.Nil(t, err)
+		defer reopened.Close()
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F", "H"}, reopened.Graph().Vertices())
+	})
+
+	t.Run("should return error for corrupt records", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		fillStore(t, s)
+		assert.Nil(t, s.Close())
+
+		path := filepath.Join(dir, "wal.log")
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		// the body of the first record
+		data[30] ^= 0xff
+		assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+		_, err = dag.OpenStore(dir)
+		assert.EqualError(t, err, "could not open store: could not read log record at offset 12: checksum mismatch")
+	})
+
+	t.Run("should return error for corrupt lengths before committed records", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := dag.OpenStore(dir)
+		assert.Nil(t, err)
+		fillStore(t, s)
+		assert.Nil(t, s.Close())
+
+		path := filepath.Join(dir, "wal.log")
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		// the length of the first record
+		data[15] = 0x7f
+		assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+		_, err = dag.OpenStore(dir)
+		assert.EqualError(t, err, "could not open store: could not read log record at offset 12: length 2130706473 points past the end of the log")
+		kept, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, data, kept)
+	})
+
+	t.Run("should return read-only copies of the graph", func(t *testing.T) {
+		s, err := dag.OpenStore(t.TempDir())
+		assert.Nil(t, err)
+		defer s.Close()
+		fillStore(t, s)
+
+		g := s.Graph()
+		assert.EqualError(t, g.Add("G"), "graph is read-only")
+		assert.EqualError(t, g.Connect("C", "F"), "could not connect vertex C to vertex F: graph is read-only")
+		assert.Nil(t, s.Add("G"))
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F"}, g.Vertices())
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F", "G"}, s.Graph().Vertices())
+	})
+
+	t.Run("should refuse writes after close", func(t *testing.T) {
+		s, err := dag.OpenStore(t.TempDir())
+		assert.Nil(t, err)
+		assert.Nil(t, s.Close())
+		assert.EqualError(t, s.Add("A"), "store is closed")
+		assert.EqualError(t, s.Snapshot(), "store is closed")
+		assert.Nil(t, s.Close())
+	})
+}
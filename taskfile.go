@@ -0,0 +1,272 @@
+package dag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// TaskfileTask is a task of a Taskfile, it is the payload of vertices that are loaded from Taskfiles
+type TaskfileTask struct {
+	Name    Vertex
+	Desc    string
+	Aliases []string
+	// Deps are the tasks of deps, they run before the task
+	Deps []Vertex
+	// Calls are the tasks that cmds call, e.g. "- task: build"
+	Calls []Vertex
+	// Cmds are the shell commands of cmds
+	Cmds []string
+	// Line & Column are the position of the task in the Taskfile
+	Line   int
+	Column int
+}
+
+// LoadTaskfileFile loads a graph from a Taskfile, e.g. Taskfile.yml
+func LoadTaskfileFile(path string) (*Graph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load taskfile")
+	}
+	defer file.Close()
+	return LoadTaskfile(file)
+}
+
+// LoadTaskfile loads a graph from a Taskfile of version 3, see https://taskfile.dev. vertices are the tasks in file
+// order & their payloads are *TaskfileTask. the tasks of deps & the tasks that cmds call are connected to the task, so
+// TopSort lists them first & Deps returns them
+//
+//	version: "3"
+//	tasks:
+//	  lint:
+//	    deps: [lint:install]   # lint:install -> lint
+//	    cmds:
+//	      - golangci-lint run
+//	  ci:
+//	    cmds:
+//	      - task: lint         # lint -> ci
+//
+// tasks are referenced by names or aliases, a leading colon refers to the root Taskfile. tasks of included Taskfiles
+// are not loaded, references to them are added as vertices without payloads. variables are not evaluated.
+//
+// syntax errors, unknown tasks & cycles are returned as *LoadError
+func LoadTaskfile(r io.Reader) (*Graph, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load taskfile")
+	}
+
+	root := yaml.Node{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		message, line := strings.TrimPrefix(err.Error(), "yaml: "), 0
+		if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			message = strings.TrimPrefix(message, match[0]+": ")
+		}
+		return nil, &LoadError{Line: line, Message: message}
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, &LoadError{Line: root.Line, Column: root.Column, Message: "expected a mapping"}
+	}
+	return loadTaskfile(root.Content[0])
+}
+
+// taskfileRef is a reference to a task with its position
+type taskfileRef struct {
+	name Vertex
+	node *yaml.Node
+}
+
+func loadTaskfile(document *yaml.Node) (*Graph, error) {
+	fail := func(node *yaml.Node, format string, args ...any) *LoadError {
+		return &LoadError{Line: node.Line, Column: node.Column, Message: fmt.Sprintf(format, args...)}
+	}
+
+	var version, tasks *yaml.Node
+	namespaces := []string{}
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		key, value := document.Content[i], document.Content[i+1]
+		switch key.Value {
+		case "version":
+			version = value
+		case "tasks":
+			tasks = value
+		case "includes":
+			if value.Kind == yaml.MappingNode {
+				for j := 0; j < len(value.Content); j += 2 {
+					namespaces = append(namespaces, value.Content[j].Value)
+				}
+			}
+		}
+	}
+	if version == nil {
+		return nil, fail(document, "version is missing, expected 3")
+	}
+	if version.Kind != yaml.ScalarNode || version.Value != "3" && !strings.HasPrefix(version.Value, "3.") {
+		return nil, fail(version, "unsupported version %s, expected 3", version.Value)
+	}
+
+	g, err := New()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load taskfile")
+	}
+	if tasks == nil {
+		return g, nil
+	}
+	if tasks.Kind != yaml.MappingNode {
+		return nil, fail(tasks, "tasks must be a mapping")
+	}
+
+	loaded := []*TaskfileTask{}
+	refs := map[Vertex][]taskfileRef{}
+	aliases := map[string]Vertex{}
+	for i := 0; i+1 < len(tasks.Content); i += 2 {
+		key, value := tasks.Content[i], tasks.Content[i+1]
+		if g.Exists(key.Value) {
+			return nil, fail(key, "duplicate task %s", key.Value)
+		}
+		task, taskRefs, err := taskfileTask(key, value)
+		if err != nil {
+			return nil, err
+		}
+		for _, alias := range task.Aliases {
+			aliases[alias] = task.Name
+		}
+		_ = g.Add(task.Name)
+		_ = g.SetPayload(task.Name, task)
+		loaded = append(loaded, task)
+		refs[task.Name] = taskRefs
+	}
+
+	for _, task := range loaded {
+		for _, ref := range refs[task.Name] {
+			name := strings.TrimPrefix(ref.name, ":")
+			if aliased, ok := aliases[name]; ok && !g.Exists(name) {
+				name = aliased
+			}
+			if !g.Exists(name) {
+				if !some(namespaces, func(namespace string) bool { return strings.HasPrefix(name, namespace+":") }) {
+					err := fail(ref.node, "task %s: unknown task %s", task.Name, ref.name)
+					err.Err = &VertexNotFoundError{Vertex: name}
+					return nil, err
+				}
+				_ = g.Add(name)
+			}
+
+			if next, _ := g.Next(name); includes(next, task.Name) {
+				continue
+			}
+			if cycle := dependencyCycle(g, name, task.Name); cycle != nil {
+				err := fail(ref.node, "task %s: dependency %s creates a cycle %s", task.Name, name, strings.Join(cycle, " -> "))
+				err.Cycle = cycle
+				return nil, err
+			}
+			if err := g.Connect(name, task.Name); err != nil {
+				return nil, errors.Wrap(err, "could not load taskfile")
+			}
+		}
+	}
+	return g, nil
+}
+
+// taskfileTask decodes a task, which is a mapping, a command or a list of commands
+func taskfileTask(key *yaml.Node, node *yaml.Node) (*TaskfileTask, []taskfileRef, error) {
+	task := &TaskfileTask{Name: key.Value, Line: key.Line, Column: key.Column}
+	refs := []taskfileRef{}
+	fail := func(node *yaml.Node, format string, args ...any) error {
+		message := fmt.Sprintf(format, args...)
+		return &LoadError{Line: node.Line, Column: node.Column, Message: fmt.Sprintf("task %s: %s", task.Name, message)}
+	}
+
+	// cmd adds a command or a call of a task
+	cmd := func(node *yaml.Node) error {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			task.Cmds = append(task.Cmds, node.Value)
+		case yaml.MappingNode:
+			if name := mappingValue(node, "task"); name != nil {
+				task.Calls = append(task.Calls, name.Value)
+				refs = append(refs, taskfileRef{name: name.Value, node: name})
+			} else if command := mappingValue(node, "cmd"); command != nil {
+				task.Cmds = append(task.Cmds, command.Value)
+			}
+		default:
+			return fail(node, "command must be a string or a mapping")
+		}
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag != "!!null" {
+			task.Cmds = append(task.Cmds, node.Value)
+		}
+		return task, refs, nil
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := cmd(item); err != nil {
+				return nil, nil, err
+			}
+		}
+		return task, refs, nil
+	case yaml.MappingNode:
+	default:
+		return nil, nil, fail(node, "expected a mapping, a command or a list of commands")
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		field, value := node.Content[i], node.Content[i+1]
+		switch field.Value {
+		case "desc":
+			task.Desc = value.Value
+		case "aliases":
+			for _, alias := range value.Content {
+				task.Aliases = append(task.Aliases, alias.Value)
+			}
+		case "deps":
+			if value.Kind != yaml.SequenceNode {
+				return nil, nil, fail(value, "deps must be a list")
+			}
+			for _, dep := range value.Content {
+				name := dep
+				if dep.Kind == yaml.MappingNode {
+					name = mappingValue(dep, "task")
+				}
+				if name == nil || name.Kind != yaml.ScalarNode {
+					return nil, nil, fail(dep, "dependency must be a task name or a mapping with a task")
+				}
+				task.Deps = append(task.Deps, name.Value)
+				refs = append(refs, taskfileRef{name: name.Value, node: name})
+			}
+		case "cmd":
+			if err := cmd(value); err != nil {
+				return nil, nil, err
+			}
+		case "cmds":
+			if value.Kind != yaml.SequenceNode {
+				return nil, nil, fail(value, "cmds must be a list")
+			}
+			for _, item := range value.Content {
+				if err := cmd(item); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return task, refs, nil
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
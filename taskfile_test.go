@@ -0,0 +1,87 @@
+package dag_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTaskfile(t *testing.T) {
+	t.Run("should load the taskfile of the repository", func(t *testing.T) {
+		g, err := dag.LoadTaskfileFile("Taskfile.yml")
+		assert.Nil(t, err)
+
+		deps, err := g.Deps("ci")
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"build", "lint:install", "test:clean", "test:coverprofile", "lint", "test:race", "test:cover:check"}, deps)
+
+		payload, _ := g.Payload("lint")
+		assert.Equal(t, &dag.TaskfileTask{
+			Name:   "lint",
+			Desc:   "Lint the project",
+			Deps:   []dag.Vertex{"lint:install"},
+			Cmds:   []string{"golangci-lint run", "staticcheck ./..."},
+			Line:   25,
+			Column: 3,
+		}, payload)
+	})
+
+	t.Run("should resolve short syntax, aliases & included tasks", func(t *testing.T) {
+		g, err := dag.LoadTaskfile(strings.NewReader(`version: '3'
+includes:
+  docs: ./docs
+tasks:
+  generate: go generate ./...
+  build:
+    aliases: [b]
+    deps:
+      - generate
+      - task: docs:build
+        vars: {OUT: site}
+    cmd: go build
+  release:
+    - task: :b
+    - goreleaser
+    - task: build
+`))
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"generate", "build", "release", "docs:build"}, g.Vertices())
+		assert.Equal(t, dag.Edges{
+			"generate":   {"build"},
+			"build":      {"release"},
+			"release":    {},
+			"docs:build": {"build"},
+		}, g.Edges())
+
+		payload, _ := g.Payload("release")
+		assert.Equal(t, []dag.Vertex{":b", "build"}, payload.(*dag.TaskfileTask).Calls)
+		assert.Equal(t, []string{"goreleaser"}, payload.(*dag.TaskfileTask).Cmds)
+		_, ok := g.Payload("docs:build")
+		assert.False(t, ok)
+	})
+
+	t.Run("should return errors with positions", func(t *testing.T) {
+		inputs := map[string]string{
+			"tasks: {}":                              "line 1, column 1: version is missing, expected 3",
+			"version: '2'":                           "line 1, column 10: unsupported version 2, expected 3",
+			"version: '3'\ntasks:\n  a: {deps: [b]}": "line 3, column 14: task a: unknown task b",
+			"version: '3'\ntasks:\n  a: {deps: x}":   "line 3, column 13: task a: deps must be a list",
+			"version: '3'\ntasks: [":                 "line 2: did not find expected node content",
+		}
+		for input, message := range inputs {
+			_, err := dag.LoadTaskfile(strings.NewReader(input))
+			assert.EqualError(t, err, message, input)
+		}
+	})
+
+	t.Run("should return cycles", func(t *testing.T) {
+		_, err := dag.LoadTaskfile(strings.NewReader("version: '3'\ntasks:\n  a: {deps: [b]}\n  b:\n    cmds:\n      - task: a\n"))
+		var loadErr *dag.LoadError
+		assert.True(t, errors.As(err, &loadErr))
+		assert.Equal(t, []dag.Vertex{"a", "b", "a"}, loadErr.Cycle)
+		assert.EqualError(t, err, "line 6, column 15: task b: dependency a creates a cycle a -> b -> a")
+	})
+}
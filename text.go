@@ -0,0 +1,391 @@
+package dag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTextWidth is the width limit of WriteText when no width is given
+const DefaultTextWidth = 80
+
+type TextOptions func(*textRenderer) error
+
+// WithWidth sets the width limit in columns, 0 means no limit. graphs with more lanes than fit the width are rendered
+// as trees, labels that do not fit are truncated & the branches of deep tree rows are cut from the left
+func WithWidth(width int) TextOptions {
+	return func(r *textRenderer) error {
+		if width < 0 {
+			return fmt.Errorf("width must not be negative, got %d", width)
+		}
+		r.width = width
+		return nil
+	}
+}
+
+// WithASCII draws with ASCII characters instead of unicode box drawing characters
+func WithASCII() TextOptions {
+	return func(r *textRenderer) error {
+		r.chars = asciiChars
+		return nil
+	}
+}
+
+// WithTree renders the graph as an indented tree regardless of its width
+func WithTree() TextOptions {
+	return func(r *textRenderer) error {
+		r.tree = true
+		return nil
+	}
+}
+
+// textChars are the characters that the lanes & trees are drawn with
+type textChars struct {
+	vertex, vertical, horizontal                   rune
+	teeRight, teeLeft, cross, teeDown, teeUp       rune
+	downRight, downLeft, upLeft                    rune
+	treeBranch, treeLast, treeVertical, treeIndent string
+	ellipsis                                       string
+}
+
+var unicodeChars = textChars{
+	vertex: '●', vertical: '│', horizontal: '─',
+	teeRight: '├', teeLeft: '┤', cross: '┼', teeDown: '┬', teeUp: '┴',
+	downRight: '╭', downLeft: '╮', upLeft: '╯',
+	treeBranch: "├── ", treeLast: "└── ", treeVertical: "│   ", treeIndent: "    ",
+	ellipsis: "…",
+}
+
+var asciiChars = textChars{
+	vertex: '*', vertical: '|', horizontal: '-',
+	teeRight: '+', teeLeft: '+', cross: '+', teeDown: '+', teeUp: '+',
+	downRight: '.', downLeft: '.', upLeft: '\'',
+	treeBranch: "|-- ", treeLast: "`-- ", treeVertical: "|   ", treeIndent: "    ",
+	ellipsis: "...",
+}
+
+type textRenderer struct {
+	width int
+	chars textChars
+	tree  bool
+}
+
+// WriteText draws the graph for terminals, e.g. CI logs. vertices are written one per row, ordered by their layer,
+// the length of the longest path from a root, and then by insertion order. edges are drawn as lanes between the
+// rows like git log --graph does
+//
+//	● A
+//	├─╮
+//	● │ B
+//	├─│─╮
+//	│ ● │ D
+//	● │ │ C
+//	╭─┴─╯
+//	● E
+//	● F
+//
+// an edge that crosses a lane is drawn below it. graphs with more lanes than fit the width are written as an indented
+// tree of the roots instead, where vertices that are already written are marked with (*) & not expanded again
+func (g *Graph) WriteText(w io.Writer, opts ...TextOptions) error {
+	r := &textRenderer{width: DefaultTextWidth, chars: unicodeChars}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return errors.Wrap(err, "could not write text")
+		}
+	}
+
+	vertices, edges := g.snapshot()
+	lines, fits := r.lanes(layerOrder(vertices, edges), edges)
+	if r.tree || !fits {
+		lines = r.treeLines(vertices, edges)
+	}
+
+	out := bufio.NewWriter(w)
+	for _, line := range lines {
+		fmt.Fprintln(out, line)
+	}
+	return out.Flush()
+}
+
+// layerOrder orders vertices by the length of the longest path from a root & then by insertion order
+func layerOrder(vertices []Vertex, edges Edges) []Vertex {
+	inDegree := map[Vertex]int{}
+	for _, v := range vertices {
+		for _, next := range edges[v] {
+			inDegree[next]++
+		}
+	}
+
+	layer := map[Vertex]int{}
+	ready := filter(vertices, func(v Vertex) bool { return inDegree[v] == 0 })
+	for len(ready) > 0 {
+		v := ready[0]
+		ready = ready[1:]
+		for _, next := range edges[v] {
+			if layer[v]+1 > layer[next] {
+				layer[next] = layer[v] + 1
+			}
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	ordered := append([]Vertex{}, vertices...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return layer[ordered[i]] < layer[ordered[j]]
+	})
+	return ordered
+}
+
+// lane is a column that carries an edge down to its target vertex
+type lane struct {
+	target Vertex
+	active bool
+}
+
+// lanes draws the vertices in order, returning false when the lanes do not fit the width
+func (r *textRenderer) lanes(ordered []Vertex, edges Edges) (lines []string, fits bool) {
+	c := r.chars
+	lanes := []lane{}
+	widest := 0
+	draw := func(cells []rune) string {
+		line := strings.TrimRight(string(cells), " ")
+		if n := utf8.RuneCountInString(line); n > widest {
+			widest = n
+		}
+		return line
+	}
+	free := func(except int) int {
+		for i, l := range lanes {
+			if !l.active && i != except {
+				return i
+			}
+		}
+		lanes = append(lanes, lane{})
+		return len(lanes) - 1
+	}
+
+	for _, v := range ordered {
+		incoming := []int{}
+		for i, l := range lanes {
+			if l.active && l.target == v {
+				incoming = append(incoming, i)
+			}
+		}
+
+		// the vertex takes the leftmost incoming lane, moving left over free lanes
+		column := 0
+		if len(incoming) == 0 {
+			column = free(-1)
+		} else {
+			column = incoming[0]
+			for column > 0 && !lanes[column-1].active {
+				column--
+			}
+		}
+
+		if len(incoming) > 1 || len(incoming) == 1 && incoming[0] != column {
+			hi := incoming[len(incoming)-1]
+			cells := r.cells(lanes)
+			r.horizontal(cells, column, hi)
+			for _, i := range incoming {
+				cells[2*i] = c.teeUp
+			}
+			cells[2*hi] = c.upLeft
+			if column == incoming[0] {
+				cells[2*column] = c.teeRight
+			} else {
+				cells[2*column] = c.downRight
+			}
+			lines = append(lines, draw(cells))
+		}
+		for _, i := range incoming {
+			lanes[i].active = false
+		}
+
+		cells := r.cells(lanes)
+		cells[2*column] = c.vertex
+		lines = append(lines, r.row(draw(cells)+" ", v))
+
+		next := edges[v]
+		if len(next) == 0 {
+			lanes[column].active = false
+		} else {
+			lanes[column] = lane{target: next[0], active: true}
+		}
+		if len(next) > 1 {
+			branches := []int{}
+			for _, n := range next[1:] {
+				i := free(column)
+				branches = append(branches, i)
+				lanes[i] = lane{target: n, active: true}
+			}
+
+			lo, hi := column, column
+			for _, i := range branches {
+				if i < lo {
+					lo = i
+				}
+				if i > hi {
+					hi = i
+				}
+			}
+			cells := r.cells(lanes)
+			r.horizontal(cells, lo, hi)
+			for _, i := range branches {
+				cells[2*i] = c.teeDown
+			}
+			if lo < column {
+				cells[2*lo] = c.downRight
+			}
+			if hi > column {
+				cells[2*hi] = c.downLeft
+			}
+			switch {
+			case lo < column && hi > column:
+				cells[2*column] = c.cross
+			case lo < column:
+				cells[2*column] = c.teeLeft
+			default:
+				cells[2*column] = c.teeRight
+			}
+			lines = append(lines, draw(cells))
+		}
+
+		for len(lanes) > 0 && !lanes[len(lanes)-1].active {
+			lanes = lanes[:len(lanes)-1]
+		}
+	}
+
+	// the widest lanes must leave room for a space & a label character
+	if r.width > 0 && widest+2 > r.width {
+		return nil, false
+	}
+	return lines, true
+}
+
+// cells returns a line with the active lanes, lanes are 2 cells apart
+func (r *textRenderer) cells(lanes []lane) []rune {
+	cells := []rune(strings.Repeat(" ", 2*len(lanes)-1))
+	for i, l := range lanes {
+		if l.active {
+			cells[2*i] = r.chars.vertical
+		}
+	}
+	return cells
+}
+
+// horizontal draws an edge from lane lo to lane hi, the edge is drawn below the lanes it crosses
+func (r *textRenderer) horizontal(cells []rune, lo int, hi int) {
+	for x := 2 * lo; x <= 2*hi; x++ {
+		if cells[x] == ' ' {
+			cells[x] = r.chars.horizontal
+		}
+	}
+}
+
+// row appends a label to the lanes, truncating the label to the width
+func (r *textRenderer) row(prefix string, label string) string {
+	return prefix + r.truncate(label, r.width-utf8.RuneCountInString(prefix))
+}
+
+// treeRow appends a label & a marker, e.g. (*), to the levels of the branches of a tree. the marker is always kept &
+// the label is truncated to the width. levels that leave no room for the marker & the ellipsis of a truncated label
+// are cut from the left & replaced by the ellipsis, so deep rows stay within the width
+func (r *textRenderer) treeRow(levels []string, label string, marker string) string {
+	prefix := strings.Join(levels, "")
+	if r.width == 0 {
+		return prefix + label + marker
+	}
+
+	ellipsis := r.chars.ellipsis
+	need := utf8.RuneCountInString(label)
+	if length := utf8.RuneCountInString(ellipsis); need > length {
+		need = length
+	}
+	need += utf8.RuneCountInString(marker)
+	for cut := 1; utf8.RuneCountInString(prefix)+need > r.width && cut <= len(levels); cut++ {
+		prefix = ellipsis + strings.Join(levels[cut:], "")
+	}
+	if utf8.RuneCountInString(prefix)+need > r.width {
+		prefix = ""
+	}
+	return prefix + r.truncate(label, r.width-utf8.RuneCountInString(prefix)-utf8.RuneCountInString(marker)) + marker
+}
+
+// truncate shortens a label to the width, ending it with the ellipsis. labels are cut to the ellipsis when there is no
+// room for any of their characters, & to their first characters when there is no room for the ellipsis either
+func (r *textRenderer) truncate(label string, width int) string {
+	if r.width == 0 || utf8.RuneCountInString(label) <= width {
+		return label
+	}
+	if width < 1 {
+		return ""
+	}
+	ellipsis := r.chars.ellipsis
+	runes := []rune(label)
+	keep := width - utf8.RuneCountInString(ellipsis)
+	switch {
+	case keep > 0:
+		return string(runes[:keep]) + ellipsis
+	case keep == 0:
+		return ellipsis
+	default:
+		return string(runes[:width])
+	}
+}
+
+// treeLines draws the graph as an indented tree of its roots
+func (r *textRenderer) treeLines(vertices []Vertex, edges Edges) []string {
+	c := r.chars
+	hasPrev := map[Vertex]bool{}
+	for _, v := range vertices {
+		for _, next := range edges[v] {
+			hasPrev[next] = true
+		}
+	}
+
+	lines := []string{}
+	written := map[Vertex]bool{}
+	// levels holds the branch of a vertex after the indents of its ancestors
+	var walk func(v Vertex, levels []string)
+	walk = func(v Vertex, levels []string) {
+		if written[v] {
+			lines = append(lines, r.treeRow(levels, v, " (*)"))
+			return
+		}
+		lines = append(lines, r.treeRow(levels, v, ""))
+		written[v] = true
+
+		indents := levels
+		if len(levels) > 0 {
+			indent := c.treeIndent
+			if levels[len(levels)-1] == c.treeBranch {
+				indent = c.treeVertical
+			}
+			indents = append(levels[:len(levels)-1:len(levels)-1], indent)
+		}
+		next := edges[v]
+		for i, n := range next {
+			branch := c.treeBranch
+			if i == len(next)-1 {
+				branch = c.treeLast
+			}
+			walk(n, append(indents[:len(indents):len(indents)], branch))
+		}
+	}
+
+	for _, v := range vertices {
+		if !hasPrev[v] {
+			walk(v, nil)
+		}
+	}
+	return lines
+}
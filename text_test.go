@@ -0,0 +1,144 @@
+package dag_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteText(t *testing.T) {
+	newGraph := func(t *testing.T) *dag.Graph {
+		// A -> B -> C, A -> D -> E -> F, B -> E
+		g, err := dag.New(
+			dag.WithVertices([]dag.Vertex{"A", "B", "C", "D", "E", "F"}),
+			dag.WithEdges(dag.Edges{"A": {"B", "D"}, "B": {"C", "E"}, "D": {"E"}, "E": {"F"}}),
+		)
+		assert.Nil(t, err)
+		return g
+	}
+
+	t.Run("should draw lanes between layers", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.Nil(t, newGraph(t).WriteText(&out))
+		assert.Equal(t, `● A
+├─╮
+● │ B
+├─│─╮
+│ ● │ D
+● │ │ C
+╭─┴─╯
+● E
+● F
+`, out.String())
+	})
+
+	t.Run("should draw with ascii characters", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.Nil(t, newGraph(t).WriteText(&out, dag.WithASCII()))
+		assert.Equal(t, `* A
++-.
+* | B
++-|-.
+| * | D
+* | | C
+.-+-'
+* E
+* F
+`, out.String())
+	})
+
+	t.Run("should move vertices left over free lanes", func(t *testing.T) {
+		g, err := dag.New(
+			dag.WithVertices([]dag.Vertex{"X", "Y", "A", "B"}),
+			dag.WithEdges(dag.Edges{"X": {"A"}, "Y": {"A", "B"}}),
+		)
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteText(&out))
+		assert.Equal(t, `● X
+│ ● Y
+│ ├─╮
+├─╯ │
+●   │ A
+╭───╯
+● B
+`, out.String())
+	})
+
+	t.Run("should write trees", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.Nil(t, newGraph(t).WriteText(&out, dag.WithTree(), dag.WithASCII()))
+		assert.Equal(t, "A\n|-- B\n|   |-- C\n|   `-- E\n|       `-- F\n`-- D\n    `-- E (*)\n", out.String())
+	})
+
+	t.Run("should fall back to trees for wide graphs", func(t *testing.T) {
+		g, err := dag.New(
+			dag.WithVertices([]dag.Vertex{"R", "A", "B", "C", "D"}),
+			dag.WithEdges(dag.Edges{"R": {"A", "B", "C", "D"}}),
+		)
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteText(&out, dag.WithWidth(8)))
+		assert.Equal(t, "R\n├── A\n├── B\n├── C\n└── D\n", out.String())
+
+		out.Reset()
+		assert.Nil(t, g.WriteText(&out, dag.WithWidth(9)))
+		assert.Equal(t, `● R
+├─┬─┬─╮
+● │ │ │ A
+╭─╯ │ │
+●   │ │ B
+╭───╯ │
+●     │ C
+╭─────╯
+● D
+`, out.String())
+	})
+
+	t.Run("should truncate labels to the width", func(t *testing.T) {
+		g, err := dag.New(dag.WithVertices([]dag.Vertex{"a-very-long-vertex", "b"}), dag.WithEdges(dag.Edges{"a-very-long-vertex": {"b"}}))
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteText(&out, dag.WithWidth(10)))
+		assert.Equal(t, "● a-very-…\n● b\n", out.String())
+
+		out.Reset()
+		assert.Nil(t, g.WriteText(&out, dag.WithWidth(0)))
+		assert.Equal(t, "● a-very-long-vertex\n● b\n", out.String())
+	})
+
+	t.Run("should keep repeat markers & the width in narrow trees", func(t *testing.T) {
+		g, err := dag.New(
+			dag.WithVertices([]dag.Vertex{"root", "middle", "shared-vertex"}),
+			dag.WithEdges(dag.Edges{"root": {"shared-vertex", "middle"}, "middle": {"shared-vertex"}}),
+		)
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		assert.Nil(t, g.WriteText(&out, dag.WithTree(), dag.WithWidth(12)))
+		assert.Equal(t, "root\n├── shared-…\n└── middle\n…└── sh… (*)\n", out.String())
+
+		out.Reset()
+		assert.Nil(t, g.WriteText(&out, dag.WithTree(), dag.WithWidth(10)))
+		assert.Equal(t, "root\n├── share…\n└── middle\n…└── … (*)\n", out.String())
+
+		out.Reset()
+		assert.Nil(t, newGraph(t).WriteText(&out, dag.WithTree(), dag.WithASCII(), dag.WithWidth(8)))
+		assert.Equal(t, "A\n|-- B\n...|-- C\n...`-- E\n...`-- F\n`-- D\n...E (*)\n", out.String())
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			assert.LessOrEqual(t, len(line), 8, line)
+		}
+	})
+
+	t.Run("should return error for negative widths", func(t *testing.T) {
+		var out bytes.Buffer
+		err := newGraph(t).WriteText(&out, dag.WithWidth(-1))
+		assert.EqualError(t, err, "could not write text: width must not be negative, got -1")
+	})
+}
@@ -0,0 +1,83 @@
+package dag
+
+import "fmt"
+
+// TriggerRule decides whether a vertex runs once all of its previous vertices are finished
+type TriggerRule string
+
+const (
+	// TriggerAllSuccess runs the vertex when all previous vertices succeeded, it is the default rule
+	TriggerAllSuccess TriggerRule = "all_success"
+	// TriggerAllDone runs the vertex regardless of the states of previous vertices
+	TriggerAllDone TriggerRule = "all_done"
+	// TriggerAllFailed runs the vertex when all previous vertices failed or are skipped
+	TriggerAllFailed TriggerRule = "all_failed"
+	// TriggerOneSuccess runs the vertex when at least one previous vertex succeeded
+	TriggerOneSuccess TriggerRule = "one_success"
+	// TriggerOneFailed runs the vertex when at least one previous vertex failed
+	TriggerOneFailed TriggerRule = "one_failed"
+	// TriggerNoneFailed runs the vertex when none of the previous vertices failed, skipped vertices are allowed
+	TriggerNoneFailed TriggerRule = "none_failed"
+)
+
+// Valid checks if the rule is one of the known trigger rules
+func (r TriggerRule) Valid() bool {
+	switch r {
+	case TriggerAllSuccess, TriggerAllDone, TriggerAllFailed, TriggerOneSuccess, TriggerOneFailed, TriggerNoneFailed:
+		return true
+	}
+	return false
+}
+
+// satisfied checks the rule against the terminal states of previous vertices. vertices without previous vertices always run
+func (r TriggerRule) satisfied(states []State) bool {
+	if len(states) == 0 {
+		return true
+	}
+
+	succeeded := func(s State) bool { return s == StateSucceeded }
+	failed := func(s State) bool { return s == StateFailed }
+
+	switch r {
+	case TriggerAllDone:
+		return true
+	case TriggerAllFailed:
+		return !some(states, succeeded)
+	case TriggerOneSuccess:
+		return some(states, succeeded)
+	case TriggerOneFailed:
+		return some(states, failed)
+	case TriggerNoneFailed:
+		return !some(states, failed)
+	default:
+		return !some(states, func(s State) bool { return !succeeded(s) })
+	}
+}
+
+// TriggerRuleFunc returns the trigger rule of a vertex
+type TriggerRuleFunc func(vertex Vertex) TriggerRule
+
+// WithTriggerRules sets the trigger rules of vertices, vertices that do not satisfy their rule are skipped
+func WithTriggerRules(rules TriggerRuleFunc) ExecutorOptions {
+	return func(e *Executor) error {
+		if rules == nil {
+			return fmt.Errorf("trigger rule func is nil")
+		}
+		e.triggerRules = rules
+		return nil
+	}
+}
+
+// triggered checks if a vertex whose previous vertices are finished should run
+func (e *Executor) triggered(vertex Vertex, prev []Vertex, results Results) bool {
+	rule := TriggerAllSuccess
+	if e.triggerRules != nil {
+		rule = e.triggerRules(vertex)
+	}
+
+	states := make([]State, 0, len(prev))
+	for _, prevVertex := range prev {
+		states = append(states, results[prevVertex].State)
+	}
+	return rule.satisfied(states)
+}
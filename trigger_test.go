@@ -0,0 +1,85 @@
+package dag_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerRules(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		t.Run("should validate known rules", func(t *testing.T) {
+			assert.True(t, dag.TriggerAllSuccess.Valid())
+			assert.True(t, dag.TriggerNoneFailed.Valid())
+			assert.False(t, dag.TriggerRule("sometimes").Valid())
+		})
+	})
+
+	t.Run("WithTriggerRules", func(t *testing.T) {
+		t.Run("should return error when rule func is nil", func(t *testing.T) {
+			_, err := dag.NewExecutor(createGraph(), concatTask, dag.WithTriggerRules(nil))
+			assert.NotNil(t, err)
+		})
+
+		// A -> B -> C, A -> D -> E -> F, B -> E with B failing
+		expected := map[dag.TriggerRule]dag.State{
+			dag.TriggerAllSuccess: dag.StateSkipped,
+			dag.TriggerAllDone:    dag.StateSucceeded,
+			dag.TriggerAllFailed:  dag.StateSkipped,
+			dag.TriggerOneSuccess: dag.StateSucceeded,
+			dag.TriggerOneFailed:  dag.StateSucceeded,
+			dag.TriggerNoneFailed: dag.StateSkipped,
+		}
+		for rule, state := range expected {
+			rule, state := rule, state
+			t.Run(fmt.Sprintf("should apply %s rule", rule), func(t *testing.T) {
+				task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+					if vertex == "B" {
+						return nil, fmt.Errorf("B failed")
+					}
+					return nil, nil
+				}
+
+				executor, err := dag.NewExecutor(createGraph(), task, dag.WithTriggerRules(func(vertex dag.Vertex) dag.TriggerRule {
+					if vertex == "E" {
+						return rule
+					}
+					return dag.TriggerAllSuccess
+				}))
+				assert.Nil(t, err)
+
+				results, _ := executor.Run(context.Background())
+				assert.Equal(t, state, results["E"].State)
+				assert.Equal(t, dag.StateSkipped, results["C"].State)
+			})
+		}
+
+		t.Run("should run cleanup vertices after failures", func(t *testing.T) {
+			g, err := dag.New(dag.WithVertices([]dag.Vertex{"A", "B", "cleanup"}), dag.WithEdges(dag.Edges{"A": {"B"}, "B": {"cleanup"}}))
+			assert.Nil(t, err)
+
+			task := func(ctx context.Context, vertex dag.Vertex, inputs map[dag.Vertex][]byte) ([]byte, error) {
+				if vertex == "A" {
+					return nil, fmt.Errorf("A failed")
+				}
+				return nil, nil
+			}
+
+			executor, err := dag.NewExecutor(g, task, dag.WithTriggerRules(func(vertex dag.Vertex) dag.TriggerRule {
+				if vertex == "cleanup" {
+					return dag.TriggerAllDone
+				}
+				return dag.TriggerAllSuccess
+			}))
+			assert.Nil(t, err)
+
+			results, err := executor.Run(context.Background())
+			assert.NotNil(t, err)
+			assert.Equal(t, dag.StateSkipped, results["B"].State)
+			assert.Equal(t, dag.StateSucceeded, results["cleanup"].State)
+		})
+	})
+}
@@ -0,0 +1,216 @@
+package dag
+
+// Tx is a transaction of a graph, its mutations are applied under the lock of the graph & rolled back together.
+// every mutation records an inverse operation that reverts it & the operation itself, so that a transaction can be
+// undone & applied again.
+//
+// next vertices of a vertex are only appended to or replaced by the graph, never changed in place, so inverse
+// operations restore the next vertices that are kept before a mutation. inverse operations are only valid in reverse
+// order of their mutations
+type Tx struct {
+	graph *Graph
+	ops   []func(tx *Tx) error
+	undo  []func(g *Graph)
+	// touched holds the vertices whose next vertices or payloads are changed by the transaction
+	touched []Vertex
+}
+
+// Tx applies the mutations of fn atomically, other readers & writers of the graph wait until fn returns.
+// when fn returns an error or panics, the mutations that are already applied are reverted in reverse order.
+//
+// the graph must not be used inside fn, the graph of the transaction is read with tx.Graph() instead
+//
+//	err := g.Tx(func(tx *dag.Tx) error {
+//		if err := tx.Add("lint"); err != nil {
+//			return err
+//		}
+//		return tx.Connect("lint", "build")
+//	})
+func (g *Graph) Tx(fn func(tx *Tx) error) error {
+	_, err := g.tx(fn)
+	return err
+}
+
+// tx applies a transaction & returns it when it is committed
+func (g *Graph) tx(fn func(tx *Tx) error) (tx *Tx, err error) {
+	if g.readOnly {
+		return nil, errReadOnly()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// the graph of the transaction shares the vertices, edges & payloads of the graph with a lock of its own, so that
+	// the methods of the graph can be used while the lock of the graph is held
+	tx = &Tx{graph: &Graph{vertices: g.vertices, edges: g.edges, payloads: g.payloads}}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.revert(tx.graph)
+			g.vertices = tx.graph.vertices
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.revert(tx.graph)
+		g.vertices = tx.graph.vertices
+		return nil, err
+	}
+	g.vertices = tx.graph.vertices
+	return tx, nil
+}
+
+// revert applies the inverse operations of the transaction in reverse order
+func (tx *Tx) revert(g *Graph) {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i](g)
+	}
+}
+
+// record records an applied operation, its inverse operation & the vertices it changes
+func (tx *Tx) record(op func(tx *Tx) error, inverse func(g *Graph), touched ...Vertex) {
+	tx.ops = append(tx.ops, op)
+	tx.undo = append(tx.undo, inverse)
+	tx.touched = append(tx.touched, touched...)
+}
+
+// Graph returns the graph with the mutations of the transaction, it must only be read
+func (tx *Tx) Graph() *Graph {
+	return tx.graph
+}
+
+// Add adds unconnected vertices like Graph.Add, vertices that are added before a duplicate vertex are kept until
+// the transaction is rolled back
+func (tx *Tx) Add(vertices ...Vertex) error {
+	before := len(tx.graph.vertices)
+	err := tx.graph.Add(vertices...)
+
+	added := append([]Vertex{}, tx.graph.vertices[before:]...)
+	if len(added) > 0 {
+		tx.record(func(tx *Tx) error {
+			return tx.Add(added...)
+		}, func(g *Graph) {
+			g.vertices = exclude(g.vertices, added...)
+			for _, v := range added {
+				delete(g.edges, v)
+			}
+		}, added...)
+	}
+	return err
+}
+
+// Append adds a vertex that is connected to the given previous vertices like Graph.Append
+func (tx *Tx) Append(v Vertex, prevVertices []Vertex) error {
+	edges := make(Edges, len(prevVertices))
+	for _, prevVertex := range prevVertices {
+		edges[prevVertex] = tx.graph.edges[prevVertex]
+	}
+	if err := tx.graph.Append(v, prevVertices); err != nil {
+		return err
+	}
+
+	prevVertices = append([]Vertex{}, prevVertices...)
+	tx.record(func(tx *Tx) error {
+		return tx.Append(v, prevVertices)
+	}, func(g *Graph) {
+		for prevVertex, next := range edges {
+			g.edges[prevVertex] = next
+		}
+		g.vertices = exclude(g.vertices, v)
+		delete(g.edges, v)
+	}, append([]Vertex{v}, prevVertices...)...)
+	return nil
+}
+
+// Connect connects two vertices like Graph.Connect
+func (tx *Tx) Connect(from Vertex, to Vertex) error {
+	next := tx.graph.edges[from]
+	if err := tx.graph.Connect(from, to); err != nil {
+		return err
+	}
+
+	tx.record(func(tx *Tx) error {
+		return tx.Connect(from, to)
+	}, func(g *Graph) {
+		g.edges[from] = next
+	}, from)
+	return nil
+}
+
+// DisconnectEdge disconnects two vertices like Graph.DisconnectEdge
+func (tx *Tx) DisconnectEdge(from Vertex, to Vertex) error {
+	next := tx.graph.edges[from]
+	if err := tx.graph.DisconnectEdge(from, to); err != nil {
+		return err
+	}
+
+	tx.record(func(tx *Tx) error {
+		return tx.DisconnectEdge(from, to)
+	}, func(g *Graph) {
+		g.edges[from] = next
+	}, from)
+	return nil
+}
+
+// Remove removes a vertex & its next vertices like Graph.Remove, the vertices, their edges & payloads are restored
+// at their positions on rollback
+func (tx *Tx) Remove(v Vertex) ([]Vertex, error) {
+	vertices := append([]Vertex{}, tx.graph.vertices...)
+	edges := make(Edges, len(tx.graph.edges))
+	for vertex, next := range tx.graph.edges {
+		edges[vertex] = next
+	}
+	payloads := make(map[Vertex]any, len(tx.graph.payloads))
+	for vertex, payload := range tx.graph.payloads {
+		payloads[vertex] = payload
+	}
+	removed, err := tx.graph.Remove(v)
+	if err != nil {
+		return removed, err
+	}
+
+	changed := Edges{}
+	for vertex, next := range edges {
+		if current, ok := tx.graph.edges[vertex]; !ok || len(current) != len(next) {
+			changed[vertex] = next
+		}
+	}
+	removedPayloads := map[Vertex]any{}
+	for _, vertex := range removed {
+		if payload, ok := payloads[vertex]; ok {
+			removedPayloads[vertex] = payload
+		}
+	}
+	tx.record(func(tx *Tx) error {
+		_, err := tx.Remove(v)
+		return err
+	}, func(g *Graph) {
+		g.vertices = append([]Vertex{}, vertices...)
+		for vertex, next := range changed {
+			g.edges[vertex] = next
+		}
+		for vertex, payload := range removedPayloads {
+			g.payloads[vertex] = payload
+		}
+	}, keys(changed)...)
+	return removed, nil
+}
+
+// SetPayload sets the payload of a vertex like Graph.SetPayload
+func (tx *Tx) SetPayload(vertex Vertex, payload any) error {
+	previous, existed := tx.graph.payloads[vertex]
+	if err := tx.graph.SetPayload(vertex, payload); err != nil {
+		return err
+	}
+
+	tx.record(func(tx *Tx) error {
+		return tx.SetPayload(vertex, payload)
+	}, func(g *Graph) {
+		if existed {
+			g.payloads[vertex] = previous
+		} else {
+			delete(g.payloads, vertex)
+		}
+	}, vertex)
+	return nil
+}
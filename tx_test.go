@@ -0,0 +1,122 @@
+package dag_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTx(t *testing.T) {
+	t.Run("should commit mutations", func(t *testing.T) {
+		g := createGraph()
+		err := g.Tx(func(tx *dag.Tx) error {
+			if err := tx.Add("G"); err != nil {
+				return err
+			}
+			if err := tx.Connect("F", "G"); err != nil {
+				return err
+			}
+			assert.True(t, tx.Graph().Exists("G"))
+			_, err := tx.Remove("C")
+			return err
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B", "D", "E", "F", "G"}, g.Vertices())
+		assert.Equal(t, dag.Edges{"A": {"B", "D"}, "B": {"E"}, "D": {"E"}, "E": {"F"}, "F": {"G"}, "G": {}}, g.Edges())
+	})
+
+	t.Run("should roll back all mutations on error", func(t *testing.T) {
+		g := createGraph()
+		assert.Nil(t, g.SetPayload("E", "e"))
+		assert.Nil(t, g.SetPayload("A", "a"))
+
+		err := g.Tx(func(tx *dag.Tx) error {
+			for _, op := range []func() error{
+				func() error { return tx.Add("G", "H") },
+				func() error { return tx.Append("I", []dag.Vertex{"C", "G"}) },
+				func() error { return tx.Connect("H", "A") },
+				func() error { return tx.DisconnectEdge("A", "B") },
+				func() error { return tx.SetPayload("A", "changed") },
+				func() error { return tx.SetPayload("G", "g") },
+				func() error { _, err := tx.Remove("C"); return err },
+				func() error { return tx.Connect("E", "A") },
+			} {
+				if err := op(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		assert.EqualError(t, err, "could not connect nodes. reason: cyclic edges are not allowed from E to A")
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F"}, g.Vertices())
+		assert.Equal(t, dag.Edges{"A": {"B", "D"}, "B": {"C", "E"}, "C": {}, "D": {"E"}, "E": {"F"}, "F": {}}, g.Edges())
+		payload, _ := g.Payload("A")
+		assert.Equal(t, "a", payload)
+		payload, _ = g.Payload("E")
+		assert.Equal(t, "e", payload)
+		_, ok := g.Payload("G")
+		assert.False(t, ok)
+	})
+
+	t.Run("should roll back vertices that are added before a duplicate vertex", func(t *testing.T) {
+		g := createGraph()
+		err := g.Tx(func(tx *dag.Tx) error {
+			if err := tx.Add("G", "A"); err != nil {
+				assert.True(t, tx.Graph().Exists("G"))
+				return err
+			}
+			return nil
+		})
+		assert.EqualError(t, err, "vertex A already added. vertices must be unique")
+		assert.False(t, g.Exists("G"))
+	})
+
+	t.Run("should roll back on panic", func(t *testing.T) {
+		g := createGraph()
+		assert.Panics(t, func() {
+			_ = g.Tx(func(tx *dag.Tx) error {
+				_ = tx.Add("G")
+				panic("boom")
+			})
+		})
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F"}, g.Vertices())
+
+		// the lock of the graph is released
+		assert.Nil(t, g.Add("G"))
+	})
+
+	t.Run("should block other writers until commit", func(t *testing.T) {
+		g := createGraph()
+		done := make(chan error)
+		err := g.Tx(func(tx *dag.Tx) error {
+			go func() {
+				done <- g.Connect("F", "G")
+			}()
+			return tx.Add("G")
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, <-done)
+		next, _ := g.Next("F")
+		assert.Equal(t, []dag.Vertex{"G"}, next)
+	})
+
+	t.Run("should apply large batches", func(t *testing.T) {
+		g, err := dag.New()
+		assert.Nil(t, err)
+		err = g.Tx(func(tx *dag.Tx) error {
+			prev := []dag.Vertex{}
+			for i := 0; i < 100; i++ {
+				if err := tx.Append(fmt.Sprintf("v%d", i), prev); err != nil {
+					return err
+				}
+				prev = []dag.Vertex{fmt.Sprintf("v%d", i)}
+			}
+			return nil
+		})
+		assert.Nil(t, err)
+		sorted, _ := g.TopSort()
+		assert.Len(t, sorted, 100)
+	})
+}
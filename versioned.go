@@ -0,0 +1,210 @@
+package dag
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// revisionCheckpoint is the interval of revisions that keep all next vertices & payloads
+const revisionCheckpoint = 64
+
+// Revision is a committed version of a VersionedGraph
+type Revision struct {
+	// Number is 0 for the graph the versioned graph is created with & increases by 1 with each commit
+	Number int
+	Time   time.Time
+}
+
+// revision keeps the vertices of a revision & the next vertices & payloads that changed since the previous revision.
+// next vertices are only appended to or replaced by the graph, so unchanged next vertices & payloads are shared with
+// previous revisions. checkpoints keep all next vertices & payloads, so that a revision is built from the checkpoint
+// before it & the changes after the checkpoint
+type revision struct {
+	Revision
+	vertices []Vertex
+	edges    Edges
+	payloads map[Vertex]revisionPayload
+}
+
+// revisionPayload is the payload of a vertex in a revision, ok is false when the vertex has no payload
+type revisionPayload struct {
+	value any
+	ok    bool
+}
+
+// VersionedGraph is a graph that keeps every committed version, e.g. to audit how a dependency graph looked at some
+// time. mutations are committed as transactions, each commit creates a revision that can be read with At & compared
+// with Diff. payloads are shared between revisions & must not be changed in place
+type VersionedGraph struct {
+	mu        sync.RWMutex
+	graph     *Graph
+	revisions []*revision
+}
+
+// NewVersionedGraph creates a versioned graph with the given graph as revision 0, the graph must only be mutated
+// through Commit afterwards
+func NewVersionedGraph(g *Graph) (*VersionedGraph, error) {
+	if g == nil {
+		return nil, fmt.Errorf("could not create versioned graph. graph is nil")
+	}
+	if g.readOnly {
+		return nil, errors.Wrap(errReadOnly(), "could not create versioned graph")
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	v := &VersionedGraph{graph: g}
+	v.revisions = []*revision{fullRevision(Revision{Time: time.Now().Round(0)}, g)}
+	return v, nil
+}
+
+// fullRevision creates a revision with all next vertices & payloads of a graph
+func fullRevision(rev Revision, g *Graph) *revision {
+	r := &revision{Revision: rev, vertices: g.vertices, edges: make(Edges, len(g.edges)), payloads: make(map[Vertex]revisionPayload, len(g.payloads))}
+	for vertex, next := range g.edges {
+		r.edges[vertex] = next
+	}
+	for vertex, payload := range g.payloads {
+		r.payloads[vertex] = revisionPayload{value: payload, ok: true}
+	}
+	return r
+}
+
+// Commit applies the mutations of fn atomically like Graph.Tx & creates a revision of the result. commits that return
+// an error create no revision, commits without mutations return the head revision
+func (v *VersionedGraph) Commit(fn func(tx *Tx) error) (Revision, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var committed *revision
+	_, err := v.graph.tx(func(tx *Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if len(tx.ops) > 0 {
+			committed = v.newRevision(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return Revision{}, err
+	}
+	if committed == nil {
+		return v.revisions[len(v.revisions)-1].Revision, nil
+	}
+	v.revisions = append(v.revisions, committed)
+	return committed.Revision, nil
+}
+
+// newRevision creates the next revision from the vertices that are changed by a transaction
+func (v *VersionedGraph) newRevision(tx *Tx) *revision {
+	rev := Revision{Number: len(v.revisions), Time: time.Now().Round(0)}
+	if rev.Number%revisionCheckpoint == 0 {
+		return fullRevision(rev, tx.graph)
+	}
+
+	r := &revision{Revision: rev, vertices: tx.graph.vertices, edges: Edges{}, payloads: map[Vertex]revisionPayload{}}
+	for _, vertex := range tx.touched {
+		if next, ok := tx.graph.edges[vertex]; ok {
+			r.edges[vertex] = next
+		}
+		payload, ok := tx.graph.payloads[vertex]
+		r.payloads[vertex] = revisionPayload{value: payload, ok: ok}
+	}
+	return r
+}
+
+// Head returns the latest revision
+func (v *VersionedGraph) Head() Revision {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.revisions[len(v.revisions)-1].Revision
+}
+
+// Revisions returns all revisions from the oldest to the latest
+func (v *VersionedGraph) Revisions() []Revision {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	revisions := make([]Revision, len(v.revisions))
+	for i, r := range v.revisions {
+		revisions[i] = r.Revision
+	}
+	return revisions
+}
+
+// At returns a read-only view of the graph at a revision, its mutations return errors. the view can be copied with
+// DeepCopy to be changed
+func (v *VersionedGraph) At(number int) (*Graph, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.at(number)
+}
+
+func (v *VersionedGraph) at(number int) (*Graph, error) {
+	if number < 0 || number >= len(v.revisions) {
+		return nil, fmt.Errorf("revision %d is not found. the head revision is %d", number, len(v.revisions)-1)
+	}
+
+	// later revisions replace the next vertices & payloads of earlier revisions, starting from the last checkpoint
+	start := number - number%revisionCheckpoint
+	edges, payloads := Edges{}, map[Vertex]revisionPayload{}
+	for _, r := range v.revisions[start : number+1] {
+		for vertex, next := range r.edges {
+			edges[vertex] = next
+		}
+		for vertex, payload := range r.payloads {
+			payloads[vertex] = payload
+		}
+	}
+
+	// views share the vertices & next vertices of revisions, their capacity is limited so that appends copy them
+	target := v.revisions[number]
+	view := &Graph{
+		vertices: target.vertices[:len(target.vertices):len(target.vertices)],
+		edges:    make(Edges, len(target.vertices)),
+		payloads: map[Vertex]any{},
+		readOnly: true,
+	}
+	for _, vertex := range target.vertices {
+		next := edges[vertex]
+		view.edges[vertex] = next[:len(next):len(next)]
+		if payload := payloads[vertex]; payload.ok {
+			view.payloads[vertex] = payload.value
+		}
+	}
+	return view, nil
+}
+
+// AtTime returns a read-only view of the graph at the latest revision that is committed at or before the given time
+func (v *VersionedGraph) AtTime(t time.Time) (*Graph, Revision, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for i := len(v.revisions) - 1; i >= 0; i-- {
+		if !v.revisions[i].Time.After(t) {
+			g, err := v.at(i)
+			return g, v.revisions[i].Revision, err
+		}
+	}
+	return nil, Revision{}, fmt.Errorf("there is no revision at %s. the first revision is at %s", t.Format(time.RFC3339), v.revisions[0].Time.Format(time.RFC3339))
+}
+
+// Diff returns the difference from a revision to another revision
+func (v *VersionedGraph) Diff(from int, to int) (*GraphDiff, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	a, err := v.at(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not diff revisions")
+	}
+	b, err := v.at(to)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not diff revisions")
+	}
+	return Diff(a, b), nil
+}
@@ -0,0 +1,174 @@
+package dag_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aacanakin/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedGraph(t *testing.T) {
+	t.Run("should keep every revision", func(t *testing.T) {
+		v, err := dag.NewVersionedGraph(createGraph())
+		assert.Nil(t, err)
+
+		rev, err := v.Commit(func(tx *dag.Tx) error {
+			if err := tx.Append("G", []dag.Vertex{"F"}); err != nil {
+				return err
+			}
+			return tx.SetPayload("G", "g")
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, rev.Number)
+		rev, err = v.Commit(func(tx *dag.Tx) error {
+			_, err := tx.Remove("E")
+			return err
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, rev.Number)
+		assert.Equal(t, rev, v.Head())
+
+		g, err := v.At(0)
+		assert.Nil(t, err)
+		assert.Equal(t, dag.Edges{"A": {"B", "D"}, "B": {"C", "E"}, "C": {}, "D": {"E"}, "E": {"F"}, "F": {}}, g.Edges())
+		g, err = v.At(1)
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D", "E", "F", "G"}, g.Vertices())
+		payload, _ := g.Payload("G")
+		assert.Equal(t, "g", payload)
+		g, err = v.At(2)
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"A", "B", "C", "D"}, g.Vertices())
+		next, _ := g.Next("B")
+		assert.Equal(t, []dag.Vertex{"C"}, next)
+
+		_, err = v.At(3)
+		assert.EqualError(t, err, "revision 3 is not found. the head revision is 2")
+	})
+
+	t.Run("should not create revisions for failed & empty commits", func(t *testing.T) {
+		v, err := dag.NewVersionedGraph(createGraph())
+		assert.Nil(t, err)
+
+		_, err = v.Commit(func(tx *dag.Tx) error {
+			_ = tx.Add("G")
+			return tx.Connect("F", "A")
+		})
+		assert.NotNil(t, err)
+		rev, err := v.Commit(func(tx *dag.Tx) error {
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, rev.Number)
+		assert.Len(t, v.Revisions(), 1)
+	})
+
+	t.Run("should return read-only views", func(t *testing.T) {
+		v, err := dag.NewVersionedGraph(createGraph())
+		assert.Nil(t, err)
+		g, err := v.At(0)
+		assert.Nil(t, err)
+
+		assert.EqualError(t, g.Add("G"), "graph is read-only")
+		assert.EqualError(t, g.Connect("C", "F"), "could not connect vertex C to vertex F: graph is read-only")
+		assert.EqualError(t, g.DisconnectEdge("A", "B"), "could not disconnect graph node prev=A next=B: graph is read-only")
+		_, err = g.Remove("A")
+		assert.EqualError(t, err, "could not remove node: graph is read-only")
+		assert.EqualError(t, g.SetPayload("A", 1), "graph is read-only")
+		assert.EqualError(t, g.Tx(func(tx *dag.Tx) error { return nil }), "graph is read-only")
+
+		copied, err := g.DeepCopy()
+		assert.Nil(t, err)
+		assert.Nil(t, copied.Connect("C", "F"))
+		unchanged, _ := v.At(0)
+		next, _ := unchanged.Next("C")
+		assert.Empty(t, next)
+	})
+
+	t.Run("should share unchanged next vertices between revisions", func(t *testing.T) {
+		v, err := dag.NewVersionedGraph(createGraph())
+		assert.Nil(t, err)
+		for i := 0; i < 200; i++ {
+			vertex := fmt.Sprintf("v%d", i)
+			_, err := v.Commit(func(tx *dag.Tx) error {
+				return tx.Append(vertex, []dag.Vertex{"F"})
+			})
+			assert.Nil(t, err)
+		}
+
+		for _, number := range []int{0, 1, 63, 64, 65, 130, 200} {
+			g, err := v.At(number)
+			assert.Nil(t, err)
+			assert.Len(t, g.Vertices(), 6+number)
+			next, _ := g.Next("F")
+			assert.Len(t, next, number)
+			next, _ = g.Next("A")
+			assert.Equal(t, []dag.Vertex{"B", "D"}, next)
+		}
+	})
+
+	t.Run("should find revisions by time", func(t *testing.T) {
+		v, err := dag.NewVersionedGraph(createGraph())
+		assert.Nil(t, err)
+		time.Sleep(time.Millisecond)
+		_, err = v.Commit(func(tx *dag.Tx) error {
+			return tx.Add("G")
+		})
+		assert.Nil(t, err)
+
+		revisions := v.Revisions()
+		g, rev, err := v.AtTime(revisions[1].Time.Add(-time.Nanosecond))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, rev.Number)
+		assert.False(t, g.Exists("G"))
+		g, rev, err = v.AtTime(time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, rev.Number)
+		assert.True(t, g.Exists("G"))
+
+		_, _, err = v.AtTime(revisions[0].Time.Add(-time.Hour))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("should diff revisions", func(t *testing.T) {
+		v, err := dag.NewVersionedGraph(createGraph())
+		assert.Nil(t, err)
+		_, err = v.Commit(func(tx *dag.Tx) error {
+			if err := tx.Append("G", []dag.Vertex{"C", "F"}); err != nil {
+				return err
+			}
+			if err := tx.DisconnectEdge("A", "D"); err != nil {
+				return err
+			}
+			return tx.SetPayload("B", "b")
+		})
+		assert.Nil(t, err)
+		_, err = v.Commit(func(tx *dag.Tx) error {
+			_, err := tx.Remove("D")
+			return err
+		})
+		assert.Nil(t, err)
+
+		diff, err := v.Diff(0, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, &dag.GraphDiff{
+			RemovedVertices: []dag.Vertex{"D", "E", "F"},
+			RemovedEdges:    []dag.Edge{{From: "A", To: "D"}, {From: "B", To: "E"}, {From: "D", To: "E"}, {From: "E", To: "F"}},
+			ChangedPayloads: []dag.PayloadChange{{Vertex: "B", After: "b"}},
+		}, diff)
+
+		diff, err = v.Diff(0, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, []dag.Vertex{"G"}, diff.AddedVertices)
+		assert.Equal(t, []dag.Edge{{From: "C", To: "G"}, {From: "F", To: "G"}}, diff.AddedEdges)
+
+		diff, err = v.Diff(2, 2)
+		assert.Nil(t, err)
+		assert.True(t, diff.Empty())
+
+		_, err = v.Diff(0, 5)
+		assert.EqualError(t, err, "could not diff revisions: revision 5 is not found. the head revision is 2")
+	})
+}